@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/mtls"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// List agent certificates
+// @Summary List agent certificates
+// @Security BearerAuth
+// @Schemes
+// @Description List client certificates issued to agents
+// @Tags auth required
+// @Param id query uint false "Resource ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.AgentCertificate]
+// @Router /agent-certificate [get]
+func listAgentCertificate(c *gin.Context) ([]*model.AgentCertificate, error) {
+	return singleton.AgentCAShared.GetSortedList(), nil
+}
+
+// Issue agent certificate
+// @Summary Issue agent certificate
+// @Security BearerAuth
+// @Schemes
+// @Description Issue a client certificate for an existing server, the private key is only returned once
+// @Tags auth required
+// @param id path uint true "Server ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.AgentCertificateSecret]
+// @Router /server-credential/{id}/certificate [post]
+func issueAgentCertificate(c *gin.Context) (*model.AgentCertificateSecret, error) {
+	server, err := credentialServer(c)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, issued, err := singleton.AgentCAShared.Issue(server)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.AgentCertificateSecret{
+		ServerID:    server.ID,
+		Serial:      cert.Serial,
+		Certificate: string(mtls.EncodeCert(issued.CertDER)),
+		PrivateKey:  string(mtls.EncodeKey(issued.KeyDER)),
+		CA:          singleton.AgentCAShared.CACertificate(),
+	}, nil
+}
+
+// Revoke agent certificate
+// @Summary Revoke agent certificate
+// @Security BearerAuth
+// @Schemes
+// @Description Revoke a client certificate and add it to the revocation list
+// @Tags auth required
+// @param id path uint true "Certificate ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /agent-certificate/{id}/revoke [post]
+func revokeAgentCertificate(c *gin.Context) (any, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, ok := singleton.AgentCAShared.Get(id)
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("certificate id %d does not exist", id)
+	}
+	if !cert.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	if err := singleton.AgentCAShared.Revoke(func(ac *model.AgentCertificate) bool {
+		return ac.ID == id
+	}); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
+// Get agent CA
+// @Summary Get agent CA
+// @Security BearerAuth
+// @Schemes
+// @Description Get the CA certificate and the current revocation list for agent client certificates
+// @Tags auth required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.AgentCAResponse]
+// @Router /agent-ca [get]
+func getAgentCA(c *gin.Context) (*model.AgentCAResponse, error) {
+	crl, err := singleton.AgentCAShared.CRL()
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.AgentCAResponse{
+		Certificate: singleton.AgentCAShared.CACertificate(),
+		CRL:         crl,
+	}, nil
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/rpc"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// List agent connections
+// @Summary List agent connections
+// @Security BearerAuth
+// @Schemes
+// @Description List the task and state streams of connected agents
+// @Tags admin required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.AgentConnection]
+// @Router /agent-connection [get]
+func listAgentConnection(c *gin.Context) ([]*model.AgentConnection, error) {
+	return rpc.NezhaHandlerSingleton.AgentConnections(), nil
+}
+
+// Disconnect agent
+// @Summary Disconnect agent
+// @Security BearerAuth
+// @Schemes
+// @Description Forcibly close all streams of the server's agent, returns the number of closed streams
+// @Tags admin required
+// @param id path uint true "Server ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[int]
+// @Router /agent-connection/{id}/disconnect [post]
+func disconnectAgent(c *gin.Context) (int, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, ok := singleton.ServerShared.Get(id); !ok {
+		return 0, singleton.Localizer.ErrorT("server not found")
+	}
+
+	return rpc.NezhaHandlerSingleton.DisconnectAgent(id), nil
+}
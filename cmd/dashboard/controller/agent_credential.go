@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+	"github.com/nezhahq/nezha/service/rpc"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// List enrollment tokens
+// @Summary List enrollment tokens
+// @Security BearerAuth
+// @Schemes
+// @Description List agent enrollment tokens
+// @Tags auth required
+// @Param id query uint false "Resource ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.AgentEnrollmentToken]
+// @Router /enrollment-token [get]
+func listEnrollmentToken(c *gin.Context) ([]*model.AgentEnrollmentToken, error) {
+	var tokens []*model.AgentEnrollmentToken
+	if err := scopeByUser(c, singleton.DB).Order("id DESC").Find(&tokens).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return tokens, nil
+}
+
+// Create enrollment token
+// @Summary Create enrollment token
+// @Security BearerAuth
+// @Schemes
+// @Description Create a one-time agent enrollment token, the token is only returned once
+// @Tags auth required
+// @Accept json
+// @param request body model.AgentEnrollmentTokenForm true "Enrollment Token Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.AgentEnrollmentTokenSecret]
+// @Router /enrollment-token [post]
+func createEnrollmentToken(c *gin.Context) (*model.AgentEnrollmentTokenSecret, error) {
+	var tf model.AgentEnrollmentTokenForm
+	if err := c.ShouldBindJSON(&tf); err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateRandomString(model.AgentEnrollmentTokenLength)
+	if err != nil {
+		return nil, err
+	}
+
+	var t model.AgentEnrollmentToken
+	t.UserID = getUid(c)
+	t.Note = tf.Note
+	t.TokenHash = model.HashAgentSecret(token)
+	if tf.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(tf.ExpiresIn) * time.Hour)
+		t.ExpiresAt = &expiresAt
+	}
+
+	if err := singleton.DB.Create(&t).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.AgentEnrollmentTokenSecret{ID: t.ID, Token: token}, nil
+}
+
+// Batch delete enrollment tokens
+// @Summary Batch delete enrollment tokens
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete agent enrollment tokens
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/enrollment-token [post]
+func batchDeleteEnrollmentToken(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	var tokens []model.AgentEnrollmentToken
+	if err := singleton.DB.Where("id IN (?)", ids).Find(&tokens).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	for _, t := range tokens {
+		if !t.HasPermission(c) {
+			return nil, singleton.Localizer.ErrorT("permission denied")
+		}
+	}
+
+	if err := singleton.DB.Unscoped().Delete(&model.AgentEnrollmentToken{}, "id IN (?)", ids).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
+// List server credentials
+// @Summary List server credentials
+// @Security BearerAuth
+// @Schemes
+// @Description List per-server agent credentials with their last use
+// @Tags auth required
+// @Param id query uint false "Resource ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.ServerCredential]
+// @Router /server-credential [get]
+func listServerCredential(c *gin.Context) ([]*model.ServerCredential, error) {
+	return singleton.AgentCredentialShared.GetSortedList(), nil
+}
+
+// Rotate server credential
+// @Summary Rotate server credential
+// @Security BearerAuth
+// @Schemes
+// @Description Issue a new credential for the server, invalidate the old one and disconnect its agent, the credential is only returned once and the agent has to be configured with it before reconnecting
+// @Tags auth required
+// @param id path uint true "Server ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.ServerCredentialSecret]
+// @Router /server-credential/{id}/rotate [post]
+func rotateServerCredential(c *gin.Context) (*model.ServerCredentialSecret, error) {
+	server, err := credentialServer(c)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := singleton.AgentCredentialShared.Issue(server.ID, server.UserID)
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+	// 已建立的连接不会重新认证，轮换后立即断开
+	rpc.NezhaHandlerSingleton.DisconnectAgent(server.ID)
+
+	return &model.ServerCredentialSecret{ServerID: server.ID, Secret: secret}, nil
+}
+
+// Revoke server credential
+// @Summary Revoke server credential
+// @Security BearerAuth
+// @Schemes
+// @Description Revoke the credential and client certificates of the server and disconnect its agent, the agent has to enroll again with a new token
+// @Tags auth required
+// @param id path uint true "Server ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /server-credential/{id}/revoke [post]
+func revokeServerCredential(c *gin.Context) (any, error) {
+	server, err := credentialServer(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := singleton.AgentCAShared.RevokeByServer([]uint64{server.ID}); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	if _, ok := singleton.AgentCredentialShared.Get(server.ID); ok {
+		if err := singleton.AgentCredentialShared.Revoke(server.ID); err != nil {
+			return nil, err
+		}
+	}
+	// 已建立的连接不会重新认证，吊销后立即断开
+	rpc.NezhaHandlerSingleton.DisconnectAgent(server.ID)
+	return nil, nil
+}
+
+func credentialServer(c *gin.Context) (*model.Server, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	server, ok := singleton.ServerShared.Get(id)
+	if !ok || server == nil {
+		return nil, singleton.Localizer.ErrorT("server not found")
+	}
+	if !server.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+	return server, nil
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// List agent tasks
+// @Summary List agent tasks
+// @Security BearerAuth
+// @Schemes
+// @Description List recent commands dispatched to agents with their delivery status (0: queued, 1: sent, 2: acknowledged, 3: expired). Other task types report no result and are not tracked
+// @Tags auth required
+// @Param id query uint false "Resource ID"
+// @Param server_id query uint false "Server ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.AgentTask]
+// @Router /agent-task [get]
+func listAgentTask(c *gin.Context) ([]*model.AgentTask, error) {
+	var serverID uint64
+	if idStr := c.Query("server_id"); idStr != "" {
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		serverID = id
+	}
+	return singleton.TaskQueueShared.GetSortedList(serverID), nil
+}
@@ -62,7 +62,10 @@ func createAlertRule(c *gin.Context) (uint64, error) {
 	r.NotificationGroupID = arf.NotificationGroupID
 	enable := arf.Enable
 	r.TriggerMode = arf.TriggerMode
+	r.Severity = arf.Severity
 	r.Enable = &enable
+	r.ServerGroups = arf.ServerGroups
+	r.ExcludeServerGroups = arf.ExcludeServerGroups
 
 	if err := validateRule(c, &r); err != nil {
 		return 0, err
@@ -116,7 +119,10 @@ func updateAlertRule(c *gin.Context) (any, error) {
 	r.NotificationGroupID = arf.NotificationGroupID
 	enable := arf.Enable
 	r.TriggerMode = arf.TriggerMode
+	r.Severity = arf.Severity
 	r.Enable = &enable
+	r.ServerGroups = arf.ServerGroups
+	r.ExcludeServerGroups = arf.ExcludeServerGroups
 
 	if err := validateRule(c, &r); err != nil {
 		return 0, err
@@ -167,12 +173,32 @@ func batchDeleteAlertRule(c *gin.Context) (any, error) {
 }
 
 func validateRule(c *gin.Context, r *model.AlertRule) error {
+	if r.Severity == 0 {
+		r.Severity = model.NotificationSeverityWarning
+	}
+	if r.Severity > model.NotificationSeverityCritical {
+		return singleton.Localizer.ErrorT("unknown severity %d", r.Severity)
+	}
+
+	if err := validateServerGroups(c, r.ServerGroups, r.ExcludeServerGroups); err != nil {
+		return err
+	}
+
 	if len(r.Rules) > 0 {
 		for _, rule := range r.Rules {
 			if !singleton.ServerShared.CheckPermission(c, maps.Keys(rule.Ignore)) {
 				return singleton.Localizer.ErrorT("permission denied")
 			}
 
+			if rule.IsExpressionRule() {
+				if rule.Expression == "" {
+					return singleton.Localizer.ErrorT("expression is not set")
+				}
+				if err := rule.CompileExpression(); err != nil {
+					return singleton.Localizer.ErrorT("invalid expression: %v", err)
+				}
+			}
+
 			if !rule.IsTransferDurationRule() {
 				if rule.Duration < 3 {
 					return singleton.Localizer.ErrorT("duration need to be at least 3")
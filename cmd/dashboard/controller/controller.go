@@ -11,6 +11,7 @@ import (
 	"path"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
@@ -18,6 +19,7 @@ import (
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
 
 	"github.com/nezhahq/nezha/cmd/dashboard/controller/waf"
 	docs "github.com/nezhahq/nezha/cmd/dashboard/docs"
@@ -56,6 +58,10 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	if err := authMiddleware.MiddlewareInit(); err != nil {
 		log.Fatal("authMiddleware.MiddlewareInit Error:" + err.Error())
 	}
+	if singleton.Conf.Prometheus.Enable {
+		r.GET("/metrics", exportMetrics)
+	}
+
 	api := r.Group("api/v1")
 	api.POST("/login", authMiddleware.LoginHandler)
 	api.GET("/oauth2/:provider", commonHandler(oauth2redirect))
@@ -64,6 +70,8 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	fallbackAuth := api.Group("", fallbackAuthMw)
 	fallbackAuth.GET("/setting", commonHandler(listConfig))
 	fallbackAuth.GET("/oauth2/callback", commonHandler(oauth2callback(authMiddleware)))
+	fallbackAuth.GET("/status-page/:slug", commonHandler(showStatusPage))
+	fallbackAuth.GET("/status-page/:slug/feed", statusPageFeed)
 
 	authMw := authMiddleware.MiddlewareFunc()
 	optionalAuthMw := utils.IfOr(singleton.Conf.ForceAuth, authMw, fallbackAuthMw)
@@ -71,10 +79,12 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	optionalAuth := api.Group("", optionalAuthMw)
 	optionalAuth.GET("/ws/server", commonHandler(serverStream))
 	optionalAuth.GET("/server-group", commonHandler(listServerGroup))
+	optionalAuth.GET("/server/:id/metrics", commonHandler(getServerMetrics))
 
 	optionalAuth.GET("/service", commonHandler(showService))
 	optionalAuth.GET("/service/:id", commonHandler(listServiceHistory))
 	optionalAuth.GET("/service/server", commonHandler(listServerWithServices))
+	optionalAuth.GET("/service/stats", commonHandler(getServiceStats))
 
 	auth := api.Group("", authMw)
 
@@ -103,9 +113,14 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	auth.PATCH("/server-group/:id", commonHandler(updateServerGroup))
 	auth.POST("/batch-delete/server-group", commonHandler(batchDeleteServerGroup))
 
+	auth.GET("/notification-delivery", pCommonHandler(listNotificationDelivery))
+	auth.POST("/notification-delivery/:id/resend", commonHandler(resendNotificationDelivery))
+	auth.GET("/notification-dead-letter", pCommonHandler(listNotificationDeadLetter))
+
 	auth.GET("/notification-group", commonHandler(listNotificationGroup))
 	auth.POST("/notification-group", commonHandler(createNotificationGroup))
 	auth.PATCH("/notification-group/:id", commonHandler(updateNotificationGroup))
+	auth.POST("/notification-group/:id/test", commonHandler(testNotificationGroup))
 	auth.POST("/batch-delete/notification-group", commonHandler(batchDeleteNotificationGroup))
 
 	auth.GET("/server", listHandler(listServer))
@@ -116,7 +131,32 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	auth.POST("/batch-move/server", commonHandler(batchMoveServer))
 	auth.POST("/force-update/server", commonHandler(forceUpdateServer))
 
+	auth.GET("/pending-server", adminHandler(listPendingServer))
+	auth.POST("/pending-server/:id/approve", adminHandler(approvePendingServer))
+	auth.POST("/pending-server/:id/reject", adminHandler(rejectPendingServer))
+	auth.POST("/batch-delete/pending-server", adminHandler(batchDeletePendingServer))
+
+	auth.GET("/agent-connection", adminHandler(listAgentConnection))
+	auth.GET("/agent-task", listHandler(listAgentTask))
+	auth.POST("/agent-connection/:id/disconnect", adminHandler(disconnectAgent))
+
+	auth.GET("/enrollment-token", listHandler(listEnrollmentToken))
+	auth.POST("/enrollment-token", commonHandler(createEnrollmentToken))
+	auth.POST("/batch-delete/enrollment-token", commonHandler(batchDeleteEnrollmentToken))
+
+	auth.GET("/server-credential", listHandler(listServerCredential))
+	auth.POST("/server-credential/:id/rotate", commonHandler(rotateServerCredential))
+	auth.POST("/server-credential/:id/revoke", commonHandler(revokeServerCredential))
+	auth.POST("/server-credential/:id/certificate", commonHandler(issueAgentCertificate))
+
+	auth.GET("/agent-certificate", listHandler(listAgentCertificate))
+	auth.POST("/agent-certificate/:id/revoke", commonHandler(revokeAgentCertificate))
+	auth.GET("/agent-ca", commonHandler(getAgentCA))
+
 	auth.GET("/notification", listHandler(listNotification))
+	auth.GET("/notification/types", commonHandler(listNotificationTypes))
+	auth.POST("/notification/render", commonHandler(renderNotificationTemplate))
+	auth.POST("/notification/:id/test", commonHandler(testNotification))
 	auth.POST("/notification", commonHandler(createNotification))
 	auth.PATCH("/notification/:id", commonHandler(updateNotification))
 	auth.POST("/batch-delete/notification", commonHandler(batchDeleteNotification))
@@ -132,6 +172,27 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	auth.GET("/cron/:id/manual", commonHandler(manualTriggerCron))
 	auth.POST("/batch-delete/cron", commonHandler(batchDeleteCron))
 
+	auth.GET("/maintenance", listHandler(listMaintenance))
+	auth.POST("/maintenance", commonHandler(createMaintenance))
+	auth.PATCH("/maintenance/:id", commonHandler(updateMaintenance))
+	auth.POST("/maintenance/:id/close", commonHandler(closeMaintenance))
+	auth.POST("/batch-delete/maintenance", commonHandler(batchDeleteMaintenance))
+
+	auth.GET("/status-page", listHandler(listStatusPage))
+	auth.POST("/status-page", adminHandler(createStatusPage))
+	auth.PATCH("/status-page/:id", adminHandler(updateStatusPage))
+	auth.POST("/status-page/:id/incident", adminHandler(createStatusPageIncident))
+	auth.POST("/batch-delete/status-page", commonHandler(batchDeleteStatusPage))
+
+	auth.GET("/status-page-incident", pCommonHandler(listStatusPageIncident))
+	auth.POST("/status-page-incident/:id/update", adminHandler(createStatusPageIncidentUpdate))
+	auth.POST("/batch-delete/status-page-incident", commonHandler(batchDeleteStatusPageIncident))
+
+	auth.GET("/incident", pCommonHandler(listIncident))
+	auth.GET("/incident/:id", commonHandler(getIncident))
+	auth.POST("/incident/:id/ack", commonHandler(acknowledgeIncident))
+	auth.POST("/incident/:id/comment", commonHandler(createIncidentComment))
+
 	auth.GET("/ddns", listHandler(listDDNS))
 	auth.GET("/ddns/providers", commonHandler(listProviders))
 	auth.POST("/ddns", commonHandler(createDDNS))
@@ -293,6 +354,27 @@ func getUid(c *gin.Context) uint64 {
 	return user.ID
 }
 
+func paginationQuery(c *gin.Context) (limit, offset int) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+
+	offset, err = strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return
+}
+
+// scopeByUser 非管理员只能查询自己的记录
+func scopeByUser(c *gin.Context, query *gorm.DB) *gorm.DB {
+	if user := c.MustGet(model.CtxKeyAuthorizedUser).(*model.User); !user.Role.IsAdmin() {
+		return query.Where("user_id = ?", user.ID)
+	}
+	return query
+}
+
 func fallbackToFrontend(frontendDist fs.FS) func(*gin.Context) {
 	checkLocalFileOrFs := func(c *gin.Context, fs fs.FS, path string, customStatusCode int) bool {
 		if _, err := os.Stat(path); err == nil {
@@ -53,6 +53,9 @@ func createCron(c *gin.Context) (uint64, error) {
 	if !singleton.ServerShared.CheckPermission(c, slices.Values(cf.Servers)) {
 		return 0, singleton.Localizer.ErrorT("permission denied")
 	}
+	if err := validateServerGroups(c, cf.ServerGroups, cf.ExcludeServerGroups); err != nil {
+		return 0, err
+	}
 
 	cr.UserID = getUid(c)
 	cr.TaskType = cf.TaskType
@@ -63,6 +66,8 @@ func createCron(c *gin.Context) (uint64, error) {
 	cr.PushSuccessful = cf.PushSuccessful
 	cr.NotificationGroupID = cf.NotificationGroupID
 	cr.Cover = cf.Cover
+	cr.ServerGroups = cf.ServerGroups
+	cr.ExcludeServerGroups = cf.ExcludeServerGroups
 
 	if cr.TaskType == model.CronTypeCronTask && cr.Cover == model.CronCoverAlertTrigger {
 		return 0, singleton.Localizer.ErrorT("scheduled tasks cannot be triggered by alarms")
@@ -111,6 +116,9 @@ func updateCron(c *gin.Context) (any, error) {
 	if !singleton.ServerShared.CheckPermission(c, slices.Values(cf.Servers)) {
 		return 0, singleton.Localizer.ErrorT("permission denied")
 	}
+	if err := validateServerGroups(c, cf.ServerGroups, cf.ExcludeServerGroups); err != nil {
+		return 0, err
+	}
 
 	var cr model.Cron
 	if err := singleton.DB.First(&cr, id).Error; err != nil {
@@ -129,6 +137,8 @@ func updateCron(c *gin.Context) (any, error) {
 	cr.PushSuccessful = cf.PushSuccessful
 	cr.NotificationGroupID = cf.NotificationGroupID
 	cr.Cover = cf.Cover
+	cr.ServerGroups = cf.ServerGroups
+	cr.ExcludeServerGroups = cf.ExcludeServerGroups
 
 	if cr.TaskType == model.CronTypeCronTask && cr.Cover == model.CronCoverAlertTrigger {
 		return nil, singleton.Localizer.ErrorT("scheduled tasks cannot be triggered by alarms")
@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// List incidents
+// @Summary List incidents
+// @Security BearerAuth
+// @Schemes
+// @Description List incidents of alert rules and services, latest first
+// @Tags auth required
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Param source query uint false "0: alert rule, 1: service"
+// @Param rule_id query uint false "Alert rule ID or service ID"
+// @Param server_id query uint false "Server ID"
+// @Param unresolved query bool false "Only list unresolved incidents"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.Incident, model.Incident]
+// @Router /incident [get]
+func listIncident(c *gin.Context) (*model.Value[[]*model.Incident], error) {
+	limit, offset := paginationQuery(c)
+
+	query := scopeByUser(c, singleton.DB.Model(&model.Incident{}))
+	if source, err := strconv.ParseUint(c.Query("source"), 10, 8); err == nil {
+		query = query.Where("source = ?", source)
+	}
+	if ruleID, err := strconv.ParseUint(c.Query("rule_id"), 10, 64); err == nil {
+		query = query.Where("rule_id = ?", ruleID)
+	}
+	if serverID, err := strconv.ParseUint(c.Query("server_id"), 10, 64); err == nil {
+		query = query.Where("server_id = ?", serverID)
+	}
+	if unresolved, _ := strconv.ParseBool(c.Query("unresolved")); unresolved {
+		query = query.Where("resolved_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var incidents []*model.Incident
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&incidents).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.Value[[]*model.Incident]{
+		Value: incidents,
+		Pagination: model.Pagination{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
+	}, nil
+}
+
+// Get incident
+// @Summary Get incident
+// @Security BearerAuth
+// @Schemes
+// @Description Get incident with its comments
+// @Tags auth required
+// @param id path uint true "Incident ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.IncidentDetail]
+// @Router /incident/{id} [get]
+func getIncident(c *gin.Context) (*model.IncidentDetail, error) {
+	incident, err := findIncident(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []model.IncidentComment
+	if err := singleton.DB.Where("incident_id = ?", incident.ID).Order("id ASC").Find(&comments).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.IncidentDetail{
+		Incident: incident,
+		Comments: comments,
+	}, nil
+}
+
+// Acknowledge incident
+// @Summary Acknowledge incident
+// @Security BearerAuth
+// @Schemes
+// @Description Acknowledge an unresolved incident, repeated notifications are stopped until it is resolved
+// @Tags auth required
+// @param id path uint true "Incident ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /incident/{id}/ack [post]
+func acknowledgeIncident(c *gin.Context) (any, error) {
+	incident, err := findIncident(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if incident.Resolved() {
+		return nil, singleton.Localizer.ErrorT("incident is already resolved")
+	}
+	if incident.Acknowledged() {
+		return nil, singleton.Localizer.ErrorT("incident is already acknowledged")
+	}
+
+	if err := singleton.IncidentShared.Acknowledge(incident, getUid(c)); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
+// Add incident comment
+// @Summary Add incident comment
+// @Security BearerAuth
+// @Schemes
+// @Description Add incident comment
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Incident ID"
+// @param request body model.IncidentCommentForm true "Comment Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /incident/{id}/comment [post]
+func createIncidentComment(c *gin.Context) (uint64, error) {
+	var cf model.IncidentCommentForm
+	if err := c.ShouldBindJSON(&cf); err != nil {
+		return 0, err
+	}
+	if cf.Content == "" {
+		return 0, singleton.Localizer.ErrorT("comment content is empty")
+	}
+
+	incident, err := findIncident(c)
+	if err != nil {
+		return 0, err
+	}
+
+	user := c.MustGet(model.CtxKeyAuthorizedUser).(*model.User)
+	comment := model.IncidentComment{
+		IncidentID: incident.ID,
+		Username:   user.Username,
+		Content:    cf.Content,
+	}
+	comment.UserID = user.ID
+
+	if err := singleton.DB.Create(&comment).Error; err != nil {
+		return 0, newGormError("%v", err)
+	}
+	return comment.ID, nil
+}
+
+func findIncident(c *gin.Context) (*model.Incident, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var incident model.Incident
+	if err := singleton.DB.First(&incident, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("incident id %d does not exist", id)
+	}
+
+	if !incident.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+	return &incident, nil
+}
@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// List maintenance windows
+// @Summary List maintenance windows
+// @Schemes
+// @Description List maintenance windows
+// @Security BearerAuth
+// @Tags auth required
+// @Param id query uint false "Resource ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.Maintenance]
+// @Router /maintenance [get]
+func listMaintenance(c *gin.Context) ([]*model.Maintenance, error) {
+	var m []*model.Maintenance
+
+	slist := singleton.MaintenanceShared.GetSortedList()
+
+	if err := copier.Copy(&m, &slist); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Add maintenance window
+// @Summary Add maintenance window
+// @Security BearerAuth
+// @Schemes
+// @Description Add maintenance window
+// @Tags auth required
+// @Accept json
+// @param request body model.MaintenanceForm true "Maintenance Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /maintenance [post]
+func createMaintenance(c *gin.Context) (uint64, error) {
+	var mf model.MaintenanceForm
+	if err := c.ShouldBindJSON(&mf); err != nil {
+		return 0, err
+	}
+
+	var m model.Maintenance
+	m.UserID = getUid(c)
+	if err := validateMaintenance(c, &mf, &m); err != nil {
+		return 0, err
+	}
+
+	if err := singleton.DB.Create(&m).Error; err != nil {
+		return 0, newGormError("%v", err)
+	}
+
+	singleton.MaintenanceShared.Update(&m)
+	return m.ID, nil
+}
+
+// Edit maintenance window
+// @Summary Edit maintenance window
+// @Security BearerAuth
+// @Schemes
+// @Description Edit maintenance window, an early closed window is reopened
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Maintenance ID"
+// @param request body model.MaintenanceForm true "Maintenance Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /maintenance/{id} [patch]
+func updateMaintenance(c *gin.Context) (any, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var mf model.MaintenanceForm
+	if err := c.ShouldBindJSON(&mf); err != nil {
+		return nil, err
+	}
+
+	var m model.Maintenance
+	if err := singleton.DB.First(&m, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("maintenance id %d does not exist", id)
+	}
+
+	if !m.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	if err := validateMaintenance(c, &mf, &m); err != nil {
+		return nil, err
+	}
+	m.ClosedAt = nil
+
+	if err := singleton.DB.Save(&m).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.MaintenanceShared.Update(&m)
+	return nil, nil
+}
+
+// Close maintenance window
+// @Summary Close maintenance window
+// @Security BearerAuth
+// @Schemes
+// @Description Close the current maintenance window early, later occurrences of a recurring window are not affected
+// @Tags auth required
+// @param id path uint true "Maintenance ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /maintenance/{id}/close [post]
+func closeMaintenance(c *gin.Context) (any, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var m model.Maintenance
+	if err := singleton.DB.First(&m, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("maintenance id %d does not exist", id)
+	}
+
+	if !m.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	now := time.Now()
+	if !m.Active(now.In(singleton.Loc)) {
+		return nil, singleton.Localizer.ErrorT("maintenance window is not active")
+	}
+	m.ClosedAt = &now
+
+	if err := singleton.DB.Save(&m).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.MaintenanceShared.Update(&m)
+	return nil, nil
+}
+
+// Batch delete maintenance windows
+// @Summary Batch delete maintenance windows
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete maintenance windows
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/maintenance [post]
+func batchDeleteMaintenance(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if !singleton.MaintenanceShared.CheckPermission(c, slices.Values(ids)) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	if err := singleton.DB.Unscoped().Delete(&model.Maintenance{}, "id in (?)", ids).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.MaintenanceShared.Delete(ids)
+	return nil, nil
+}
+
+func validateMaintenance(c *gin.Context, mf *model.MaintenanceForm, m *model.Maintenance) error {
+	if len(mf.Servers) == 0 && len(mf.ServerGroups) == 0 && len(mf.Services) == 0 {
+		return singleton.Localizer.ErrorT("need to specify at least one server, server group or service")
+	}
+	if !singleton.ServerShared.CheckPermission(c, slices.Values(mf.Servers)) {
+		return singleton.Localizer.ErrorT("permission denied")
+	}
+	if !singleton.ServiceSentinelShared.CheckPermission(c, slices.Values(mf.Services)) {
+		return singleton.Localizer.ErrorT("permission denied")
+	}
+	if err := validateServerGroups(c, mf.ServerGroups); err != nil {
+		return err
+	}
+
+	m.Name = mf.Name
+	m.Scheduler = mf.Scheduler
+	m.Duration = mf.Duration
+	m.StartAt = mf.StartAt
+	m.EndAt = mf.EndAt
+	m.Servers = mf.Servers
+	m.ServerGroups = mf.ServerGroups
+	m.Services = mf.Services
+
+	if m.IsRecurring() {
+		if err := m.ParseScheduler(); err != nil {
+			return singleton.Localizer.ErrorT("invalid scheduler: %v", err)
+		}
+		if m.Duration == 0 {
+			return singleton.Localizer.ErrorT("duration need to be at least 1")
+		}
+		if !m.EndAt.IsZero() && !m.EndAt.After(m.StartAt) {
+			return singleton.Localizer.ErrorT("end_at must be later than start_at")
+		}
+	} else if !m.EndAt.After(m.StartAt) {
+		return singleton.Localizer.ErrorT("end_at must be later than start_at")
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/rpc"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+type hostStateMetric struct {
+	name  string
+	help  string
+	typ   string
+	value func(*model.HostState) float64
+}
+
+var hostStateMetrics = []hostStateMetric{
+	{"nezha_server_cpu_usage_percent", "CPU usage in percent.", "gauge", func(s *model.HostState) float64 { return s.CPU }},
+	{"nezha_server_memory_used_bytes", "Used memory in bytes.", "gauge", func(s *model.HostState) float64 { return float64(s.MemUsed) }},
+	{"nezha_server_swap_used_bytes", "Used swap in bytes.", "gauge", func(s *model.HostState) float64 { return float64(s.SwapUsed) }},
+	{"nezha_server_disk_used_bytes", "Used disk space in bytes.", "gauge", func(s *model.HostState) float64 { return float64(s.DiskUsed) }},
+	{"nezha_server_network_receive_bytes_total", "Total received bytes reported by the agent.", "counter", func(s *model.HostState) float64 { return float64(s.NetInTransfer) }},
+	{"nezha_server_network_transmit_bytes_total", "Total transmitted bytes reported by the agent.", "counter", func(s *model.HostState) float64 { return float64(s.NetOutTransfer) }},
+	{"nezha_server_network_receive_speed_bytes", "Current receive speed in bytes per second.", "gauge", func(s *model.HostState) float64 { return float64(s.NetInSpeed) }},
+	{"nezha_server_network_transmit_speed_bytes", "Current transmit speed in bytes per second.", "gauge", func(s *model.HostState) float64 { return float64(s.NetOutSpeed) }},
+	{"nezha_server_uptime_seconds", "Uptime in seconds.", "gauge", func(s *model.HostState) float64 { return float64(s.Uptime) }},
+	{"nezha_server_load1", "1 minute load average.", "gauge", func(s *model.HostState) float64 { return s.Load1 }},
+	{"nezha_server_load5", "5 minute load average.", "gauge", func(s *model.HostState) float64 { return s.Load5 }},
+	{"nezha_server_load15", "15 minute load average.", "gauge", func(s *model.HostState) float64 { return s.Load15 }},
+	{"nezha_server_tcp_connections", "Number of TCP connections.", "gauge", func(s *model.HostState) float64 { return float64(s.TcpConnCount) }},
+	{"nezha_server_udp_connections", "Number of UDP connections.", "gauge", func(s *model.HostState) float64 { return float64(s.UdpConnCount) }},
+	{"nezha_server_processes", "Number of processes.", "gauge", func(s *model.HostState) float64 { return float64(s.ProcessCount) }},
+}
+
+// promWriter 以 Prometheus 文本格式输出指标
+type promWriter struct {
+	bytes.Buffer
+}
+
+func (w *promWriter) family(name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample 输出一个数据点，labels 为依次排列的键值对
+func (w *promWriter) sample(name string, value float64, labels ...string) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(labels[i])
+			w.WriteString(`="`)
+			w.WriteString(promEscaper.Replace(labels[i+1]))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.WriteByte('\n')
+}
+
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Prometheus metrics
+// @Summary Prometheus metrics
+// @Schemes
+// @Description Export servers, services and dashboard internals in Prometheus text format, requires the scrape token as a bearer token or the token query parameter
+// @Tags common
+// @Produce plain
+// @Success 200 {string} string
+// @Router /metrics [get]
+func exportMetrics(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if token == "" {
+		token = c.Query("token")
+	}
+	realIP := c.GetString(model.CtxKeyRealIPStr)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(singleton.Conf.Prometheus.ScrapeToken)) != 1 {
+		model.BlockIP(singleton.DB, realIP, model.WAFBlockReasonTypeBruteForceToken, model.BlockIDToken)
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	model.UnblockIP(singleton.DB, realIP, model.BlockIDToken)
+
+	var w promWriter
+	writeServerMetrics(&w)
+	writeServiceMetrics(&w)
+	writeCycleTransferMetrics(&w)
+	writeInternalMetrics(&w)
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", w.Bytes())
+}
+
+func writeServerMetrics(w *promWriter) {
+	servers := singleton.ServerShared.GetSortedList()
+	labels := make(map[uint64][]string, len(servers))
+	for _, s := range servers {
+		groups := singleton.ServerGroupShared.GetGroupNames(s.ID)
+		labels[s.ID] = []string{"server_id", strconv.FormatUint(s.ID, 10), "name", s.Name, "uuid", s.UUID, "group", strings.Join(groups, ",")}
+	}
+
+	w.family("nezha_server_last_active_timestamp_seconds", "Unix time of the last state report.", "gauge")
+	for _, s := range servers {
+		if !s.LastActive.IsZero() {
+			w.sample("nezha_server_last_active_timestamp_seconds", float64(s.LastActive.Unix()), labels[s.ID]...)
+		}
+	}
+	w.family("nezha_server_memory_total_bytes", "Total memory in bytes.", "gauge")
+	for _, s := range servers {
+		if s.Host != nil {
+			w.sample("nezha_server_memory_total_bytes", float64(s.Host.MemTotal), labels[s.ID]...)
+		}
+	}
+	w.family("nezha_server_swap_total_bytes", "Total swap in bytes.", "gauge")
+	for _, s := range servers {
+		if s.Host != nil {
+			w.sample("nezha_server_swap_total_bytes", float64(s.Host.SwapTotal), labels[s.ID]...)
+		}
+	}
+	w.family("nezha_server_disk_total_bytes", "Total disk space in bytes.", "gauge")
+	for _, s := range servers {
+		if s.Host != nil {
+			w.sample("nezha_server_disk_total_bytes", float64(s.Host.DiskTotal), labels[s.ID]...)
+		}
+	}
+
+	for _, m := range hostStateMetrics {
+		w.family(m.name, m.help, m.typ)
+		for _, s := range servers {
+			if s.State != nil {
+				w.sample(m.name, m.value(s.State), labels[s.ID]...)
+			}
+		}
+	}
+
+	w.family("nezha_server_temperature_celsius", "Sensor temperature in celsius.", "gauge")
+	for _, s := range servers {
+		if s.State == nil {
+			continue
+		}
+		for _, t := range s.State.Temperatures {
+			w.sample("nezha_server_temperature_celsius", t.Temperature, append(slices.Clone(labels[s.ID]), "sensor", t.Name)...)
+		}
+	}
+	w.family("nezha_server_gpu_usage_percent", "GPU usage in percent.", "gauge")
+	for _, s := range servers {
+		if s.State == nil {
+			continue
+		}
+		for i, g := range s.State.GPU {
+			w.sample("nezha_server_gpu_usage_percent", g, append(slices.Clone(labels[s.ID]), "gpu", strconv.Itoa(i))...)
+		}
+	}
+}
+
+func writeServiceMetrics(w *promWriter) {
+	type sample struct {
+		labels []string
+		stats  singleton.ServiceReporterStats
+	}
+
+	services := singleton.ServiceSentinelShared.GetList()
+	serverList := singleton.ServerShared.GetList()
+	stats := singleton.ServiceSentinelShared.CopyReporterStats()
+
+	var samples []sample
+	for _, serviceID := range slices.Sorted(maps.Keys(stats)) {
+		service, ok := services[serviceID]
+		if !ok {
+			continue
+		}
+		reporters := stats[serviceID]
+		for _, reporter := range slices.Sorted(maps.Keys(reporters)) {
+			var reporterName string
+			if reporter == singleton.DashboardReporter {
+				reporterName = "dashboard"
+			} else if server, ok := serverList[reporter]; ok {
+				reporterName = server.Name
+			}
+			samples = append(samples, sample{
+				labels: []string{"service_id", strconv.FormatUint(serviceID, 10), "service", service.Name,
+					"reporter_id", strconv.FormatUint(reporter, 10), "reporter", reporterName},
+				stats: reporters[reporter],
+			})
+		}
+	}
+
+	w.family("nezha_service_up_total", "Successful checks since the dashboard started.", "counter")
+	for _, s := range samples {
+		w.sample("nezha_service_up_total", float64(s.stats.Up), s.labels...)
+	}
+	w.family("nezha_service_down_total", "Failed checks since the dashboard started.", "counter")
+	for _, s := range samples {
+		w.sample("nezha_service_down_total", float64(s.stats.Down), s.labels...)
+	}
+	w.family("nezha_service_latency_milliseconds", "Latency of the latest check in milliseconds.", "gauge")
+	for _, s := range samples {
+		w.sample("nezha_service_latency_milliseconds", float64(s.stats.Delay), s.labels...)
+	}
+}
+
+func writeCycleTransferMetrics(w *promWriter) {
+	type sample struct {
+		labels   []string
+		transfer uint64
+	}
+	type rule struct {
+		labels   []string
+		min, max uint64
+	}
+
+	var (
+		rules   []rule
+		samples []sample
+	)
+	singleton.AlertsLock.RLock()
+	for _, ruleID := range slices.Sorted(maps.Keys(singleton.AlertsCycleTransferStatsStore)) {
+		stats := singleton.AlertsCycleTransferStatsStore[ruleID]
+		ruleLabels := []string{"rule_id", strconv.FormatUint(ruleID, 10), "rule", stats.Name}
+		rules = append(rules, rule{labels: ruleLabels, min: stats.Min, max: stats.Max})
+		for _, serverID := range slices.Sorted(maps.Keys(stats.Transfer)) {
+			samples = append(samples, sample{
+				labels:   append(slices.Clone(ruleLabels), "server_id", strconv.FormatUint(serverID, 10), "name", stats.ServerName[serverID]),
+				transfer: stats.Transfer[serverID],
+			})
+		}
+	}
+	singleton.AlertsLock.RUnlock()
+
+	w.family("nezha_cycle_transfer_bytes", "Transfer used in the current cycle of a transfer alert rule.", "gauge")
+	for _, s := range samples {
+		w.sample("nezha_cycle_transfer_bytes", float64(s.transfer), s.labels...)
+	}
+	w.family("nezha_cycle_transfer_max_bytes", "Upper limit of a transfer alert rule.", "gauge")
+	for _, r := range rules {
+		w.sample("nezha_cycle_transfer_max_bytes", float64(r.max), r.labels...)
+	}
+	w.family("nezha_cycle_transfer_min_bytes", "Lower limit of a transfer alert rule.", "gauge")
+	for _, r := range rules {
+		w.sample("nezha_cycle_transfer_min_bytes", float64(r.min), r.labels...)
+	}
+}
+
+func writeInternalMetrics(w *promWriter) {
+	length, capacity := singleton.ServiceSentinelShared.ReportQueueStats()
+	w.family("nezha_service_report_queue_length", "Pending service reports waiting to be processed.", "gauge")
+	w.sample("nezha_service_report_queue_length", float64(length))
+	w.family("nezha_service_report_queue_capacity", "Capacity of the service report queue.", "gauge")
+	w.sample("nezha_service_report_queue_capacity", float64(capacity))
+
+	w.family("nezha_agent_streams_connected", "Connected agent task streams.", "gauge")
+	w.sample("nezha_agent_streams_connected", float64(rpc.NezhaHandlerSingleton.TaskStreamCount()))
+
+	w.family("nezha_online_users", "Online websocket users.", "gauge")
+	w.sample("nezha_online_users", float64(singleton.GetOnlineUserCount()))
+
+	w.family("nezha_servers", "Configured servers.", "gauge")
+	w.sample("nezha_servers", float64(len(singleton.ServerShared.GetList())))
+}
@@ -1,14 +1,21 @@
 package controller
 
 import (
+	"context"
+	"fmt"
+	"net/http"
 	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
 	"gorm.io/gorm"
 
 	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/notifier"
 	"github.com/nezhahq/nezha/service/singleton"
 )
 
@@ -52,6 +59,8 @@ func createNotification(c *gin.Context) (uint64, error) {
 	var n model.Notification
 	n.UserID = getUid(c)
 	n.Name = nf.Name
+	n.Type = nf.Type
+	n.Config = nf.Config
 	n.RequestMethod = nf.RequestMethod
 	n.RequestType = nf.RequestType
 	n.RequestHeader = nf.RequestHeader
@@ -59,15 +68,24 @@ func createNotification(c *gin.Context) (uint64, error) {
 	n.URL = nf.URL
 	verifyTLS := nf.VerifyTLS
 	n.VerifyTLS = &verifyTLS
+	n.Templates = nf.Templates
+
+	if err := validateNotification(&n); err != nil {
+		return 0, err
+	}
 
 	ns := model.NotificationServerBundle{
 		Notification: &n,
 		Server:       nil,
 		Loc:          singleton.Loc,
 	}
+	nt, err := notifier.New(&ns)
+	if err != nil {
+		return 0, singleton.Localizer.ErrorT("invalid notification config: %v", err)
+	}
 	// 未勾选跳过检查
 	if !nf.SkipCheck {
-		if err := ns.Send(singleton.Localizer.T("a test message")); err != nil {
+		if err := nt.Send(c.Request.Context(), singleton.Localizer.T("a test message")); err != nil {
 			return 0, err
 		}
 	}
@@ -113,6 +131,8 @@ func updateNotification(c *gin.Context) (any, error) {
 	}
 
 	n.Name = nf.Name
+	n.Type = nf.Type
+	n.Config = nf.Config
 	n.RequestMethod = nf.RequestMethod
 	n.RequestType = nf.RequestType
 	n.RequestHeader = nf.RequestHeader
@@ -120,15 +140,24 @@ func updateNotification(c *gin.Context) (any, error) {
 	n.URL = nf.URL
 	verifyTLS := nf.VerifyTLS
 	n.VerifyTLS = &verifyTLS
+	n.Templates = nf.Templates
+
+	if err := validateNotification(&n); err != nil {
+		return nil, err
+	}
 
 	ns := model.NotificationServerBundle{
 		Notification: &n,
 		Server:       nil,
 		Loc:          singleton.Loc,
 	}
+	nt, err := notifier.New(&ns)
+	if err != nil {
+		return nil, singleton.Localizer.ErrorT("invalid notification config: %v", err)
+	}
 	// 未勾选跳过检查
 	if !nf.SkipCheck {
-		if err := ns.Send(singleton.Localizer.T("a test message")); err != nil {
+		if err := nt.Send(c.Request.Context(), singleton.Localizer.T("a test message")); err != nil {
 			return nil, err
 		}
 	}
@@ -169,6 +198,9 @@ func batchDeleteNotification(c *gin.Context) (any, error) {
 		if err := tx.Unscoped().Delete(&model.NotificationGroupNotification{}, "notification_id in (?)", n).Error; err != nil {
 			return err
 		}
+		if err := tx.Unscoped().Delete(&model.NotificationDelivery{}, "notification_id in (?) AND status = ?", n, model.NotificationDeliveryPending).Error; err != nil {
+			return err
+		}
 		return nil
 	})
 
@@ -179,3 +211,279 @@ func batchDeleteNotification(c *gin.Context) (any, error) {
 	singleton.NotificationShared.Delete(n)
 	return nil, nil
 }
+
+// List notification types
+// @Summary List notification types
+// @Security BearerAuth
+// @Schemes
+// @Description List notification types
+// @Tags auth required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]string]
+// @Router /notification/types [get]
+func listNotificationTypes(c *gin.Context) ([]string, error) {
+	return model.NotificationTypeList[:], nil
+}
+
+func validateNotification(n *model.Notification) error {
+	if n.Type == "" {
+		n.Type = model.NotificationTypeTemplate
+	}
+	if !slices.Contains(model.NotificationTypeList[:], n.Type) {
+		return singleton.Localizer.ErrorT("unknown notification type %s", n.Type)
+	}
+	if err := model.ValidateNotificationTemplates(n.Templates); err != nil {
+		return singleton.Localizer.ErrorT("invalid notification template: %v", err)
+	}
+	return nil
+}
+
+// Render notification template
+// @Summary Render notification template
+// @Security BearerAuth
+// @Schemes
+// @Description Render a notification template with sample event data, or with the current state of a server
+// @Tags auth required
+// @Accept json
+// @param request body model.NotificationRenderForm true "NotificationRenderForm"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[string]
+// @Router /notification/render [post]
+func renderNotificationTemplate(c *gin.Context) (string, error) {
+	var rf model.NotificationRenderForm
+	if err := c.ShouldBindJSON(&rf); err != nil {
+		return "", err
+	}
+	if rf.Source == "" {
+		rf.Source = model.NotificationSourceAlert
+	}
+	if !slices.Contains(model.NotificationSourceList[:], rf.Source) {
+		return "", singleton.Localizer.ErrorT("unknown notification source %s", rf.Source)
+	}
+
+	server, err := sampleNotificationServer(c, rf.ServerID)
+	if err != nil {
+		return "", err
+	}
+
+	event, message := sampleNotificationEvent(rf.Source, server)
+	data := model.NewNotificationTemplateData(event, message, server, time.Now())
+	msg, err := model.RenderNotificationTemplate(rf.Template, data, singleton.Loc)
+	if err != nil {
+		return "", singleton.Localizer.ErrorT("invalid notification template: %v", err)
+	}
+	return msg, nil
+}
+
+// Test notification
+// @Summary Test notification
+// @Security BearerAuth
+// @Schemes
+// @Description Send a sample message through the notification and return the response of the channel, the response body is only returned to admins
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Notification ID"
+// @param request body model.NotificationTestForm false "NotificationTestForm"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.NotificationTestResult]
+// @Router /notification/{id}/test [post]
+func testNotification(c *gin.Context) (*model.NotificationTestResult, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := singleton.NotificationShared.Get(id)
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("notification id %d does not exist", id)
+	}
+	if !n.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	results, err := sendTestNotifications(c, []*model.Notification{n})
+	if err != nil {
+		return nil, err
+	}
+	return &results[0], nil
+}
+
+// sendTestNotifications 向通知方式并发发送示例消息，返回每个通知方式的发送结果
+func sendTestNotifications(c *gin.Context, notifications []*model.Notification) ([]model.NotificationTestResult, error) {
+	var tf model.NotificationTestForm
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&tf); err != nil {
+			return nil, err
+		}
+	}
+	if tf.Source == "" {
+		tf.Source = model.NotificationSourceAlert
+	}
+	if !slices.Contains(model.NotificationSourceList[:], tf.Source) {
+		return nil, singleton.Localizer.ErrorT("unknown notification source %s", tf.Source)
+	}
+
+	server, err := sampleNotificationServer(c, tf.ServerID)
+	if err != nil {
+		return nil, err
+	}
+	event, message := sampleNotificationEvent(tf.Source, server)
+	// 通知地址由用户填写，响应内容只返回给管理员，避免被用于读取内网服务
+	showBody := c.MustGet(model.CtxKeyAuthorizedUser).(*model.User).Role.IsAdmin()
+
+	results := make([]model.NotificationTestResult, len(notifications))
+	var wg sync.WaitGroup
+	for i, n := range notifications {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = sendTestNotification(c.Request.Context(), n, event, message, server, showBody)
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+func sendTestNotification(ctx context.Context, n *model.Notification, event model.NotificationEvent, message string, server *model.Server, showBody bool) model.NotificationTestResult {
+	result := model.NotificationTestResult{
+		NotificationID: n.ID,
+		Name:           n.Name,
+	}
+
+	if tmpl := n.Template(event.Source); tmpl != "" {
+		var err error
+		data := model.NewNotificationTemplateData(event, message, server, time.Now())
+		if message, err = model.RenderNotificationTemplate(tmpl, data, singleton.Loc); err != nil {
+			result.Error = singleton.Localizer.Tf("invalid notification template: %v", err)
+			return result
+		}
+	}
+
+	ns := model.NotificationServerBundle{
+		Notification: n,
+		Server:       server,
+		Loc:          singleton.Loc,
+	}
+	var resp notifier.Response
+	ctx, cancel := context.WithTimeout(notifier.WithResponse(ctx, &resp), time.Second*30)
+	defer cancel()
+
+	err := notifier.Send(ctx, &ns, singleton.Localizer.Tf("[Test] %s", message))
+	result.StatusCode = resp.StatusCode
+	if showBody {
+		result.ResponseBody = resp.Body
+	}
+	if err != nil {
+		result.Error = err.Error()
+		// 非 2xx 响应的错误信息中同样包含响应内容
+		if !showBody && resp.StatusCode != 0 {
+			result.Error = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		return result
+	}
+	result.Success = true
+	return result
+}
+
+// sampleNotificationServer 返回测试通知使用的服务器，未指定服务器时返回示例数据
+func sampleNotificationServer(c *gin.Context, serverID uint64) (*model.Server, error) {
+	if serverID != 0 {
+		s, ok := singleton.ServerShared.Get(serverID)
+		if !ok {
+			return nil, singleton.Localizer.ErrorT("server id %d does not exist", serverID)
+		}
+		if !s.HasPermission(c) {
+			return nil, singleton.Localizer.ErrorT("permission denied")
+		}
+		server := &model.Server{}
+		if err := copier.Copy(server, s); err != nil {
+			return nil, err
+		}
+		if server.Host == nil {
+			server.Host = &model.Host{}
+		}
+		if server.State == nil {
+			server.State = &model.HostState{}
+		}
+		if server.GeoIP == nil {
+			server.GeoIP = &model.GeoIP{}
+		}
+		return server, nil
+	}
+
+	return &model.Server{
+		Common: model.Common{ID: 1},
+		Name:   "example",
+		Host: &model.Host{
+			Platform:  "debian",
+			MemTotal:  8 << 30,
+			DiskTotal: 100 << 30,
+			SwapTotal: 2 << 30,
+		},
+		State: &model.HostState{
+			CPU:         95.5,
+			MemUsed:     6 << 30,
+			DiskUsed:    42 << 30,
+			NetInSpeed:  12 << 20,
+			NetOutSpeed: 3 << 20,
+			Load1:       3.2,
+			Load5:       2.8,
+			Load15:      2.1,
+		},
+		GeoIP: &model.GeoIP{
+			IP: model.IP{IPv4Addr: "192.0.2.1"},
+		},
+		LastActive: time.Now(),
+	}, nil
+}
+
+// sampleNotificationEvent 返回用于预览模板的示例事件与默认格式的消息
+func sampleNotificationEvent(source string, server *model.Server) (model.NotificationEvent, string) {
+	event := model.NotificationEvent{
+		Source:   source,
+		Severity: model.NotificationSeverityWarning,
+	}
+	switch source {
+	case model.NotificationSourceAlert:
+		event.Rule = "CPU"
+		event.ServerID = server.ID
+		event.State = model.NotificationStateFiring
+		event.PrevState = model.NotificationStateResolved
+		event.Duration = 5 * time.Minute
+		event.Metrics = []model.NotificationMetric{{Type: "cpu", Value: server.State.CPU, Max: 90}}
+		return event, fmt.Sprintf("[%s] %s(%s) %s", singleton.Localizer.T("Incident"),
+			server.Name, singleton.IPDesensitize(server.GeoIP.IP.Join()), event.Rule)
+	case model.NotificationSourceService:
+		event.Rule = "example.com"
+		event.Severity = model.NotificationSeverityCritical
+		event.State = singleton.StatusCodeToString(singleton.StatusDown)
+		event.PrevState = singleton.StatusCodeToString(singleton.StatusGood)
+		event.Duration = 3 * time.Minute
+		event.Reporter = server.Name
+		event.Error = "connection refused"
+		event.Reporters = []string{fmt.Sprintf("%s (3/10)", server.Name)}
+		return event, singleton.Localizer.Tf("[%s] %s Reporter: %s, Error: %s", event.State, event.Rule, event.Reporter, event.Error) +
+			singleton.Localizer.Tf(", Failed reporters: %s", strings.Join(event.Reporters, ", "))
+	case model.NotificationSourceTLS:
+		expiry := time.Now().AddDate(0, 0, 6)
+		event.Rule = "example.com"
+		event.CertIssuer = "Example CA"
+		event.CertExpiry = &expiry
+		return event, fmt.Sprintf("[TLS] %s %s", event.Rule, singleton.Localizer.Tf(
+			"The TLS certificate will expire within seven days. Expiration time: %s", expiry.Format(time.DateTime)))
+	case model.NotificationSourceCron:
+		event.Rule = "backup"
+		event.ServerID = server.ID
+		event.Error = "exit status 1"
+		return event, fmt.Sprintf("[%s] %s, %s\n%s", singleton.Localizer.T("Scheduled Task Executed Failed"),
+			event.Rule, server.Name, event.Error)
+	default:
+		event.Severity = model.NotificationSeverityInfo
+		event.ServerID = server.ID
+		event.PrevState = singleton.IPDesensitize("192.0.2.1")
+		event.State = singleton.IPDesensitize("198.51.100.1")
+		return event, fmt.Sprintf("[%s] %s, %s => %s", singleton.Localizer.T("IP Changed"),
+			server.Name, event.PrevState, event.State)
+	}
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// List notification deliveries
+// @Summary List notification deliveries
+// @Security BearerAuth
+// @Schemes
+// @Description List recent notification deliveries, latest first
+// @Tags auth required
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Param status query uint false "0: pending, 1: sent, 2: failed"
+// @Param notification_id query uint false "Notification ID"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.NotificationDelivery, model.NotificationDelivery]
+// @Router /notification-delivery [get]
+func listNotificationDelivery(c *gin.Context) (*model.Value[[]*model.NotificationDelivery], error) {
+	limit, offset := paginationQuery(c)
+
+	query := scopeByUser(c, singleton.DB.Model(&model.NotificationDelivery{}))
+	if status, err := strconv.ParseUint(c.Query("status"), 10, 8); err == nil {
+		query = query.Where("status = ?", status)
+	}
+	if notificationID, err := strconv.ParseUint(c.Query("notification_id"), 10, 64); err == nil {
+		query = query.Where("notification_id = ?", notificationID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var deliveries []*model.NotificationDelivery
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.Value[[]*model.NotificationDelivery]{
+		Value: deliveries,
+		Pagination: model.Pagination{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
+	}, nil
+}
+
+// List notification dead letters
+// @Summary List notification dead letters
+// @Security BearerAuth
+// @Schemes
+// @Description List notifications that exhausted their retries, latest first
+// @Tags auth required
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.NotificationDeadLetter, model.NotificationDeadLetter]
+// @Router /notification-dead-letter [get]
+func listNotificationDeadLetter(c *gin.Context) (*model.Value[[]*model.NotificationDeadLetter], error) {
+	limit, offset := paginationQuery(c)
+
+	query := scopeByUser(c, singleton.DB.Model(&model.NotificationDeadLetter{}))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var deadLetters []*model.NotificationDeadLetter
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&deadLetters).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.Value[[]*model.NotificationDeadLetter]{
+		Value: deadLetters,
+		Pagination: model.Pagination{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
+	}, nil
+}
+
+// Resend notification delivery
+// @Summary Resend notification delivery
+// @Security BearerAuth
+// @Schemes
+// @Description Queue a failed notification delivery again, its dead letter is removed
+// @Tags auth required
+// @param id path uint true "Delivery ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /notification-delivery/{id}/resend [post]
+func resendNotificationDelivery(c *gin.Context) (any, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var d model.NotificationDelivery
+	if err := singleton.DB.First(&d, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("delivery id %d does not exist", id)
+	}
+
+	if !d.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	if d.Status != model.NotificationDeliveryFailed {
+		return nil, singleton.Localizer.ErrorT("only failed deliveries can be resent")
+	}
+	if _, ok := singleton.NotificationShared.Get(d.NotificationID); !ok {
+		return nil, singleton.Localizer.ErrorT("notification id %d does not exist", d.NotificationID)
+	}
+
+	if err := singleton.NotificationShared.Resend(&d); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
@@ -76,8 +76,18 @@ func createNotificationGroup(c *gin.Context) (uint64, error) {
 
 	var ng model.NotificationGroup
 	ng.Name = ngf.Name
+	ng.Routes = ngf.Routes
+	ng.DigestWindow = ngf.DigestWindow
+	ng.DailyDigestTime = ngf.DailyDigestTime
 	ng.UserID = uid
 
+	if err := validateNotificationRoutes(c, &ngf); err != nil {
+		return 0, err
+	}
+	if err := ng.ValidateDigest(); err != nil {
+		return 0, singleton.Localizer.ErrorT("invalid digest settings: %v", err)
+	}
+
 	var count int64
 	if err := singleton.DB.Model(&model.Notification{}).Where("id in (?)", ngf.Notifications).Count(&count).Error; err != nil {
 		return 0, newGormError("%v", err)
@@ -151,8 +161,18 @@ func updateNotificationGroup(c *gin.Context) (any, error) {
 	}
 
 	ngDB.Name = ngf.Name
+	ngDB.Routes = ngf.Routes
+	ngDB.DigestWindow = ngf.DigestWindow
+	ngDB.DailyDigestTime = ngf.DailyDigestTime
 	ngf.Notifications = slices.Compact(ngf.Notifications)
 
+	if err := validateNotificationRoutes(c, &ngf); err != nil {
+		return nil, err
+	}
+	if err := ngDB.ValidateDigest(); err != nil {
+		return nil, singleton.Localizer.ErrorT("invalid digest settings: %v", err)
+	}
+
 	var count int64
 	if err := singleton.DB.Model(&model.Notification{}).Where("id in (?)", ngf.Notifications).Count(&count).Error; err != nil {
 		return nil, newGormError("%v", err)
@@ -237,3 +257,54 @@ func batchDeleteNotificationGroup(c *gin.Context) (any, error) {
 	singleton.NotificationShared.DeleteGroup(ngn)
 	return nil, nil
 }
+
+func validateNotificationRoutes(c *gin.Context, ngf *model.NotificationGroupForm) error {
+	for _, route := range ngf.Routes {
+		if err := route.Validate(); err != nil {
+			return singleton.Localizer.ErrorT("invalid route: %v", err)
+		}
+		for _, nid := range route.Notifications {
+			if !slices.Contains(ngf.Notifications, nid) {
+				return singleton.Localizer.ErrorT("notification id %d is not in the group", nid)
+			}
+		}
+		if err := validateServerGroups(c, route.ServerGroups); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Test notification group
+// @Summary Test notification group
+// @Security BearerAuth
+// @Schemes
+// @Description Send a sample message through every notification in the group and return the response of each channel
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Notification Group ID"
+// @param request body model.NotificationTestForm false "NotificationTestForm"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.NotificationTestResult]
+// @Router /notification-group/{id}/test [post]
+func testNotificationGroup(c *gin.Context) ([]model.NotificationTestResult, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var ng model.NotificationGroup
+	if err := singleton.DB.First(&ng, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("group id %d does not exist", id)
+	}
+	if !ng.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	notifications := singleton.NotificationShared.GetGroupNotifications(ng.ID)
+	if len(notifications) == 0 {
+		return nil, singleton.Localizer.ErrorT("notification group %d has no notifications", id)
+	}
+	return sendTestNotifications(c, notifications)
+}
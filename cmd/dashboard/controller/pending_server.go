@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"strconv"
+
+	petname "github.com/dustinkirkland/golang-petname"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// List pending servers
+// @Summary List pending servers
+// @Security BearerAuth
+// @Schemes
+// @Description List agents waiting for approval
+// @Tags admin required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.PendingServer]
+// @Router /pending-server [get]
+func listPendingServer(c *gin.Context) ([]*model.PendingServer, error) {
+	return singleton.PendingServerShared.GetSortedList(), nil
+}
+
+// Approve pending server
+// @Summary Approve pending server
+// @Security BearerAuth
+// @Schemes
+// @Description Approve an agent and add it as a server
+// @Tags admin required
+// @Accept json
+// @param id path uint true "Pending Server ID"
+// @param request body model.PendingServerApproveForm true "Approve Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /pending-server/{id}/approve [post]
+func approvePendingServer(c *gin.Context) (uint64, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	var af model.PendingServerApproveForm
+	if err := c.ShouldBindJSON(&af); err != nil {
+		return 0, err
+	}
+
+	p, ok := singleton.PendingServerShared.Get(id)
+	if !ok {
+		return 0, singleton.Localizer.ErrorT("pending server id %d does not exist", id)
+	}
+	if _, ok := singleton.ServerShared.UUIDToID(p.UUID); ok {
+		return 0, singleton.Localizer.ErrorT("server with uuid %s already exists", p.UUID)
+	}
+	for _, gid := range af.ServerGroups {
+		if _, ok := singleton.ServerGroupShared.Get(gid); !ok {
+			return 0, singleton.Localizer.ErrorT("group id %d does not exist", gid)
+		}
+	}
+
+	s := model.Server{UUID: p.UUID, Name: af.Name, Common: model.Common{
+		UserID: p.UserID,
+	}}
+	if s.Name == "" {
+		s.Name = petname.Generate(2, "-")
+	}
+
+	uid := getUid(c)
+	err = singleton.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&s).Error; err != nil {
+			return err
+		}
+		for _, gid := range af.ServerGroups {
+			if err := tx.Create(&model.ServerGroupServer{
+				Common: model.Common{
+					UserID: uid,
+				},
+				ServerGroupId: gid,
+				ServerId:      s.ID,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(&model.PendingServer{}, id).Error
+	})
+	if err != nil {
+		return 0, newGormError("%v", err)
+	}
+
+	model.InitServer(&s)
+	if p.Host != nil {
+		s.Host = p.Host
+	}
+	singleton.ServerShared.Update(&s, s.UUID)
+	singleton.PendingServerShared.Delete([]uint64{id})
+	if len(af.ServerGroups) > 0 {
+		if err := singleton.ServerGroupShared.Reload(); err != nil {
+			return 0, newGormError("%v", err)
+		}
+	}
+
+	return s.ID, nil
+}
+
+// Reject pending server
+// @Summary Reject pending server
+// @Security BearerAuth
+// @Schemes
+// @Description Reject an agent, further connections of it are denied until the request is deleted
+// @Tags admin required
+// @param id path uint true "Pending Server ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /pending-server/{id}/reject [post]
+func rejectPendingServer(c *gin.Context) (any, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := singleton.PendingServerShared.Reject(id); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// Batch delete pending servers
+// @Summary Batch delete pending servers
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete pending or rejected agent requests
+// @Tags admin required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/pending-server [post]
+func batchDeletePendingServer(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.DB.Unscoped().Delete(&model.PendingServer{}, "id IN (?)", ids).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.PendingServerShared.Delete(ids)
+	return nil, nil
+}
@@ -104,6 +104,75 @@ func updateServer(c *gin.Context) (any, error) {
 	return nil, nil
 }
 
+// Get server metrics
+// @Summary Get server metrics
+// @Security BearerAuth
+// @Schemes
+// @Description Get the history of a server metric, downsampled to the given step
+// @Tags common
+// @param id path uint true "Server ID"
+// @param metric query string true "Metric name, same as the field name of HostState"
+// @param from query int false "Start time in unix seconds, defaults to 24 hours ago"
+// @param to query int false "End time in unix seconds, defaults to now"
+// @param step query int false "Step in seconds"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.ServerMetricsResponse]
+// @Router /server/{id}/metrics [get]
+func getServerMetrics(c *gin.Context) (*model.ServerMetricsResponse, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	server, ok := singleton.ServerShared.Get(id)
+	if !ok || server == nil {
+		return nil, singleton.Localizer.ErrorT("server not found")
+	}
+
+	_, isMember := c.Get(model.CtxKeyAuthorizedUser)
+	if server.HideForGuest && !isMember {
+		return nil, singleton.Localizer.ErrorT("unauthorized")
+	}
+
+	to := time.Now()
+	if v := c.Query("to"); v != "" {
+		ts, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		to = time.Unix(ts, 0)
+	}
+	from := to.Add(-24 * time.Hour)
+	if v := c.Query("from"); v != "" {
+		ts, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		from = time.Unix(ts, 0)
+	}
+	var step uint64
+	if v := c.Query("step"); v != "" {
+		if step, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	metric := c.Query("metric")
+	tier, realStep, points, err := singleton.ServerMetricShared.Query(id, metric, from, to, time.Duration(step)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ServerMetricsResponse{
+		ServerID: id,
+		Metric:   metric,
+		Tier:     tier,
+		Step:     uint64(realStep / time.Second),
+		Points:   points,
+	}, nil
+}
+
 // Batch delete server
 // @Summary Batch delete server
 // @Security BearerAuth
@@ -152,7 +221,18 @@ func batchDeleteServer(c *gin.Context) (any, error) {
 	singleton.DB.Unscoped().Delete(&model.Transfer{}, "server_id in (?)", servers)
 	singleton.AlertsLock.Unlock()
 
+	singleton.DB.Unscoped().Delete(&model.ServerMetric{}, "server_id in (?)", servers)
 	singleton.ServerShared.Delete(servers)
+	singleton.ServerMetricShared.Forget(servers)
+	singleton.AgentCredentialShared.Delete(servers)
+	singleton.TaskQueueShared.Delete(servers)
+	if err := singleton.AgentCAShared.RevokeByServer(servers); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	singleton.IncidentShared.ResolveByServer(servers)
+	if err := singleton.ServerGroupShared.Reload(); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
@@ -181,7 +261,7 @@ func forceUpdateServer(c *gin.Context) (*model.ServerTaskResponse, error) {
 			if !server.HasPermission(c) {
 				return nil, singleton.Localizer.ErrorT("permission denied")
 			}
-			if err := server.TaskStream.Send(&pb.Task{
+			if err := singleton.TaskQueueShared.Send(server, &pb.Task{
 				Type: model.TaskTypeUpgrade,
 			}); err != nil {
 				forceUpdateResp.Failure = append(forceUpdateResp.Failure, sid)
@@ -221,7 +301,7 @@ func getServerConfig(c *gin.Context) (string, error) {
 		return "", singleton.Localizer.ErrorT("permission denied")
 	}
 
-	if err := s.TaskStream.Send(&pb.Task{
+	if err := singleton.TaskQueueShared.Send(s, &pb.Task{
 		Type: model.TaskTypeReportConfig,
 	}); err != nil {
 		return "", err
@@ -293,7 +373,7 @@ func setServerConfig(c *gin.Context) (*model.ServerTaskResponse, error) {
 					Type: model.TaskTypeApplyConfig,
 					Data: configForm.Config,
 				}
-				if err := s.TaskStream.Send(task); err != nil {
+				if err := singleton.TaskQueueShared.Send(s, task); err != nil {
 					respMu.Lock()
 					resp.Failure = append(resp.Failure, s.ID)
 					respMu.Unlock()
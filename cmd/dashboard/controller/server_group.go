@@ -106,6 +106,9 @@ func createServerGroup(c *gin.Context) (uint64, error) {
 		return 0, newGormError("%v", err)
 	}
 
+	if err := singleton.ServerGroupShared.Reload(); err != nil {
+		return 0, err
+	}
 	return sg.ID, nil
 }
 
@@ -185,7 +188,7 @@ func updateServerGroup(c *gin.Context) (any, error) {
 		return nil, newGormError("%v", err)
 	}
 
-	return nil, nil
+	return nil, singleton.ServerGroupShared.Reload()
 }
 
 // Batch delete server group
@@ -230,5 +233,20 @@ func batchDeleteServerGroup(c *gin.Context) (any, error) {
 		return nil, newGormError("%v", err)
 	}
 
-	return nil, nil
+	return nil, singleton.ServerGroupShared.Reload()
+}
+
+func validateServerGroups(c *gin.Context, groupLists ...[]uint64) error {
+	for _, groups := range groupLists {
+		for _, id := range groups {
+			sg, ok := singleton.ServerGroupShared.Get(id)
+			if !ok {
+				return singleton.Localizer.ErrorT("group id %d does not exist", id)
+			}
+			if !sg.HasPermission(c) {
+				return singleton.Localizer.ErrorT("permission denied")
+			}
+		}
+	}
+	return nil
 }
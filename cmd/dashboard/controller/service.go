@@ -11,6 +11,7 @@ import (
 	"github.com/jinzhu/copier"
 
 	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/probe"
 	"github.com/nezhahq/nezha/pkg/utils"
 	"github.com/nezhahq/nezha/service/singleton"
 	"gorm.io/gorm"
@@ -83,17 +84,23 @@ func listServiceHistory(c *gin.Context) ([]*model.ServiceInfos, error) {
 		return nil, err
 	}
 
-	m := singleton.ServerShared.GetList()
-	server, ok := m[id]
-	if !ok || server == nil {
-		return nil, singleton.Localizer.ErrorT("server not found")
-	}
-
 	_, isMember := c.Get(model.CtxKeyAuthorizedUser)
 	authorized := isMember // TODO || isViewPasswordVerfied
 
-	if server.HideForGuest && !authorized {
-		return nil, singleton.Localizer.ErrorT("unauthorized")
+	m := singleton.ServerShared.GetList()
+	if id == singleton.DashboardReporter {
+		// 面板监测点的记录只对登录用户可见
+		if !authorized {
+			return nil, singleton.Localizer.ErrorT("unauthorized")
+		}
+	} else {
+		server, ok := m[id]
+		if !ok || server == nil {
+			return nil, singleton.Localizer.ErrorT("server not found")
+		}
+		if server.HideForGuest && !authorized {
+			return nil, singleton.Localizer.ErrorT("unauthorized")
+		}
 	}
 
 	var serviceHistories []*model.ServiceHistory
@@ -113,7 +120,11 @@ func listServiceHistory(c *gin.Context) ([]*model.ServiceInfos, error) {
 				ServiceID:   history.ServiceID,
 				ServerID:    history.ServerID,
 				ServiceName: service.Name,
-				ServerName:  m[history.ServerID].Name,
+			}
+			if history.ServerID == singleton.DashboardReporter {
+				infos.ServerName = singleton.Localizer.T("Dashboard")
+			} else if server, ok := m[history.ServerID]; ok {
+				infos.ServerName = server.Name
 			}
 			resultMap[history.ServiceID] = infos
 			sortedServiceIDs = append(sortedServiceIDs, history.ServiceID)
@@ -153,6 +164,12 @@ func listServerWithServices(c *gin.Context) ([]uint64, error) {
 
 	var ret []uint64
 	for _, id := range serverIdsWithService {
+		if id == singleton.DashboardReporter {
+			if authorized {
+				ret = append(ret, id)
+			}
+			continue
+		}
 		server, ok := singleton.ServerShared.Get(id)
 		if !ok || server == nil {
 			return nil, singleton.Localizer.ErrorT("server not found")
@@ -165,6 +182,81 @@ func listServerWithServices(c *gin.Context) ([]uint64, error) {
 	return ret, nil
 }
 
+// Get service stats
+// @Summary Get service stats
+// @Security BearerAuth
+// @Schemes
+// @Description Get the availability of services over a date range from the daily rollups
+// @Tags common
+// @param id query string false "Comma separated service IDs, defaults to all visible services"
+// @param from query string false "Start date in YYYY-MM-DD, defaults to 29 days before to"
+// @param to query string false "End date in YYYY-MM-DD, defaults to today"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.ServiceStats]
+// @Router /service/stats [get]
+func getServiceStats(c *gin.Context) ([]*model.ServiceStats, error) {
+	now := time.Now().In(singleton.Loc)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, singleton.Loc)
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation(time.DateOnly, v, singleton.Loc)
+		if err != nil {
+			return nil, err
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -29)
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation(time.DateOnly, v, singleton.Loc)
+		if err != nil {
+			return nil, err
+		}
+		from = t
+	}
+	if to.Before(from) {
+		return nil, singleton.Localizer.ErrorT("invalid time range")
+	}
+
+	visible := func(s *model.Service) bool {
+		return s.EnableShowInService || s.HasPermission(c)
+	}
+
+	var services []*model.Service
+	if v := c.Query("id"); v != "" {
+		for _, idStr := range strings.Split(v, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(idStr), 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			service, ok := singleton.ServiceSentinelShared.Get(id)
+			if !ok || !visible(service) {
+				return nil, singleton.Localizer.ErrorT("service id %d does not exist", id)
+			}
+			services = append(services, service)
+		}
+	} else {
+		for _, service := range singleton.ServiceSentinelShared.GetSortedList() {
+			if visible(service) {
+				services = append(services, service)
+			}
+		}
+	}
+
+	ids := make([]uint64, 0, len(services))
+	for _, service := range services {
+		ids = append(ids, service.ID)
+	}
+	daily, err := singleton.ServiceDailyStats(ids, from, to)
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	ret := make([]*model.ServiceStats, 0, len(services))
+	for _, service := range services {
+		ret = append(ret, model.NewServiceStats(service.ID, service.Name, daily[service.ID]))
+	}
+	return ret, nil
+}
+
 // Create service
 // @Summary Create service
 // @Security BearerAuth
@@ -201,6 +293,19 @@ func createService(c *gin.Context) (uint64, error) {
 	m.EnableTriggerTask = mf.EnableTriggerTask
 	m.RecoverTriggerTasks = mf.RecoverTriggerTasks
 	m.FailTriggerTasks = mf.FailTriggerTasks
+	m.ServerGroups = mf.ServerGroups
+	m.ExcludeServerGroups = mf.ExcludeServerGroups
+	m.HTTPCheck = mf.HTTPCheck
+	m.DNSCheck = mf.DNSCheck
+	m.UDPCheck = mf.UDPCheck
+	m.TLSCheck = mf.TLSCheck
+	m.DashboardProbe = mf.DashboardProbe
+	m.Quorum = mf.Quorum
+	m.WindowSamples = mf.WindowSamples
+	m.WindowDuration = mf.WindowDuration
+	m.GoodThreshold = mf.GoodThreshold
+	m.DegradedThreshold = mf.DegradedThreshold
+	m.FailureThreshold = mf.FailureThreshold
 
 	if err := validateServers(c, &m); err != nil {
 		return 0, err
@@ -215,14 +320,17 @@ func createService(c *gin.Context) (uint64, error) {
 		skipServers = append(skipServers, k)
 	}
 
-	var err error
-	if m.Cover == 0 {
-		err = singleton.DB.Unscoped().Delete(&model.ServiceHistory{}, "service_id = ? and server_id in (?)", m.ID, skipServers).Error
-	} else {
-		err = singleton.DB.Unscoped().Delete(&model.ServiceHistory{}, "service_id = ? and server_id not in (?)", m.ID, skipServers).Error
-	}
-	if err != nil {
-		return 0, err
+	// 按分组覆盖时成员关系是动态的，不清理历史记录
+	if len(m.ServerGroups) == 0 {
+		var err error
+		if m.Cover == 0 {
+			err = singleton.DB.Unscoped().Delete(&model.ServiceHistory{}, "service_id = ? and server_id in (?)", m.ID, skipServers).Error
+		} else {
+			err = singleton.DB.Unscoped().Delete(&model.ServiceHistory{}, "service_id = ? and server_id not in (?) and server_id > 0 and server_id != ?", m.ID, skipServers, singleton.DashboardReporter).Error
+		}
+		if err != nil {
+			return 0, err
+		}
 	}
 
 	if err := singleton.ServiceSentinelShared.Update(&m); err != nil {
@@ -279,6 +387,19 @@ func updateService(c *gin.Context) (any, error) {
 	m.EnableTriggerTask = mf.EnableTriggerTask
 	m.RecoverTriggerTasks = mf.RecoverTriggerTasks
 	m.FailTriggerTasks = mf.FailTriggerTasks
+	m.ServerGroups = mf.ServerGroups
+	m.ExcludeServerGroups = mf.ExcludeServerGroups
+	m.HTTPCheck = mf.HTTPCheck
+	m.DNSCheck = mf.DNSCheck
+	m.UDPCheck = mf.UDPCheck
+	m.TLSCheck = mf.TLSCheck
+	m.DashboardProbe = mf.DashboardProbe
+	m.Quorum = mf.Quorum
+	m.WindowSamples = mf.WindowSamples
+	m.WindowDuration = mf.WindowDuration
+	m.GoodThreshold = mf.GoodThreshold
+	m.DegradedThreshold = mf.DegradedThreshold
+	m.FailureThreshold = mf.FailureThreshold
 
 	if err := validateServers(c, &m); err != nil {
 		return 0, err
@@ -290,13 +411,16 @@ func updateService(c *gin.Context) (any, error) {
 
 	skipServers := utils.MapKeysToSlice(mf.SkipServers)
 
-	if m.Cover == model.ServiceCoverAll {
-		err = singleton.DB.Unscoped().Delete(&model.ServiceHistory{}, "service_id = ? and server_id in (?)", m.ID, skipServers).Error
-	} else {
-		err = singleton.DB.Unscoped().Delete(&model.ServiceHistory{}, "service_id = ? and server_id not in (?) and server_id > 0", m.ID, skipServers).Error
-	}
-	if err != nil {
-		return nil, err
+	// 按分组覆盖时成员关系是动态的，不清理历史记录
+	if len(m.ServerGroups) == 0 {
+		if m.Cover == model.ServiceCoverAll {
+			err = singleton.DB.Unscoped().Delete(&model.ServiceHistory{}, "service_id = ? and server_id in (?)", m.ID, skipServers).Error
+		} else {
+			err = singleton.DB.Unscoped().Delete(&model.ServiceHistory{}, "service_id = ? and server_id not in (?) and server_id > 0 and server_id != ?", m.ID, skipServers, singleton.DashboardReporter).Error
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if err := singleton.ServiceSentinelShared.Update(&m); err != nil {
@@ -347,5 +471,23 @@ func validateServers(c *gin.Context, ss *model.Service) error {
 		return singleton.Localizer.ErrorT("permission denied")
 	}
 
-	return nil
+	if err := ss.ValidateCheck(); err != nil {
+		return singleton.Localizer.ErrorT("invalid service check: %v", err)
+	}
+
+	if err := ss.ValidateStatusSettings(); err != nil {
+		return singleton.Localizer.ErrorT("invalid status settings: %v", err)
+	}
+
+	if ss.DashboardProbe {
+		if user := c.MustGet(model.CtxKeyAuthorizedUser).(*model.User); !user.Role.IsAdmin() {
+			return singleton.Localizer.ErrorT("permission denied")
+		}
+	}
+
+	if ss.DashboardProbe && !probe.Supported(ss.Type) {
+		return singleton.Localizer.ErrorT("dashboard probing is not supported for this service type")
+	}
+
+	return validateServerGroups(c, ss.ServerGroups, ss.ExcludeServerGroups)
 }
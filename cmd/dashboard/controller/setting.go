@@ -104,6 +104,9 @@ func updateConfig(c *gin.Context) (any, error) {
 	singleton.Conf.WebRealIPHeader = sf.WebRealIPHeader
 	singleton.Conf.AgentRealIPHeader = sf.AgentRealIPHeader
 	singleton.Conf.AgentTLS = sf.AgentTLS
+	singleton.Conf.RequireAgentCredential = sf.RequireAgentCredential
+	singleton.Conf.RequireAgentApproval = sf.RequireAgentApproval
+	singleton.Conf.PersistAgentCommands = sf.PersistAgentCommands
 	singleton.Conf.UserTemplate = sf.UserTemplate
 
 	if err := singleton.Conf.Save(); err != nil {
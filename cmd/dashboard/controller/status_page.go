@@ -0,0 +1,495 @@
+package controller
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+const statusPageFeedSize = 50
+
+// Show status page
+// @Summary Show status page
+// @Schemes
+// @Description Show the public status page with component status, uptime and incident updates
+// @Tags common
+// @param slug path string true "Status page slug"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.StatusPageResponse]
+// @Router /status-page/{slug} [get]
+func showStatusPage(c *gin.Context) (*model.StatusPageResponse, error) {
+	page, ok := singleton.StatusPageShared.GetBySlug(c.Param("slug"))
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("status page not found")
+	}
+
+	res, err, _ := requestGroup.Do("status-page-"+page.Slug, func() (any, error) {
+		return singleton.StatusPageShared.Summary(page)
+	})
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return res.(*model.StatusPageResponse), nil
+}
+
+// Status page feed
+// @Summary Status page feed
+// @Schemes
+// @Description Atom feed of the incident updates of a status page
+// @Tags common
+// @param slug path string true "Status page slug"
+// @Produce xml
+// @Success 200 {string} string "Atom feed"
+// @Router /status-page/{slug}/feed [get]
+func statusPageFeed(c *gin.Context) {
+	page, ok := singleton.StatusPageShared.GetBySlug(c.Param("slug"))
+	if !ok || !page.EnableFeed {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	incidents, err := singleton.StatusPageIncidents(page.ID, time.Time{}, statusPageFeedSize)
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	data, err := page.AtomFeed(scheme+"://"+c.Request.Host+c.Request.URL.Path, incidents)
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Data(http.StatusOK, "application/atom+xml; charset=utf-8", data)
+}
+
+// List status pages
+// @Summary List status pages
+// @Security BearerAuth
+// @Schemes
+// @Description List status pages
+// @Tags auth required
+// @Param id query uint false "Resource ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.StatusPage]
+// @Router /status-page [get]
+func listStatusPage(c *gin.Context) ([]*model.StatusPage, error) {
+	var p []*model.StatusPage
+
+	slist := singleton.StatusPageShared.GetSortedList()
+
+	if err := copier.Copy(&p, &slist); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Add status page
+// @Summary Add status page
+// @Security BearerAuth
+// @Schemes
+// @Description Add status page
+// @Tags auth required
+// @Accept json
+// @param request body model.StatusPageForm true "Status Page Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /status-page [post]
+func createStatusPage(c *gin.Context) (uint64, error) {
+	var pf model.StatusPageForm
+	if err := c.ShouldBindJSON(&pf); err != nil {
+		return 0, err
+	}
+
+	var p model.StatusPage
+	p.UserID = getUid(c)
+	if err := validateStatusPage(c, &pf, &p); err != nil {
+		return 0, err
+	}
+
+	if err := singleton.DB.Create(&p).Error; err != nil {
+		return 0, newGormError("%v", err)
+	}
+
+	singleton.StatusPageShared.Update(&p)
+	return p.ID, nil
+}
+
+// Edit status page
+// @Summary Edit status page
+// @Security BearerAuth
+// @Schemes
+// @Description Edit status page
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Status Page ID"
+// @param request body model.StatusPageForm true "Status Page Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /status-page/{id} [patch]
+func updateStatusPage(c *gin.Context) (any, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var pf model.StatusPageForm
+	if err := c.ShouldBindJSON(&pf); err != nil {
+		return nil, err
+	}
+
+	var p model.StatusPage
+	if err := singleton.DB.First(&p, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("status page id %d does not exist", id)
+	}
+
+	if !p.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	if err := validateStatusPage(c, &pf, &p); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.DB.Save(&p).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.StatusPageShared.Update(&p)
+	return nil, nil
+}
+
+// Batch delete status pages
+// @Summary Batch delete status pages
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete status pages with their incident updates
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/status-page [post]
+func batchDeleteStatusPage(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if !singleton.StatusPageShared.CheckPermission(c, slices.Values(ids)) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	err := singleton.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&model.StatusPageIncidentUpdate{},
+			"incident_id IN (SELECT id FROM status_page_incidents WHERE status_page_id IN (?))", ids).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&model.StatusPageIncident{}, "status_page_id IN (?)", ids).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.StatusPage{}, "id IN (?)", ids).Error
+	})
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.StatusPageShared.Delete(ids)
+	return nil, nil
+}
+
+// List status page incidents
+// @Summary List status page incidents
+// @Security BearerAuth
+// @Schemes
+// @Description List incident and maintenance posts of a status page with their updates, latest first
+// @Tags auth required
+// @Param status_page_id query uint true "Status Page ID"
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.StatusPageIncident, model.StatusPageIncident]
+// @Router /status-page-incident [get]
+func listStatusPageIncident(c *gin.Context) (*model.Value[[]*model.StatusPageIncident], error) {
+	page, err := findStatusPage(c, c.Query("status_page_id"))
+	if err != nil {
+		return nil, err
+	}
+	limit, offset := paginationQuery(c)
+
+	query := singleton.DB.Model(&model.StatusPageIncident{}).Where("status_page_id = ?", page.ID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	incidents := make([]*model.StatusPageIncident, 0)
+	if err := query.Order("started_at DESC, id DESC").Limit(limit).Offset(offset).Find(&incidents).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	if err := singleton.LoadStatusPageIncidentUpdates(incidents); err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.Value[[]*model.StatusPageIncident]{
+		Value: incidents,
+		Pagination: model.Pagination{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
+	}, nil
+}
+
+// Post status page incident
+// @Summary Post status page incident
+// @Security BearerAuth
+// @Schemes
+// @Description Post an incident or maintenance to a status page, the content is saved as its first update
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Status Page ID"
+// @param request body model.StatusPageIncidentForm true "Status Page Incident Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /status-page/{id}/incident [post]
+func createStatusPageIncident(c *gin.Context) (uint64, error) {
+	page, err := findStatusPage(c, c.Param("id"))
+	if err != nil {
+		return 0, err
+	}
+
+	var f model.StatusPageIncidentForm
+	if err := c.ShouldBindJSON(&f); err != nil {
+		return 0, err
+	}
+
+	incident := model.StatusPageIncident{
+		StatusPageID: page.ID,
+		Type:         f.Type,
+		Title:        f.Title,
+		Status:       f.Status,
+		Components:   f.Components,
+		StartedAt:    f.StartedAt,
+	}
+	incident.UserID = getUid(c)
+	if incident.Type != model.StatusPageIncidentTypeIncident && incident.Type != model.StatusPageIncidentTypeMaintenance {
+		return 0, singleton.Localizer.ErrorT("invalid incident type %d", incident.Type)
+	}
+	if !incident.ValidStatus(f.Status) {
+		return 0, singleton.Localizer.ErrorT("invalid status %s", f.Status)
+	}
+	for _, name := range f.Components {
+		if !slices.ContainsFunc(page.Components, func(component model.StatusPageComponent) bool {
+			return component.Name == name
+		}) {
+			return 0, singleton.Localizer.ErrorT("component %s does not exist", name)
+		}
+	}
+	now := time.Now()
+	if incident.StartedAt.IsZero() {
+		incident.StartedAt = now
+	}
+	if model.IsFinalStatus(f.Status) {
+		incident.ResolvedAt = &now
+	}
+
+	err = singleton.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&incident).Error; err != nil {
+			return err
+		}
+		update := model.StatusPageIncidentUpdate{
+			IncidentID: incident.ID,
+			Status:     f.Status,
+			Content:    f.Content,
+		}
+		update.UserID = incident.UserID
+		return tx.Create(&update).Error
+	})
+	if err != nil {
+		return 0, newGormError("%v", err)
+	}
+
+	singleton.StatusPageShared.Invalidate(page.Slug)
+	return incident.ID, nil
+}
+
+// Update status page incident
+// @Summary Update status page incident
+// @Security BearerAuth
+// @Schemes
+// @Description Post an update to an incident or maintenance, the incident ends when the status is resolved or completed
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Status Page Incident ID"
+// @param request body model.StatusPageIncidentUpdateForm true "Status Page Incident Update Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /status-page-incident/{id}/update [post]
+func createStatusPageIncidentUpdate(c *gin.Context) (uint64, error) {
+	incident, page, err := findStatusPageIncident(c)
+	if err != nil {
+		return 0, err
+	}
+
+	var f model.StatusPageIncidentUpdateForm
+	if err := c.ShouldBindJSON(&f); err != nil {
+		return 0, err
+	}
+	if !incident.ValidStatus(f.Status) {
+		return 0, singleton.Localizer.ErrorT("invalid status %s", f.Status)
+	}
+
+	update := model.StatusPageIncidentUpdate{
+		IncidentID: incident.ID,
+		Status:     f.Status,
+		Content:    f.Content,
+	}
+	update.UserID = getUid(c)
+
+	incident.Status = f.Status
+	if model.IsFinalStatus(f.Status) {
+		if !incident.Resolved() {
+			now := time.Now()
+			incident.ResolvedAt = &now
+		}
+	} else {
+		incident.ResolvedAt = nil
+	}
+
+	err = singleton.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&update).Error; err != nil {
+			return err
+		}
+		return tx.Save(incident).Error
+	})
+	if err != nil {
+		return 0, newGormError("%v", err)
+	}
+
+	singleton.StatusPageShared.Invalidate(page.Slug)
+	return update.ID, nil
+}
+
+// Batch delete status page incidents
+// @Summary Batch delete status page incidents
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete incident and maintenance posts with their updates
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/status-page-incident [post]
+func batchDeleteStatusPageIncident(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	var incidents []model.StatusPageIncident
+	if err := singleton.DB.Where("id IN (?)", ids).Find(&incidents).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	pageIDs := make([]uint64, 0, len(incidents))
+	for _, i := range incidents {
+		pageIDs = append(pageIDs, i.StatusPageID)
+	}
+	if !singleton.StatusPageShared.CheckPermission(c, slices.Values(pageIDs)) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	err := singleton.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&model.StatusPageIncidentUpdate{}, "incident_id IN (?)", ids).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.StatusPageIncident{}, "id IN (?)", ids).Error
+	})
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	for _, id := range pageIDs {
+		if page, ok := singleton.StatusPageShared.Get(id); ok {
+			singleton.StatusPageShared.Invalidate(page.Slug)
+		}
+	}
+	return nil, nil
+}
+
+func findStatusPage(c *gin.Context, idStr string) (*model.StatusPage, error) {
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	page, ok := singleton.StatusPageShared.Get(id)
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("status page id %d does not exist", id)
+	}
+	if !page.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+	return page, nil
+}
+
+func findStatusPageIncident(c *gin.Context) (*model.StatusPageIncident, *model.StatusPage, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var incident model.StatusPageIncident
+	if err := singleton.DB.First(&incident, id).Error; err != nil {
+		return nil, nil, singleton.Localizer.ErrorT("status page incident id %d does not exist", id)
+	}
+	page, ok := singleton.StatusPageShared.Get(incident.StatusPageID)
+	if !ok {
+		return nil, nil, singleton.Localizer.ErrorT("status page id %d does not exist", incident.StatusPageID)
+	}
+	if !page.HasPermission(c) {
+		return nil, nil, singleton.Localizer.ErrorT("permission denied")
+	}
+	return &incident, page, nil
+}
+
+func validateStatusPage(c *gin.Context, pf *model.StatusPageForm, p *model.StatusPage) error {
+	p.Name = pf.Name
+	p.Slug = pf.Slug
+	p.Description = pf.Description
+	p.EnableFeed = pf.EnableFeed
+	p.Components = pf.Components
+
+	if err := p.Validate(); err != nil {
+		return singleton.Localizer.ErrorT("invalid status page: %v", err)
+	}
+	if existing, ok := singleton.StatusPageShared.GetBySlug(p.Slug); ok && existing.ID != p.ID {
+		return singleton.Localizer.ErrorT("slug %s is already in use", p.Slug)
+	}
+
+	serviceIDs := p.ServiceIDs()
+	for _, id := range serviceIDs {
+		if _, ok := singleton.ServiceSentinelShared.Get(id); !ok {
+			return singleton.Localizer.ErrorT("service id %d does not exist", id)
+		}
+	}
+	if !singleton.ServiceSentinelShared.CheckPermission(c, slices.Values(serviceIDs)) {
+		return singleton.Localizer.ErrorT("permission denied")
+	}
+	return nil
+}
@@ -70,6 +70,36 @@ func initSystem(bus chan<- *model.Service) error {
 		return err
 	}
 
+	// 每小时汇总服务监控的每日可用性
+	if _, err := singleton.CronShared.AddFunc("0 5 * * * *", singleton.RollupServiceHistory); err != nil {
+		return err
+	}
+
+	// 每天的3:40 清理过期的服务器指标
+	if _, err := singleton.CronShared.AddFunc("0 40 3 * * *", singleton.CleanServerMetrics); err != nil {
+		return err
+	}
+
+	// 每天的3:50 清理过期的通知发送记录
+	if _, err := singleton.CronShared.AddFunc("0 50 3 * * *", singleton.CleanNotificationDeliveries); err != nil {
+		return err
+	}
+
+	// 每 5 分钟保存一次报警状态快照
+	if _, err := singleton.CronShared.AddFunc("0 */5 * * * *", singleton.SaveAlertState); err != nil {
+		return err
+	}
+
+	// 每分钟检查是否有通知组需要发送每日摘要
+	if _, err := singleton.CronShared.AddFunc("0 * * * * *", singleton.NotificationShared.SendDailyDigests); err != nil {
+		return err
+	}
+
+	// 每分钟清理离线服务器队列中过期的任务
+	if _, err := singleton.CronShared.AddFunc("30 * * * * *", singleton.TaskQueueShared.ExpireTasks); err != nil {
+		return err
+	}
+
 	// 每小时对流量记录进行打点
 	if _, err := singleton.CronShared.AddFunc("0 0 * * * *", func() { singleton.RecordTransferHourlyUsage() }); err != nil {
 		return err
@@ -124,6 +154,7 @@ func main() {
 		log.Fatal(err)
 	}
 
+	singleton.BackfillServiceDailyStats()
 	singleton.CleanServiceHistory()
 	rpc.DispatchKeepalive()
 	go rpc.DispatchTask(serviceSentinelDispatchBus)
@@ -152,6 +183,11 @@ func main() {
 				InsecureSkipVerify: singleton.Conf.HTTPS.InsecureTLS,
 			},
 		}
+		// 浏览器不会提供客户端证书，只校验 Agent 主动提供的证书
+		if singleton.AgentCAShared.Enabled() {
+			muxServerHTTPS.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			muxServerHTTPS.TLSConfig.ClientCAs = singleton.AgentCAShared.CertPool()
+		}
 	}
 
 	errChan := make(chan error, 2)
@@ -172,6 +208,9 @@ func main() {
 	}, func(c context.Context) error {
 		log.Println("NEZHA>> Graceful::START")
 		singleton.RecordTransferHourlyUsage()
+		singleton.ServerMetricShared.Flush()
+		singleton.SaveAlertState()
+		singleton.NotificationShared.FlushDigests()
 		log.Println("NEZHA>> Graceful::END")
 		var err error
 		if muxServerHTTPS != nil {
@@ -81,32 +81,38 @@ func DispatchTask(serviceSentinelDispatchBus <-chan *model.Service) {
 			continue
 		}
 
-		switch task.Cover {
-		case model.ServiceCoverIgnoreAll:
-			for id, enabled := range task.SkipServers {
-				if !enabled {
-					continue
-				}
-
-				server, _ := singleton.ServerShared.Get(id)
-				if server == nil || server.TaskStream == nil {
-					continue
-				}
-
-				if canSendTaskToServer(task, server) {
-					server.TaskStream.Send(task.PB())
-				}
-			}
-		case model.ServiceCoverAll:
-			for id, server := range singleton.ServerShared.Range {
-				if server == nil || server.TaskStream == nil || task.SkipServers[id] {
-					continue
-				}
-
-				if canSendTaskToServer(task, server) {
-					server.TaskStream.Send(task.PB())
-				}
+		dispatchToAgents(task)
+
+		// 面板探测只对管理员开放，所有者降级后不再执行
+		if task.DashboardProbe && ownerRole(task).IsAdmin() {
+			singleton.ServiceSentinelShared.ProbeFromDashboard(task)
+		}
+	}
+}
+
+// dispatchToAgents 将服务监控任务下发给范围内的 Agent，需要检查配置的任务只下发给声明支持的 Agent
+func dispatchToAgents(task *model.Service) {
+	requiresCheck := task.RequiresServiceCheck()
+	for id, server := range singleton.ServerShared.Range {
+		if server == nil || server.TaskStream == nil {
+			continue
+		}
+		if !singleton.ServerGroupShared.InScope(id, task.ServerGroups, task.ExcludeServerGroups, func() bool {
+			if task.Cover == model.ServiceCoverIgnoreAll {
+				return task.SkipServers[id]
 			}
+			return !task.SkipServers[id]
+		}) {
+			continue
+		}
+
+		if !canSendTaskToServer(task, server) {
+			continue
+		}
+		if !requiresCheck {
+			singleton.TaskQueueShared.Post(server, task.PB())
+		} else if server.Supports(model.AgentFeatureServiceCheck) {
+			singleton.TaskQueueShared.Post(server, task.CheckPB())
 		}
 	}
 }
@@ -118,7 +124,7 @@ func DispatchKeepalive() {
 			if s == nil || s.TaskStream == nil {
 				continue
 			}
-			s.TaskStream.Send(&proto.Task{Type: model.TaskTypeKeepalive})
+			singleton.TaskQueueShared.Post(s, &proto.Task{Type: model.TaskTypeKeepalive})
 		}
 	})
 }
@@ -151,7 +157,7 @@ func ServeNAT(w http.ResponseWriter, r *http.Request, natConfig *model.NAT) {
 		return
 	}
 
-	if err := server.TaskStream.Send(&proto.Task{
+	if err := singleton.TaskQueueShared.Send(server, &proto.Task{
 		Type: model.TaskTypeNAT,
 		Data: string(taskData),
 	}); err != nil {
@@ -177,14 +183,15 @@ func ServeNAT(w http.ResponseWriter, r *http.Request, natConfig *model.NAT) {
 }
 
 func canSendTaskToServer(task *model.Service, server *model.Server) bool {
-	var role model.Role
+	return task.UserID == server.UserID || ownerRole(task).IsAdmin()
+}
+
+func ownerRole(task *model.Service) model.Role {
 	singleton.UserLock.RLock()
-	if u, ok := singleton.UserInfoMap[task.UserID]; !ok {
-		role = model.RoleMember
-	} else {
-		role = u.Role
-	}
-	singleton.UserLock.RUnlock()
+	defer singleton.UserLock.RUnlock()
 
-	return task.UserID == server.UserID || role.IsAdmin()
+	if u, ok := singleton.UserInfoMap[task.UserID]; ok {
+		return u.Role
+	}
+	return model.RoleMember
 }
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// 开启 mTLS 后，注册成功或续签时通过 gRPC 响应头下发客户端证书与私钥（DER 编码）
+const (
+	MetadataKeyAgentCertificate = "client_certificate-bin"
+	MetadataKeyAgentKey         = "client_key-bin"
+)
+
+// AgentCA 面板用于签发 Agent 客户端证书的 CA，只保存一份
+type AgentCA struct {
+	ID        uint64    `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"<-:create"`
+	CertPEM   string    `gorm:"type:longtext"`
+	KeyPEM    string    `gorm:"type:longtext"`
+	CRLNumber int64     // 每次生成吊销列表时递增
+}
+
+// AgentCertificate 签发给服务器的客户端证书，证书身份按序列号映射到服务器
+type AgentCertificate struct {
+	Common
+	ServerID  uint64     `gorm:"index" json:"server_id"`
+	Serial    string     `gorm:"uniqueIndex" json:"serial"` // 十六进制序列号
+	NotAfter  time.Time  `json:"not_after"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (c *AgentCertificate) Revoked() bool {
+	return c.RevokedAt != nil
+}
+
+// Valid 判断证书在 now 时是否未过期且未被吊销
+func (c *AgentCertificate) Valid(now time.Time) bool {
+	return !c.Revoked() && now.Before(c.NotAfter)
+}
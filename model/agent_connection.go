@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+const (
+	AgentStreamTask  = "task"  // RequestTask
+	AgentStreamState = "state" // ReportSystemState
+)
+
+// AgentConnection Agent 当前连接的一条任务流或状态流
+type AgentConnection struct {
+	ID              uint64    `json:"id"`
+	ServerID        uint64    `json:"server_id"`
+	ServerName      string    `json:"server_name,omitempty"`
+	Stream          string    `json:"stream"`
+	IP              string    `json:"ip,omitempty"`
+	AgentVersion    string    `json:"agent_version,omitempty"`
+	ConnectedAt     time.Time `json:"connected_at"`
+	LastReceivedAt  time.Time `json:"last_received_at"`
+	ReconnectsToday uint64    `json:"reconnects_today"` // 当天同一服务器同类流的重连次数
+}
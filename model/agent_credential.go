@@ -0,0 +1,59 @@
+package model
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	AgentCredentialLength      = 48
+	AgentEnrollmentTokenLength = 32
+
+	// MetadataKeyAgentCredential 注册成功后通过 gRPC 响应头下发服务器专属凭据，Agent 之后以此作为 client_secret 连接
+	MetadataKeyAgentCredential = "client_credential"
+)
+
+// AgentEnrollmentToken 一次性的 Agent 注册令牌，首次连接时换取服务器专属凭据
+type AgentEnrollmentToken struct {
+	Common
+	Note      string     `json:"note,omitempty"`
+	TokenHash string     `gorm:"uniqueIndex;type:char(64)" json:"-"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	ServerID  uint64     `json:"server_id,omitempty"` // 使用该令牌注册的服务器
+}
+
+// Usable 判断令牌是否未使用且未过期
+func (t *AgentEnrollmentToken) Usable(now time.Time) bool {
+	return t.UsedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
+
+// ServerCredential 服务器专属的 Agent 凭据，与服务器的 UUID 绑定
+type ServerCredential struct {
+	Common
+	ServerID   uint64     `gorm:"uniqueIndex" json:"server_id"`
+	SecretHash string     `gorm:"type:char(64)" json:"-"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (c *ServerCredential) Revoked() bool {
+	return c.RevokedAt != nil
+}
+
+// Verify 校验 Agent 提供的凭据，已吊销的凭据总是校验失败
+func (c *ServerCredential) Verify(secret string) bool {
+	if c.Revoked() || secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashAgentSecret(secret)), []byte(c.SecretHash)) == 1
+}
+
+// HashAgentSecret 凭据与注册令牌只保存哈希值
+func HashAgentSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
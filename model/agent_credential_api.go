@@ -0,0 +1,32 @@
+package model
+
+type AgentEnrollmentTokenForm struct {
+	Note      string `json:"note,omitempty" validate:"optional"`
+	ExpiresIn uint64 `json:"expires_in,omitempty" validate:"optional"` // 有效期（小时），0 为不过期
+}
+
+// AgentEnrollmentTokenSecret 新建的注册令牌，明文只在创建时返回一次
+type AgentEnrollmentTokenSecret struct {
+	ID    uint64 `json:"id"`
+	Token string `json:"token"`
+}
+
+// ServerCredentialSecret 轮换后的服务器凭据，明文只在轮换时返回一次
+type ServerCredentialSecret struct {
+	ServerID uint64 `json:"server_id"`
+	Secret   string `json:"secret"`
+}
+
+// AgentCertificateSecret 新签发的客户端证书，私钥只在签发时返回一次
+type AgentCertificateSecret struct {
+	ServerID    uint64 `json:"server_id"`
+	Serial      string `json:"serial"`
+	Certificate string `json:"certificate"` // PEM
+	PrivateKey  string `json:"private_key"` // PEM
+	CA          string `json:"ca"`          // PEM
+}
+
+type AgentCAResponse struct {
+	Certificate string `json:"certificate"` // PEM
+	CRL         string `json:"crl"`         // PEM，包含所有未过期的已吊销证书
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServerCredentialVerify(t *testing.T) {
+	cred := &ServerCredential{SecretHash: HashAgentSecret("secret")}
+	if !cred.Verify("secret") {
+		t.Fatal("expected the credential to be valid")
+	}
+	if cred.Verify("other") || cred.Verify("") {
+		t.Fatal("expected a wrong secret to be rejected")
+	}
+
+	now := time.Now()
+	cred.RevokedAt = &now
+	if cred.Verify("secret") {
+		t.Fatal("expected a revoked credential to be rejected")
+	}
+}
+
+func TestAgentEnrollmentTokenUsable(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	cases := []struct {
+		token AgentEnrollmentToken
+		exp   bool
+	}{
+		{token: AgentEnrollmentToken{}, exp: true},
+		{token: AgentEnrollmentToken{ExpiresAt: &future}, exp: true},
+		{token: AgentEnrollmentToken{ExpiresAt: &past}, exp: false},
+		{token: AgentEnrollmentToken{UsedAt: &past}, exp: false},
+	}
+	for i, c := range cases {
+		if got := c.token.Usable(now); got != c.exp {
+			t.Errorf("case %d: expected %v, got %v", i, c.exp, got)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package model
+
+import "strings"
+
+const (
+	// MetadataKeyAgentFeatures Agent 通过请求元数据声明支持的功能，多个功能以逗号分隔
+	MetadataKeyAgentFeatures = "client_features"
+
+	// AgentFeatureCredential Agent 支持读取注册后下发的服务器凭据，不支持的 Agent 不会使用注册令牌
+	AgentFeatureCredential = "credential"
+	// AgentFeatureServiceCheck Agent 支持 JSON 格式的 HTTP 检查以及 DNS、UDP、TLS 监控，不支持的 Agent 不会收到这些任务
+	AgentFeatureServiceCheck = "service_check"
+)
+
+// ParseAgentFeatures 解析 Agent 在请求元数据中声明的功能
+func ParseAgentFeatures(values []string) []string {
+	var features []string
+	for _, v := range values {
+		for f := range strings.SplitSeq(v, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				features = append(features, f)
+			}
+		}
+	}
+	return features
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+const (
+	AgentTaskQueued       uint8 = iota // 等待发送
+	AgentTaskSent                      // 已写入任务流，等待 Agent 回报结果
+	AgentTaskAcknowledged              // Agent 已回报结果
+	AgentTaskExpired                   // 在有效期内未能发送
+)
+
+// AgentTask 下发给 Agent 的任务，ID 为面板分配的投递 ID。
+// 任务协议中没有投递 ID，Agent 回报结果时按任务类型与任务 Id 匹配，
+// 因此只跟踪会回报结果的命令任务，且同一命令的多次发送只能按顺序确认
+type AgentTask struct {
+	Common
+	ServerID   uint64     `gorm:"index" json:"server_id"`
+	Type       uint64     `json:"type"`
+	TaskID     uint64     `json:"task_id,omitempty"` // 任务本身的 Id，如计划任务 ID
+	Data       string     `json:"-"`
+	Status     uint8      `json:"status"`
+	Persistent bool       `gorm:"-" json:"persistent,omitempty"` // 已持久化，面板重启后继续下发
+	ExpiresAt  time.Time  `json:"expires_at"`
+	SentAt     *time.Time `json:"sent_at,omitempty"`
+	AckedAt    *time.Time `json:"acked_at,omitempty"`
+	Successful bool       `json:"successful,omitempty"`
+}
+
+func (t *AgentTask) PB() *pb.Task {
+	return &pb.Task{
+		Id:   t.TaskID,
+		Type: t.Type,
+		Data: t.Data,
+	}
+}
+
+// Expired 是否已超过发送有效期
+func (t *AgentTask) Expired(now time.Time) bool {
+	return t.Status == AgentTaskQueued && !now.Before(t.ExpiresAt)
+}
+
+// Acknowledges 判断 Agent 回报的结果是否对应该任务
+func (t *AgentTask) Acknowledges(r *pb.TaskResult) bool {
+	return t.Status == AgentTaskSent && t.Type == r.GetType() && t.TaskID == r.GetId()
+}
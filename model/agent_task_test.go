@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+func TestAgentTaskExpired(t *testing.T) {
+	now := time.Now()
+	task := AgentTask{Status: AgentTaskQueued, ExpiresAt: now}
+	if !task.Expired(now) {
+		t.Fatal("expected a queued task to expire at its deadline")
+	}
+	if task.Expired(now.Add(-time.Second)) {
+		t.Fatal("expected the task to be valid before its deadline")
+	}
+	task.Status = AgentTaskSent
+	if task.Expired(now.Add(time.Hour)) {
+		t.Fatal("expected a sent task not to expire")
+	}
+}
+
+func TestAgentTaskAcknowledges(t *testing.T) {
+	task := AgentTask{Status: AgentTaskSent, Type: TaskTypeCommand, TaskID: 3}
+	if !task.Acknowledges(&pb.TaskResult{Type: TaskTypeCommand, Id: 3}) {
+		t.Fatal("expected the result to acknowledge the task")
+	}
+	if task.Acknowledges(&pb.TaskResult{Type: TaskTypeCommand, Id: 4}) {
+		t.Fatal("expected a result of another cron not to acknowledge the task")
+	}
+	if task.Acknowledges(&pb.TaskResult{Type: TaskTypeHTTPGet, Id: 3}) {
+		t.Fatal("expected a result of another type not to acknowledge the task")
+	}
+	task.Status = AgentTaskQueued
+	if task.Acknowledges(&pb.TaskResult{Type: TaskTypeCommand, Id: 3}) {
+		t.Fatal("expected a queued task not to be acknowledged")
+	}
+}
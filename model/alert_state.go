@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+)
+
+// AlertState 报警规则在单台服务器上的检查状态快照，用于面板重启后恢复
+type AlertState struct {
+	AlertRuleID uint64    `gorm:"primaryKey;autoIncrement:false"`
+	ServerID    uint64    `gorm:"primaryKey;autoIncrement:false"`
+	PrevState   uint8     // 上一次的报警状态
+	SamplesRaw  string    `gorm:"default:'[]'"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+
+	Samples [][]bool `gorm:"-"` // [timeTick][ruleId] 时间点对应的 rule 的检查结果
+}
+
+func (s *AlertState) BeforeSave(tx *gorm.DB) error {
+	if data, err := json.Marshal(s.Samples); err != nil {
+		return err
+	} else {
+		s.SamplesRaw = string(data)
+	}
+	return nil
+}
+
+func (s *AlertState) AfterFind(tx *gorm.DB) error {
+	return json.Unmarshal([]byte(s.SamplesRaw), &s.Samples)
+}
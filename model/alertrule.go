@@ -19,11 +19,16 @@ type AlertRule struct {
 	Enable                 *bool    `json:"enable,omitempty"`
 	TriggerMode            uint8    `gorm:"default:0" json:"trigger_mode"` // 触发模式: 0-始终触发(默认) 1-单次触发
 	NotificationGroupID    uint64   `json:"notification_group_id"`         // 该报警规则所在的通知组
+	Severity               uint8    `gorm:"default:2" json:"severity"`     // 通知级别: 1-信息 2-警告(默认) 3-严重
 	FailTriggerTasksRaw    string   `gorm:"default:'[]'" json:"-"`
 	RecoverTriggerTasksRaw string   `gorm:"default:'[]'" json:"-"`
+	ServerGroupsRaw        string   `gorm:"default:'[]'" json:"-"`
+	ExcludeServerGroupsRaw string   `gorm:"default:'[]'" json:"-"`
 	Rules                  []*Rule  `gorm:"-" json:"rules"`
 	FailTriggerTasks       []uint64 `gorm:"-" json:"fail_trigger_tasks"`    // 失败时执行的触发任务id
 	RecoverTriggerTasks    []uint64 `gorm:"-" json:"recover_trigger_tasks"` // 恢复时执行的触发任务id
+	ServerGroups           []uint64 `gorm:"-" json:"server_groups"`         // 覆盖的服务器分组，指定后仅检查分组内的服务器，忽略规则的 Cover
+	ExcludeServerGroups    []uint64 `gorm:"-" json:"exclude_server_groups"` // 排除的服务器分组
 }
 
 func (r *AlertRule) BeforeSave(tx *gorm.DB) error {
@@ -42,6 +47,16 @@ func (r *AlertRule) BeforeSave(tx *gorm.DB) error {
 	} else {
 		r.RecoverTriggerTasksRaw = string(data)
 	}
+	if data, err := json.Marshal(r.ServerGroups); err != nil {
+		return err
+	} else {
+		r.ServerGroupsRaw = string(data)
+	}
+	if data, err := json.Marshal(r.ExcludeServerGroups); err != nil {
+		return err
+	} else {
+		r.ExcludeServerGroupsRaw = string(data)
+	}
 	return nil
 }
 
@@ -56,6 +71,12 @@ func (r *AlertRule) AfterFind(tx *gorm.DB) error {
 	if err = json.Unmarshal([]byte(r.RecoverTriggerTasksRaw), &r.RecoverTriggerTasks); err != nil {
 		return err
 	}
+	if err = json.Unmarshal([]byte(r.ServerGroupsRaw), &r.ServerGroups); err != nil {
+		return err
+	}
+	if err = json.Unmarshal([]byte(r.ExcludeServerGroupsRaw), &r.ExcludeServerGroups); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -63,6 +84,16 @@ func (r *AlertRule) Enabled() bool {
 	return r.Enable != nil && *r.Enable
 }
 
+// Covers 报警规则下所有规则均覆盖该服务器时返回 true，未指定服务器分组时用于判断覆盖范围
+func (r *AlertRule) Covers(serverID uint64) bool {
+	for _, rule := range r.Rules {
+		if !rule.Covers(serverID) {
+			return false
+		}
+	}
+	return true
+}
+
 // Snapshot 对传入的Server进行该报警规则下所有type的检查 返回每项检查结果
 func (r *AlertRule) Snapshot(cycleTransferStats *CycleTransferStats, server *Server, db *gorm.DB) []bool {
 	point := make([]bool, len(r.Rules))
@@ -73,6 +104,21 @@ func (r *AlertRule) Snapshot(cycleTransferStats *CycleTransferStats, server *Ser
 	return point
 }
 
+// Metrics 返回报警规则下各项指标的当前值，用于渲染通知模板
+func (r *AlertRule) Metrics(cycleTransferStats *CycleTransferStats, server *Server) []NotificationMetric {
+	metrics := make([]NotificationMetric, len(r.Rules))
+	for i, rule := range r.Rules {
+		metrics[i] = NotificationMetric{
+			Type:       rule.Type,
+			Value:      rule.Value(cycleTransferStats, server),
+			Min:        rule.Min,
+			Max:        rule.Max,
+			Expression: rule.Expression,
+		}
+	}
+	return metrics
+}
+
 // Check 传入包含当前报警规则下所有type检查结果 返回报警持续时间与是否通过报警检查(通过则返回true)
 func (r *AlertRule) Check(points [][]bool) (int, bool) {
 	var hasPassedRule bool
@@ -7,5 +7,8 @@ type AlertRuleForm struct {
 	RecoverTriggerTasks []uint64 `json:"recover_trigger_tasks"` // 恢复时触发的任务id
 	NotificationGroupID uint64   `json:"notification_group_id"`
 	TriggerMode         uint8    `json:"trigger_mode" default:"0"`
+	Severity            uint8    `json:"severity,omitempty" validate:"optional"` // 通知级别，默认为警告
 	Enable              bool     `json:"enable" validate:"optional"`
+	ServerGroups        []uint64 `json:"server_groups,omitempty" validate:"optional"`         // 覆盖的服务器分组
+	ExcludeServerGroups []uint64 `json:"exclude_server_groups,omitempty" validate:"optional"` // 排除的服务器分组
 }
@@ -3,6 +3,7 @@ package model
 import (
 	"slices"
 	"testing"
+	"time"
 )
 
 type arSt struct {
@@ -18,6 +19,7 @@ func TestAlertRules(t *testing.T) {
 	t.Run("OfflineRules", testOfflineRules)
 	t.Run("GeneralRules", testGeneralRules)
 	t.Run("CombinedRules", testCombinedRules)
+	t.Run("ExpressionRules", testExpressionRules)
 }
 
 func testCycleRules(t *testing.T) {
@@ -294,6 +296,52 @@ func testCombinedRules(t *testing.T) {
 	}
 }
 
+func testExpressionRules(t *testing.T) {
+	server := &Server{
+		Common:     Common{ID: 1},
+		LastActive: time.Now(),
+		Host: &Host{
+			CPU:       []string{"AMD EPYC 7763 64-Core Processor 2 Virtual Core"},
+			MemTotal:  1000,
+			SwapTotal: 1000,
+			DiskTotal: 20 << 30,
+		},
+		State: &HostState{
+			MemUsed:  950,
+			SwapUsed: 600,
+			DiskUsed: 15 << 30,
+			Load1:    5,
+		},
+	}
+
+	cases := []struct {
+		expression string
+		exp        bool
+	}{
+		{"memory > 90 && swap > 50", false},
+		{"memory > 90 && swap > 70", true},
+		{"disk_free < 10 GiB", false},
+		{"disk_free < 4 GiB", true},
+		{"load1 / cpu_cores > 2", false},
+		{"load1 / cpu_cores > 3", true},
+	}
+
+	for _, c := range cases {
+		rule := &Rule{Type: "expression", Expression: c.expression}
+		assertEq(t, c.expression, c.exp, rule.Snapshot(nil, server, nil))
+	}
+
+	// 离线或从未上报的服务器没有数据，不触发表达式规则
+	rule := &Rule{Type: "expression", Expression: "disk_free < 10 GiB"}
+	offline := *server
+	offline.LastActive = time.Now().Add(-time.Minute)
+	assertEq(t, "offline server", true, rule.Snapshot(nil, &offline, nil))
+	offline.LastActive = time.Time{}
+	assertEq(t, "never reported", true, rule.Snapshot(nil, &offline, nil))
+	offline.LastActive, offline.State = time.Now(), nil
+	assertEq(t, "nil state", true, rule.Snapshot(nil, &offline, nil))
+}
+
 func repeat[S ~[]E, E any](x S, count int) []S {
 	var slices []S
 	for range count {
@@ -315,3 +363,13 @@ func assertEq(t *testing.T, msg string, exp, act any) {
 		t.Fatalf("failed to test for %s. exp=[%v] but act=[%v]", msg, exp, act)
 	}
 }
+
+func TestAlertRuleCovers(t *testing.T) {
+	r := &AlertRule{Rules: []*Rule{
+		{Type: "cpu", Cover: RuleCoverAll, Ignore: map[uint64]bool{2: true}},
+		{Type: "memory", Cover: RuleCoverIgnoreAll, Ignore: map[uint64]bool{1: true, 2: true}},
+	}}
+	assertEq(t, "covered by all rules", true, r.Covers(1))
+	assertEq(t, "ignored by one rule", false, r.Covers(2))
+	assertEq(t, "not selected by ignore all", false, r.Covers(3))
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -46,6 +47,10 @@ type ConfigDashboard struct {
 	IgnoredIPNotification       string `koanf:"ignored_ip_notification" json:"ignored_ip_notification,omitempty"` // 特定服务器IP（多个服务器用逗号分隔）
 
 	DNSServers string `koanf:"dns_servers" json:"dns_servers,omitempty"`
+
+	RequireAgentCredential bool `koanf:"require_agent_credential" json:"require_agent_credential,omitempty"` // 不再接受用户密钥，Agent 只能通过注册令牌与服务器凭据认证，需要 Agent 声明支持服务器凭据
+	RequireAgentApproval   bool `koanf:"require_agent_approval" json:"require_agent_approval,omitempty"`     // 使用用户密钥连接的未知 Agent 需经管理员审核后才会添加为服务器
+	PersistAgentCommands   bool `koanf:"persist_agent_commands" json:"persist_agent_commands,omitempty"`     // 排队中的命令持久化到数据库，面板重启后继续下发
 }
 
 type Config struct {
@@ -53,6 +58,10 @@ type Config struct {
 	ConfigDashboard
 
 	AvgPingCount int `koanf:"avg_ping_count" json:"avg_ping_count,omitempty"`
+	// 服务监控每日汇总数据保留时长（天）
+	ServiceStatRetention uint64 `koanf:"service_stat_retention" json:"service_stat_retention,omitempty"`
+	// Agent 离线时命令在队列中等待的时长（分钟）
+	AgentCommandTTL uint64 `koanf:"agent_command_ttl" json:"agent_command_ttl,omitempty"`
 
 	Debug          bool   `koanf:"debug" json:"debug,omitempty"`           // debug模式开关
 	Location       string `koanf:"location" json:"location,omitempty"`     // 时区，默认为 Asia/Shanghai
@@ -70,6 +79,15 @@ type Config struct {
 	// HTTPS 配置
 	HTTPS HTTPSConf `koanf:"https" json:"https"`
 
+	// 服务器指标存储配置
+	Metrics MetricsConf `koanf:"metrics" json:"metrics"`
+
+	// Prometheus 指标导出配置
+	Prometheus PrometheusConf `koanf:"prometheus" json:"prometheus"`
+
+	// Agent 双向 TLS 认证配置
+	AgentMTLS AgentMTLSConf `koanf:"agent_mtls" json:"agent_mtls"`
+
 	k        *koanf.Koanf `json:"-"`
 	filePath string       `json:"-"`
 }
@@ -81,6 +99,26 @@ type HTTPSConf struct {
 	TLSKeyPath  string `koanf:"tls_key_path" json:"tls_key_path,omitempty"`
 }
 
+type MetricsConf struct {
+	Disable         bool   `koanf:"disable" json:"disable,omitempty"`
+	RawInterval     uint64 `koanf:"raw_interval" json:"raw_interval,omitempty"`         // 原始数据采样间隔（秒）
+	RawRetention    uint64 `koanf:"raw_retention" json:"raw_retention,omitempty"`       // 原始数据保留时长（小时）
+	MinuteRetention uint64 `koanf:"minute_retention" json:"minute_retention,omitempty"` // 分钟级数据保留时长（天）
+	HourRetention   uint64 `koanf:"hour_retention" json:"hour_retention,omitempty"`     // 小时级数据保留时长（天）
+	DayRetention    uint64 `koanf:"day_retention" json:"day_retention,omitempty"`       // 天级数据保留时长（天）
+}
+
+type AgentMTLSConf struct {
+	Enable       bool   `koanf:"enable" json:"enable,omitempty"`               // 在 HTTPS 端口校验 Agent 客户端证书，并在注册时签发证书
+	Require      bool   `koanf:"require" json:"require,omitempty"`             // 除通过 HTTPS 端口注册或使用服务器凭据续签证书外，拒绝未提供有效客户端证书的 Agent
+	CertValidity uint64 `koanf:"cert_validity" json:"cert_validity,omitempty"` // 客户端证书有效期（天）
+}
+
+type PrometheusConf struct {
+	Enable      bool   `koanf:"enable" json:"enable,omitempty"`
+	ScrapeToken string `koanf:"scrape_token" json:"scrape_token,omitempty"` // 抓取 /metrics 时需携带的令牌
+}
+
 // Read 读取配置文件并应用
 func (c *Config) Read(path string, frontendTemplates []FrontendTemplate) error {
 	c.k = koanf.New(".")
@@ -105,6 +143,11 @@ func (c *Config) Read(path string, frontendTemplates []FrontendTemplate) error {
 		return err
 	}
 
+	// 只有 HTTPS 端口能校验客户端证书，否则证书与私钥会通过明文连接下发
+	if (c.AgentMTLS.Enable || c.AgentMTLS.Require) && c.HTTPS.ListenPort == 0 {
+		return errors.New("agent_mtls requires https.listen_port to be set")
+	}
+
 	if c.ListenPort == 0 {
 		c.ListenPort = 8008
 	}
@@ -135,9 +178,33 @@ func (c *Config) Read(path string, frontendTemplates []FrontendTemplate) error {
 	if c.AvgPingCount == 0 {
 		c.AvgPingCount = 2
 	}
+	if c.ServiceStatRetention == 0 {
+		c.ServiceStatRetention = 400
+	}
+	if c.AgentCommandTTL == 0 {
+		c.AgentCommandTTL = 10
+	}
 	if c.Cover == 0 {
 		c.Cover = 1
 	}
+	if c.Metrics.RawInterval == 0 {
+		c.Metrics.RawInterval = 10
+	}
+	if c.Metrics.RawRetention == 0 {
+		c.Metrics.RawRetention = 24
+	}
+	if c.Metrics.MinuteRetention == 0 {
+		c.Metrics.MinuteRetention = 7
+	}
+	if c.Metrics.HourRetention == 0 {
+		c.Metrics.HourRetention = 90
+	}
+	if c.Metrics.DayRetention == 0 {
+		c.Metrics.DayRetention = 730
+	}
+	if c.AgentMTLS.CertValidity == 0 {
+		c.AgentMTLS.CertValidity = 365
+	}
 	if c.JWTSecretKey == "" {
 		c.JWTSecretKey, err = utils.GenerateRandomString(1024)
 		if err != nil {
@@ -163,6 +230,16 @@ func (c *Config) Read(path string, frontendTemplates []FrontendTemplate) error {
 		}
 	}
 
+	if c.Prometheus.Enable && c.Prometheus.ScrapeToken == "" {
+		c.Prometheus.ScrapeToken, err = utils.GenerateRandomString(32)
+		if err != nil {
+			return err
+		}
+		if err = c.Save(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
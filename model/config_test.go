@@ -70,6 +70,21 @@ func TestReadConfig(t *testing.T) {
 		os.Remove(file)
 	})
 
+	t.Run("AgentMTLSRequiresHTTPS", func(t *testing.T) {
+		file := newTempConfig(t, "agent_mtls:\n  enable: true")
+		defer os.Remove(file)
+
+		if err := (&Config{}).Read(file, nil); err == nil {
+			t.Fatal("expected agent_mtls without https.listen_port to be refused")
+		}
+
+		file2 := newTempConfig(t, "agent_mtls:\n  enable: true\nhttps:\n  listen_port: 8443")
+		defer os.Remove(file2)
+		if err := (&Config{}).Read(file2, nil); err != nil {
+			t.Fatalf("read config failed: %v", err)
+		}
+	})
+
 	t.Run("ReadEnv", func(t *testing.T) {
 		os.Setenv("NZ_JWTSECRETKEY", "test")
 		os.Setenv("NZ_USERTEMPLATE", "um")
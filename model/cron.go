@@ -29,8 +29,13 @@ type Cron struct {
 	LastResult          bool      `json:"last_result,omitempty"`      // 最后一次执行结果
 	Cover               uint8     `json:"cover"`                      // 计划任务覆盖范围 (0:仅覆盖特定服务器 1:仅忽略特定服务器 2:由触发该计划任务的服务器执行)
 
-	CronJobID  cron.EntryID `gorm:"-" json:"cron_job_id,omitempty"`
-	ServersRaw string       `json:"-"`
+	ServerGroups        []uint64 `gorm:"-" json:"server_groups"`         // 执行任务的服务器分组，指定后忽略 Cover 与 Servers
+	ExcludeServerGroups []uint64 `gorm:"-" json:"exclude_server_groups"` // 排除的服务器分组
+
+	CronJobID              cron.EntryID `gorm:"-" json:"cron_job_id,omitempty"`
+	ServersRaw             string       `json:"-"`
+	ServerGroupsRaw        string       `gorm:"default:'[]'" json:"-"`
+	ExcludeServerGroupsRaw string       `gorm:"default:'[]'" json:"-"`
 }
 
 func (c *Cron) BeforeSave(tx *gorm.DB) error {
@@ -39,9 +44,25 @@ func (c *Cron) BeforeSave(tx *gorm.DB) error {
 	} else {
 		c.ServersRaw = string(data)
 	}
+	if data, err := json.Marshal(c.ServerGroups); err != nil {
+		return err
+	} else {
+		c.ServerGroupsRaw = string(data)
+	}
+	if data, err := json.Marshal(c.ExcludeServerGroups); err != nil {
+		return err
+	} else {
+		c.ExcludeServerGroupsRaw = string(data)
+	}
 	return nil
 }
 
 func (c *Cron) AfterFind(tx *gorm.DB) error {
-	return json.Unmarshal([]byte(c.ServersRaw), &c.Servers)
+	if err := json.Unmarshal([]byte(c.ServersRaw), &c.Servers); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(c.ServerGroupsRaw), &c.ServerGroups); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(c.ExcludeServerGroupsRaw), &c.ExcludeServerGroups)
 }
@@ -9,4 +9,6 @@ type CronForm struct {
 	Cover               uint8    `json:"cover,omitempty" default:"0"`
 	PushSuccessful      bool     `json:"push_successful,omitempty" validate:"optional"`
 	NotificationGroupID uint64   `json:"notification_group_id,omitempty"`
+	ServerGroups        []uint64 `json:"server_groups,omitempty" validate:"optional"`
+	ExcludeServerGroups []uint64 `json:"exclude_server_groups,omitempty" validate:"optional"`
 }
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+const (
+	IncidentSourceAlertRule uint8 = iota
+	IncidentSourceService
+)
+
+// Incident 报警规则或服务监控的一次故障记录，检查失败时创建，恢复时关闭
+type Incident struct {
+	Common
+	Source         uint8      `gorm:"index" json:"source"`    // 0: 报警规则 1: 服务监控
+	RuleID         uint64     `gorm:"index" json:"rule_id"`   // 报警规则 ID 或服务监控 ID
+	ServerID       uint64     `gorm:"index" json:"server_id"` // 服务监控的故障不区分服务器，为 0
+	Name           string     `json:"name"`
+	MuteLabel      string     `gorm:"index" json:"-"`
+	StartedAt      time.Time  `gorm:"index" json:"started_at"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy uint64     `json:"acknowledged_by,omitempty"`
+}
+
+func (i *Incident) Resolved() bool {
+	return i.ResolvedAt != nil
+}
+
+func (i *Incident) Acknowledged() bool {
+	return i.AcknowledgedAt != nil
+}
+
+type IncidentComment struct {
+	Common
+	IncidentID uint64 `gorm:"index" json:"incident_id"`
+	Username   string `json:"username"`
+	Content    string `json:"content"`
+}
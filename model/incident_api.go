@@ -0,0 +1,10 @@
+package model
+
+type IncidentCommentForm struct {
+	Content string `json:"content" minLength:"1"`
+}
+
+type IncidentDetail struct {
+	Incident *Incident         `json:"incident"`
+	Comments []IncidentComment `json:"comments"`
+}
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// 与计划任务一致，支持秒级字段与 @every 等描述符
+var maintenanceParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+type Maintenance struct {
+	Common
+	Name      string     `json:"name"`
+	Scheduler string     `json:"scheduler,omitempty"` // 周期性维护的 cron 表达式，为空时为一次性维护
+	Duration  uint64     `json:"duration,omitempty"`  // 周期性维护每次持续的时间（秒）
+	StartAt   time.Time  `json:"start_at"`            // 一次性维护的开始时间，周期性维护的生效时间
+	EndAt     time.Time  `json:"end_at"`              // 一次性维护的结束时间，周期性维护的失效时间（为空时永久生效）
+	ClosedAt  *time.Time `json:"closed_at,omitempty"` // 提前结束当前维护窗口的时间
+
+	ServersRaw      string   `gorm:"default:'[]'" json:"-"`
+	ServerGroupsRaw string   `gorm:"default:'[]'" json:"-"`
+	ServicesRaw     string   `gorm:"default:'[]'" json:"-"`
+	Servers         []uint64 `gorm:"-" json:"servers"`
+	ServerGroups    []uint64 `gorm:"-" json:"server_groups"`
+	Services        []uint64 `gorm:"-" json:"services"`
+
+	schedule cron.Schedule
+}
+
+func (m *Maintenance) BeforeSave(tx *gorm.DB) error {
+	if data, err := json.Marshal(m.Servers); err != nil {
+		return err
+	} else {
+		m.ServersRaw = string(data)
+	}
+	if data, err := json.Marshal(m.ServerGroups); err != nil {
+		return err
+	} else {
+		m.ServerGroupsRaw = string(data)
+	}
+	if data, err := json.Marshal(m.Services); err != nil {
+		return err
+	} else {
+		m.ServicesRaw = string(data)
+	}
+	return nil
+}
+
+func (m *Maintenance) AfterFind(tx *gorm.DB) error {
+	if err := json.Unmarshal([]byte(m.ServersRaw), &m.Servers); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(m.ServerGroupsRaw), &m.ServerGroups); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(m.ServicesRaw), &m.Services); err != nil {
+		return err
+	}
+	if m.IsRecurring() {
+		// 表达式在保存前已校验，这里忽略错误
+		m.ParseScheduler()
+	}
+	return nil
+}
+
+func (m *Maintenance) IsRecurring() bool {
+	return m.Scheduler != ""
+}
+
+// ParseScheduler 解析周期性维护的 cron 表达式并缓存
+func (m *Maintenance) ParseScheduler() error {
+	schedule, err := maintenanceParser.Parse(m.Scheduler)
+	if err != nil {
+		return err
+	}
+	m.schedule = schedule
+	return nil
+}
+
+// Window 返回 now 所在的维护窗口，不在维护窗口内时返回 false
+func (m *Maintenance) Window(now time.Time) (start, end time.Time, ok bool) {
+	if !m.IsRecurring() {
+		start, end = m.StartAt, m.EndAt
+	} else {
+		if m.schedule == nil || m.Duration == 0 {
+			return
+		}
+		if !m.EndAt.IsZero() && !now.Before(m.EndAt) {
+			return
+		}
+		duration := time.Duration(m.Duration) * time.Second
+		start = m.lastStart(now, duration)
+		if start.IsZero() || start.Before(m.StartAt) {
+			return time.Time{}, time.Time{}, false
+		}
+		end = start.Add(duration)
+	}
+
+	if now.Before(start) || !now.Before(end) {
+		return time.Time{}, time.Time{}, false
+	}
+	// 当前窗口已被提前结束
+	if m.ClosedAt != nil && !m.ClosedAt.Before(start) && !now.Before(*m.ClosedAt) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// lastStart 返回 (now - duration, now] 内最后一次触发的时间，没有触发时返回零值
+func (m *Maintenance) lastStart(now time.Time, duration time.Duration) time.Time {
+	// @every 以生效时间为起点计算
+	if every, ok := m.schedule.(cron.ConstantDelaySchedule); ok {
+		if every.Delay <= 0 || now.Before(m.StartAt) {
+			return time.Time{}
+		}
+		start := m.StartAt.Add(now.Sub(m.StartAt) / every.Delay * every.Delay)
+		if !start.After(now.Add(-duration)) {
+			return time.Time{}
+		}
+		return start
+	}
+
+	// Next 单调不减，按秒二分查找最后一个满足 Next(t) <= now 的 t
+	lo := now.Add(-duration - time.Second)
+	if next := m.schedule.Next(lo); next.IsZero() || next.After(now) {
+		return time.Time{}
+	}
+	steps := int64(now.Sub(lo) / time.Second)
+	for steps > 1 {
+		mid := lo.Add(time.Duration(steps/2) * time.Second)
+		if next := m.schedule.Next(mid); !next.IsZero() && !next.After(now) {
+			lo = mid
+			steps -= steps / 2
+		} else {
+			steps = steps / 2
+		}
+	}
+	return m.schedule.Next(lo)
+}
+
+// Active 判断 now 是否处于维护窗口内
+func (m *Maintenance) Active(now time.Time) bool {
+	_, _, ok := m.Window(now)
+	return ok
+}
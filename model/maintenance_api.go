@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type MaintenanceForm struct {
+	Name         string    `json:"name" minLength:"1"`
+	Scheduler    string    `json:"scheduler,omitempty" validate:"optional"` // 周期性维护的 cron 表达式，为空时为一次性维护
+	Duration     uint64    `json:"duration,omitempty" validate:"optional"`  // 周期性维护每次持续的时间（秒）
+	StartAt      time.Time `json:"start_at"`
+	EndAt        time.Time `json:"end_at" validate:"optional"`
+	Servers      []uint64  `json:"servers,omitempty" validate:"optional"`
+	ServerGroups []uint64  `json:"server_groups,omitempty" validate:"optional"`
+	Services     []uint64  `json:"services,omitempty" validate:"optional"`
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindow(t *testing.T) {
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	oneOff := &Maintenance{StartAt: base, EndAt: base.Add(time.Hour)}
+	assertEq(t, "one-off before start", false, oneOff.Active(base.Add(-time.Second)))
+	assertEq(t, "one-off inside", true, oneOff.Active(base.Add(30*time.Minute)))
+	assertEq(t, "one-off at end", false, oneOff.Active(base.Add(time.Hour)))
+
+	closedAt := base.Add(10 * time.Minute)
+	oneOff.ClosedAt = &closedAt
+	assertEq(t, "one-off closed early", false, oneOff.Active(base.Add(30*time.Minute)))
+	assertEq(t, "one-off before close", true, oneOff.Active(base.Add(5*time.Minute)))
+
+	// 每天 02:00 开始，持续 30 分钟
+	recurring := &Maintenance{Scheduler: "0 0 2 * * *", Duration: 1800, StartAt: base}
+	if err := recurring.ParseScheduler(); err != nil {
+		t.Fatal(err)
+	}
+	assertEq(t, "recurring before window", false, recurring.Active(base.Add(time.Hour)))
+	assertEq(t, "recurring inside window", true, recurring.Active(base.Add(2*time.Hour+10*time.Minute)))
+	assertEq(t, "recurring next day", true, recurring.Active(base.AddDate(0, 0, 3).Add(2*time.Hour+29*time.Minute)))
+	assertEq(t, "recurring after window", false, recurring.Active(base.Add(2*time.Hour+30*time.Minute)))
+
+	start, end, ok := recurring.Window(base.Add(2*time.Hour + 10*time.Minute))
+	assertEq(t, "recurring window found", true, ok)
+	assertEq(t, "recurring window start", base.Add(2*time.Hour), start)
+	assertEq(t, "recurring window end", base.Add(2*time.Hour+30*time.Minute), end)
+
+	// 提前结束只影响当前窗口
+	closedAt = base.Add(2*time.Hour + 5*time.Minute)
+	recurring.ClosedAt = &closedAt
+	assertEq(t, "recurring closed early", false, recurring.Active(base.Add(2*time.Hour+10*time.Minute)))
+	assertEq(t, "recurring next window after close", true, recurring.Active(base.AddDate(0, 0, 1).Add(2*time.Hour+10*time.Minute)))
+
+	recurring.EndAt = base.AddDate(0, 0, 1)
+	assertEq(t, "recurring expired", false, recurring.Active(base.AddDate(0, 0, 1).Add(2*time.Hour+10*time.Minute)))
+
+	// 高频触发与较长的持续时间，窗口从最近一次触发开始
+	frequent := &Maintenance{Scheduler: "0 * * * * *", Duration: 7 * 86400, StartAt: base}
+	if err := frequent.ParseScheduler(); err != nil {
+		t.Fatal(err)
+	}
+	start, _, ok = frequent.Window(base.Add(3*time.Hour + 90*time.Second))
+	assertEq(t, "frequent window found", true, ok)
+	assertEq(t, "frequent window start", base.Add(3*time.Hour+time.Minute), start)
+
+	// @every 以生效时间为起点
+	every := &Maintenance{Scheduler: "@every 1h", Duration: 600, StartAt: base.Add(15 * time.Minute)}
+	if err := every.ParseScheduler(); err != nil {
+		t.Fatal(err)
+	}
+	start, _, ok = every.Window(base.Add(3*time.Hour + 20*time.Minute))
+	assertEq(t, "every window found", true, ok)
+	assertEq(t, "every window start", base.Add(3*time.Hour+15*time.Minute), start)
+	assertEq(t, "every outside window", false, every.Active(base.Add(3*time.Hour+30*time.Minute)))
+	assertEq(t, "every before start", false, every.Active(base.Add(10*time.Minute)))
+}
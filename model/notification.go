@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -10,9 +11,29 @@ import (
 	"time"
 
 	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+
 	"github.com/nezhahq/nezha/pkg/utils"
 )
 
+const (
+	NotificationTypeTemplate = "template"
+	NotificationTypeEmail    = "email"
+	NotificationTypeTelegram = "telegram"
+	NotificationTypeSlack    = "slack"
+	NotificationTypeDiscord  = "discord"
+	NotificationTypeMatrix   = "matrix"
+	NotificationTypeNtfy     = "ntfy"
+	NotificationTypeGotify   = "gotify"
+	NotificationTypeWebhook  = "webhook"
+)
+
+var NotificationTypeList = [...]string{
+	NotificationTypeTemplate, NotificationTypeEmail, NotificationTypeTelegram,
+	NotificationTypeSlack, NotificationTypeDiscord, NotificationTypeMatrix,
+	NotificationTypeNtfy, NotificationTypeGotify, NotificationTypeWebhook,
+}
+
 const (
 	_ = iota
 	NotificationRequestTypeJSON
@@ -34,12 +55,41 @@ type NotificationServerBundle struct {
 type Notification struct {
 	Common
 	Name          string `json:"name"`
+	Type          string `json:"type" gorm:"default:'template'"`
+	Config        string `json:"config,omitempty" gorm:"type:longtext"` // 非 template 类型的通知方式配置，JSON 格式
 	URL           string `json:"url"`
 	RequestMethod uint8  `json:"request_method"`
 	RequestType   uint8  `json:"request_type"`
 	RequestHeader string `json:"request_header" gorm:"type:longtext"`
 	RequestBody   string `json:"request_body" gorm:"type:longtext"`
 	VerifyTLS     *bool  `json:"verify_tls,omitempty"`
+
+	TemplatesRaw string            `gorm:"default:'{}'" json:"-"`
+	Templates    map[string]string `gorm:"-" json:"templates,omitempty"` // 按事件来源设置的消息模板，default 用于其他来源
+}
+
+func (n *Notification) BeforeSave(tx *gorm.DB) error {
+	if data, err := json.Marshal(n.Templates); err != nil {
+		return err
+	} else {
+		n.TemplatesRaw = string(data)
+	}
+	return nil
+}
+
+func (n *Notification) AfterFind(tx *gorm.DB) error {
+	if n.TemplatesRaw == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(n.TemplatesRaw), &n.Templates)
+}
+
+// Template 返回事件来源对应的消息模板，未设置时返回空字符串
+func (n *Notification) Template(source string) string {
+	if t, ok := n.Templates[source]; ok {
+		return t
+	}
+	return n.Templates[NotificationTemplateDefault]
 }
 
 func (ns *NotificationServerBundle) reqURL(message string) string {
@@ -109,7 +159,13 @@ func (n *Notification) setRequestHeader(req *http.Request) error {
 	return nil
 }
 
-func (ns *NotificationServerBundle) Send(message string) error {
+func (ns *NotificationServerBundle) Send(ctx context.Context, message string) error {
+	_, _, err := ns.SendWithResponse(ctx, message)
+	return err
+}
+
+// SendWithResponse 发送通知并返回 HTTP 状态码与响应内容，请求未发出时状态码为 0
+func (ns *NotificationServerBundle) SendWithResponse(ctx context.Context, message string) (int, string, error) {
 	var client *http.Client
 	n := ns.Notification
 	if n.VerifyTLS != nil && *n.VerifyTLS {
@@ -120,41 +176,39 @@ func (ns *NotificationServerBundle) Send(message string) error {
 
 	reqBody, err := ns.reqBody(message)
 	if err != nil {
-		return err
+		return 0, "", err
 	}
 
 	reqMethod, err := n.reqMethod()
 	if err != nil {
-		return err
+		return 0, "", err
 	}
 
-	req, err := http.NewRequest(reqMethod, ns.reqURL(message), strings.NewReader(reqBody))
+	req, err := http.NewRequestWithContext(ctx, reqMethod, ns.reqURL(message), strings.NewReader(reqBody))
 	if err != nil {
-		return err
+		return 0, "", err
 	}
 
 	n.setContentType(req)
 
 	if err := n.setRequestHeader(req); err != nil {
-		return err
+		return 0, "", err
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return 0, "", err
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	_, _ = io.Copy(io.Discard, resp.Body)
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("%d@%s %s", resp.StatusCode, resp.Status, string(body))
-	} else {
-		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, string(body), fmt.Errorf("%d@%s %s", resp.StatusCode, resp.Status, string(body))
 	}
-
-	return nil
+	return resp.StatusCode, string(body), nil
 }
 
 // replaceParamInString 替换字符串中的占位符
@@ -2,6 +2,8 @@ package model
 
 type NotificationForm struct {
 	Name          string `json:"name,omitempty" minLength:"1"`
+	Type          string `json:"type,omitempty" validate:"optional"`
+	Config        string `json:"config,omitempty" validate:"optional"`
 	URL           string `json:"url,omitempty"`
 	RequestMethod uint8  `json:"request_method,omitempty"`
 	RequestType   uint8  `json:"request_type,omitempty"`
@@ -9,4 +11,26 @@ type NotificationForm struct {
 	RequestBody   string `json:"request_body,omitempty"`
 	VerifyTLS     bool   `json:"verify_tls,omitempty" validate:"optional"`
 	SkipCheck     bool   `json:"skip_check,omitempty" validate:"optional"`
+
+	Templates map[string]string `json:"templates,omitempty" validate:"optional"`
+}
+
+type NotificationTestForm struct {
+	Source   string `json:"source,omitempty" validate:"optional"`    // 示例事件的来源，默认为 alert
+	ServerID uint64 `json:"server_id,omitempty" validate:"optional"` // 使用该服务器的当前状态替换 #SERVER.*# 占位符，为 0 时使用示例数据
+}
+
+type NotificationTestResult struct {
+	NotificationID uint64 `json:"notification_id"`
+	Name           string `json:"name"`
+	Success        bool   `json:"success"`
+	StatusCode     int    `json:"status_code,omitempty"`
+	ResponseBody   string `json:"response_body,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+type NotificationRenderForm struct {
+	Template string `json:"template,omitempty"`
+	Source   string `json:"source,omitempty" validate:"optional"`    // 事件来源，默认为 alert
+	ServerID uint64 `json:"server_id,omitempty" validate:"optional"` // 使用该服务器的当前状态渲染，为 0 时使用示例数据
 }
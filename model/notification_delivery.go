@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	NotificationDeliveryPending uint8 = iota
+	NotificationDeliverySent
+	NotificationDeliveryFailed // 重试次数用尽，已转入死信表
+)
+
+// NotificationDelivery 通知发送队列，每个通知方式单独重试
+type NotificationDelivery struct {
+	Common
+	NotificationID uint64     `gorm:"index" json:"notification_id"`
+	Message        string     `gorm:"type:longtext" json:"message"`
+	ServerRaw      string     `gorm:"type:longtext" json:"-"` // 触发通知时服务器状态的快照，用于替换模板中的占位符
+	Status         uint8      `gorm:"index" json:"status"`
+	Attempts       uint32     `json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"index" json:"next_attempt_at"`
+	LastError      string     `json:"last_error,omitempty"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+}
+
+func (d *NotificationDelivery) SetServer(s *Server) error {
+	if s == nil {
+		d.ServerRaw = ""
+		return nil
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	d.ServerRaw = string(data)
+	return nil
+}
+
+func (d *NotificationDelivery) GetServer() (*Server, error) {
+	if d.ServerRaw == "" {
+		return nil, nil
+	}
+	var s Server
+	if err := json.Unmarshal([]byte(d.ServerRaw), &s); err != nil {
+		return nil, err
+	}
+	if s.Host == nil {
+		s.Host = &Host{}
+	}
+	if s.State == nil {
+		s.State = &HostState{}
+	}
+	if s.GeoIP == nil {
+		s.GeoIP = &GeoIP{}
+	}
+	return &s, nil
+}
+
+// NotificationDeadLetter 重试次数用尽的通知
+type NotificationDeadLetter struct {
+	Common
+	DeliveryID     uint64 `gorm:"uniqueIndex" json:"delivery_id"`
+	NotificationID uint64 `gorm:"index" json:"notification_id"`
+	Message        string `gorm:"type:longtext" json:"message"`
+	Attempts       uint32 `json:"attempts"`
+	LastError      string `json:"last_error,omitempty"`
+}
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	NotificationSourceAlert    = "alert"
+	NotificationSourceService  = "service"
+	NotificationSourceTLS      = "tls"
+	NotificationSourceCron     = "cron"
+	NotificationSourceIPChange = "ip_change"
+)
+
+var NotificationSourceList = [...]string{
+	NotificationSourceAlert, NotificationSourceService, NotificationSourceTLS,
+	NotificationSourceCron, NotificationSourceIPChange,
+}
+
+const (
+	_ uint8 = iota
+	NotificationSeverityInfo
+	NotificationSeverityWarning
+	NotificationSeverityCritical
+)
+
+const (
+	NotificationStateFiring   = "firing"
+	NotificationStateResolved = "resolved"
+)
+
+// NotificationEvent 描述一次通知的来源，用于匹配通知组的路由规则与渲染通知模板
+type NotificationEvent struct {
+	Source   string
+	Rule     string // 报警规则、服务监控或计划任务的名称
+	Severity uint8
+	ServerID uint64 // 与服务器无关的通知为 0
+	// ReporterID 服务监控与 TLS 证书事件的监测点，ServerID 为 0 时按监测点匹配服务器分组，面板监测为 0
+	ReporterID uint64
+
+	// 以下字段只用于渲染通知模板，未涉及的事件为零值
+	State      string               // 报警规则为 firing、resolved，服务监控为服务状态
+	PrevState  string               // 上一次检查的状态
+	Duration   time.Duration        // 故障已持续的时间
+	Reporter   string               // 服务监控的监测点
+	Reporters  []string             // 服务监控中检查失败的监测点
+	Error      string               // 服务监控或计划任务返回的错误
+	Latency    float32              // 服务监控的延迟（毫秒）
+	Metrics    []NotificationMetric // 未通过检查的指标
+	CertIssuer string               // TLS 证书的颁发者
+	CertExpiry *time.Time           // TLS 证书的过期时间
+}
+
+// NotificationMetric 触发通知的指标与阈值
+type NotificationMetric struct {
+	Type       string  `json:"type"`
+	Value      float64 `json:"value"`
+	Min        float64 `json:"min,omitempty"`
+	Max        float64 `json:"max,omitempty"`
+	Expression string  `json:"expression,omitempty"`
+}
+
+// NotificationRoute 通知组的路由规则，匹配的通知只发送给规则指定的通知方式，
+// 未出现在任何规则中的通知方式接收该组的所有通知
+type NotificationRoute struct {
+	Sources       []string `json:"sources,omitempty"`       // 为空时匹配所有来源
+	Severities    []uint8  `json:"severities,omitempty"`    // 为空时匹配所有级别
+	ServerGroups  []uint64 `json:"server_groups,omitempty"` // 为空时不限制服务器分组，服务监控按监测点所在分组匹配
+	Weekdays      []int    `json:"weekdays,omitempty"`      // 0 为周日，为空时不限制
+	StartTime     string   `json:"start_time,omitempty"`    // HH:MM，结束时间早于开始时间时跨越午夜
+	EndTime       string   `json:"end_time,omitempty"`      // HH:MM
+	Notifications []uint64 `json:"notifications"`
+}
+
+// Validate 校验路由规则的取值
+func (r *NotificationRoute) Validate() error {
+	for _, s := range r.Sources {
+		if !slices.Contains(NotificationSourceList[:], s) {
+			return fmt.Errorf("unknown source %s", s)
+		}
+	}
+	for _, s := range r.Severities {
+		if s < NotificationSeverityInfo || s > NotificationSeverityCritical {
+			return fmt.Errorf("unknown severity %d", s)
+		}
+	}
+	for _, d := range r.Weekdays {
+		if d < 0 || d > 6 {
+			return fmt.Errorf("invalid weekday %d", d)
+		}
+	}
+	if (r.StartTime == "") != (r.EndTime == "") {
+		return fmt.Errorf("start_time and end_time must be set together")
+	}
+	if r.StartTime != "" {
+		if _, err := parseClock(r.StartTime); err != nil {
+			return err
+		}
+		if _, err := parseClock(r.EndTime); err != nil {
+			return err
+		}
+	}
+	if len(r.Notifications) == 0 {
+		return fmt.Errorf("need to specify at least one notification")
+	}
+	return nil
+}
+
+// Match 判断事件是否匹配路由规则，inServerGroups 判断事件的服务器是否属于给定分组
+func (r *NotificationRoute) Match(event NotificationEvent, now time.Time, inServerGroups func(serverID uint64, groups []uint64) bool) bool {
+	if len(r.Sources) > 0 && !slices.Contains(r.Sources, event.Source) {
+		return false
+	}
+	if len(r.Severities) > 0 && !slices.Contains(r.Severities, event.Severity) {
+		return false
+	}
+	if len(r.ServerGroups) > 0 {
+		serverID := event.ServerID
+		if serverID == 0 {
+			serverID = event.ReporterID
+		}
+		if serverID == 0 || !inServerGroups(serverID, r.ServerGroups) {
+			return false
+		}
+	}
+	if len(r.Weekdays) > 0 && !slices.Contains(r.Weekdays, int(now.Weekday())) {
+		return false
+	}
+	if r.StartTime != "" {
+		start, err := parseClock(r.StartTime)
+		if err != nil {
+			return false
+		}
+		end, err := parseClock(r.EndTime)
+		if err != nil {
+			return false
+		}
+		minute := now.Hour()*60 + now.Minute()
+		switch {
+		case start < end:
+			return minute >= start && minute < end
+		case start > end:
+			return minute >= start || minute < end
+		}
+	}
+	return true
+}
+
+// parseClock 将 HH:MM 解析为当天的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %s", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
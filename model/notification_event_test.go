@@ -0,0 +1,68 @@
+package model
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNotificationRouteMatch(t *testing.T) {
+	inGroups := func(serverID uint64, groups []uint64) bool {
+		return serverID == 1 && slices.Contains(groups, 10)
+	}
+	// 2024-01-01 为周一
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+	critical := NotificationEvent{Source: NotificationSourceAlert, Severity: NotificationSeverityCritical, ServerID: 1}
+
+	cases := []struct {
+		name  string
+		route NotificationRoute
+		event NotificationEvent
+		now   time.Time
+		want  bool
+	}{
+		{"empty route", NotificationRoute{}, critical, at(12, 0), true},
+		{"source mismatch", NotificationRoute{Sources: []string{NotificationSourceService}}, critical, at(12, 0), false},
+		{"severity match", NotificationRoute{Severities: []uint8{NotificationSeverityCritical}}, critical, at(12, 0), true},
+		{"severity mismatch", NotificationRoute{Severities: []uint8{NotificationSeverityInfo}}, critical, at(12, 0), false},
+		{"server group match", NotificationRoute{ServerGroups: []uint64{10}}, critical, at(12, 0), true},
+		{"server group without server", NotificationRoute{ServerGroups: []uint64{10}}, NotificationEvent{Source: NotificationSourceService}, at(12, 0), false},
+		{"server group by reporter", NotificationRoute{ServerGroups: []uint64{10}}, NotificationEvent{Source: NotificationSourceService, ReporterID: 1}, at(12, 0), true},
+		{"server group reporter mismatch", NotificationRoute{ServerGroups: []uint64{10}}, NotificationEvent{Source: NotificationSourceService, ReporterID: 2}, at(12, 0), false},
+		{"weekday mismatch", NotificationRoute{Weekdays: []int{0, 6}}, critical, at(12, 0), false},
+		{"office hours", NotificationRoute{Weekdays: []int{1, 2, 3, 4, 5}, StartTime: "09:00", EndTime: "18:00"}, critical, at(9, 0), true},
+		{"after office hours", NotificationRoute{StartTime: "09:00", EndTime: "18:00"}, critical, at(18, 0), false},
+		{"night before midnight", NotificationRoute{StartTime: "22:00", EndTime: "07:00"}, critical, at(23, 30), true},
+		{"night after midnight", NotificationRoute{StartTime: "22:00", EndTime: "07:00"}, critical, at(6, 59), true},
+		{"day time for night route", NotificationRoute{StartTime: "22:00", EndTime: "07:00"}, critical, at(12, 0), false},
+	}
+
+	for _, c := range cases {
+		if got := c.route.Match(c.event, c.now, inGroups); got != c.want {
+			t.Errorf("%s: Match() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestNotificationRouteValidate(t *testing.T) {
+	cases := []struct {
+		route   NotificationRoute
+		wantErr bool
+	}{
+		{NotificationRoute{Notifications: []uint64{1}}, false},
+		{NotificationRoute{}, true},
+		{NotificationRoute{Sources: []string{"sms"}, Notifications: []uint64{1}}, true},
+		{NotificationRoute{Severities: []uint8{4}, Notifications: []uint64{1}}, true},
+		{NotificationRoute{Weekdays: []int{7}, Notifications: []uint64{1}}, true},
+		{NotificationRoute{StartTime: "09:00", Notifications: []uint64{1}}, true},
+		{NotificationRoute{StartTime: "09:00", EndTime: "25:00", Notifications: []uint64{1}}, true},
+	}
+
+	for i, c := range cases {
+		if err := c.route.Validate(); (err != nil) != c.wantErr {
+			t.Errorf("case %d: Validate() error = %v, wantErr %v", i, err, c.wantErr)
+		}
+	}
+}
@@ -1,6 +1,45 @@
 package model
 
+import (
+	"fmt"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+)
+
+const NotificationDigestMaxWindow = 3600
+
 type NotificationGroup struct {
 	Common
-	Name string `json:"name"`
+	Name            string              `json:"name"`
+	DigestWindow    uint64              `json:"digest_window,omitempty"`     // 合并窗口（秒），窗口内的通知合并为一条摘要发送，为 0 时不合并
+	DailyDigestTime string              `json:"daily_digest_time,omitempty"` // 每日摘要的发送时间 HH:MM，为空时不发送
+	RoutesRaw       string              `gorm:"default:'[]'" json:"-"`
+	Routes          []NotificationRoute `gorm:"-" json:"routes"`
+}
+
+// ValidateDigest 校验摘要设置
+func (g *NotificationGroup) ValidateDigest() error {
+	if g.DigestWindow > NotificationDigestMaxWindow {
+		return fmt.Errorf("digest_window must not exceed %d seconds", NotificationDigestMaxWindow)
+	}
+	if g.DailyDigestTime != "" {
+		if _, err := parseClock(g.DailyDigestTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *NotificationGroup) BeforeSave(tx *gorm.DB) error {
+	if data, err := json.Marshal(g.Routes); err != nil {
+		return err
+	} else {
+		g.RoutesRaw = string(data)
+	}
+	return nil
+}
+
+func (g *NotificationGroup) AfterFind(tx *gorm.DB) error {
+	return json.Unmarshal([]byte(g.RoutesRaw), &g.Routes)
 }
@@ -3,6 +3,10 @@ package model
 type NotificationGroupForm struct {
 	Name          string   `json:"name" minLength:"1"`
 	Notifications []uint64 `json:"notifications"`
+
+	Routes          []NotificationRoute `json:"routes,omitempty" validate:"optional"`
+	DigestWindow    uint64              `json:"digest_window,omitempty" validate:"optional"`
+	DailyDigestTime string              `json:"daily_digest_time,omitempty" validate:"optional"`
 }
 
 type NotificationGroupResponseItem struct {
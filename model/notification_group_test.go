@@ -0,0 +1,22 @@
+package model
+
+import "testing"
+
+func TestNotificationGroupValidateDigest(t *testing.T) {
+	cases := []struct {
+		group   NotificationGroup
+		wantErr bool
+	}{
+		{NotificationGroup{}, false},
+		{NotificationGroup{DigestWindow: 300, DailyDigestTime: "09:00"}, false},
+		{NotificationGroup{DigestWindow: NotificationDigestMaxWindow + 1}, true},
+		{NotificationGroup{DailyDigestTime: "24:00"}, true},
+		{NotificationGroup{DailyDigestTime: "9am"}, true},
+	}
+
+	for _, c := range cases {
+		if err := c.group.ValidateDigest(); (err != nil) != c.wantErr {
+			t.Errorf("ValidateDigest(%+v) error = %v, wantErr %v", c.group, err, c.wantErr)
+		}
+	}
+}
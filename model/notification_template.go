@@ -0,0 +1,284 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// NotificationTemplateDefault 未单独设置来源的事件使用的模板
+const NotificationTemplateDefault = "default"
+
+const (
+	notificationTemplateMaxSize   = 64 * 1024
+	notificationTemplateMaxOutput = 64 * 1024 // 渲染结果的上限，避免模板中的循环无限输出
+)
+
+var errNotificationTemplateOutput = fmt.Errorf("rendered template is larger than %d bytes", notificationTemplateMaxOutput)
+
+// NotificationTemplateData 渲染通知模板时可以使用的数据
+type NotificationTemplateData struct {
+	NotificationEvent
+	Message      string                      // 默认格式的通知内容
+	SeverityName string                      // info、warning、critical
+	Server       *NotificationTemplateServer // 与服务器无关的通知为 nil
+	Time         time.Time                   // 触发通知的时间
+}
+
+// NotificationTemplateServer 模板中可以使用的服务器信息，只包含数据的副本
+type NotificationTemplateServer struct {
+	ID         uint64
+	Name       string
+	PublicNote string
+	LastActive time.Time
+
+	Platform        string
+	PlatformVersion string
+	Arch            string
+	Virtualization  string
+	Version         string // Agent 版本
+
+	CPU            float64
+	MemUsed        uint64
+	MemTotal       uint64
+	SwapUsed       uint64
+	SwapTotal      uint64
+	DiskUsed       uint64
+	DiskTotal      uint64
+	NetInSpeed     uint64
+	NetOutSpeed    uint64
+	NetInTransfer  uint64
+	NetOutTransfer uint64
+	Load1          float64
+	Load5          float64
+	Load15         float64
+	TcpConnCount   uint64
+	UdpConnCount   uint64
+	ProcessCount   uint64
+	Uptime         uint64
+}
+
+// NewNotificationTemplateData 组装模板数据
+func NewNotificationTemplateData(event NotificationEvent, message string, server *Server, now time.Time) *NotificationTemplateData {
+	return &NotificationTemplateData{
+		NotificationEvent: event,
+		Message:           message,
+		SeverityName:      NotificationSeverityName(event.Severity),
+		Server:            newNotificationTemplateServer(server),
+		Time:              now,
+	}
+}
+
+func newNotificationTemplateServer(server *Server) *NotificationTemplateServer {
+	if server == nil {
+		return nil
+	}
+	s := &NotificationTemplateServer{
+		ID:         server.ID,
+		Name:       server.Name,
+		PublicNote: server.PublicNote,
+		LastActive: server.LastActive,
+	}
+	if h := server.Host; h != nil {
+		s.Platform = h.Platform
+		s.PlatformVersion = h.PlatformVersion
+		s.Arch = h.Arch
+		s.Virtualization = h.Virtualization
+		s.Version = h.Version
+		s.MemTotal = h.MemTotal
+		s.SwapTotal = h.SwapTotal
+		s.DiskTotal = h.DiskTotal
+	}
+	if st := server.State; st != nil {
+		s.CPU = st.CPU
+		s.MemUsed = st.MemUsed
+		s.SwapUsed = st.SwapUsed
+		s.DiskUsed = st.DiskUsed
+		s.NetInSpeed = st.NetInSpeed
+		s.NetOutSpeed = st.NetOutSpeed
+		s.NetInTransfer = st.NetInTransfer
+		s.NetOutTransfer = st.NetOutTransfer
+		s.Load1 = st.Load1
+		s.Load5 = st.Load5
+		s.Load15 = st.Load15
+		s.TcpConnCount = st.TcpConnCount
+		s.UdpConnCount = st.UdpConnCount
+		s.ProcessCount = st.ProcessCount
+		s.Uptime = st.Uptime
+	}
+	return s
+}
+
+// NotificationSeverityName 通知级别的名称
+func NotificationSeverityName(severity uint8) string {
+	switch severity {
+	case NotificationSeverityInfo:
+		return "info"
+	case NotificationSeverityWarning:
+		return "warning"
+	case NotificationSeverityCritical:
+		return "critical"
+	}
+	return ""
+}
+
+// ValidateNotificationTemplates 校验通知方式的消息模板
+func ValidateNotificationTemplates(templates map[string]string) error {
+	for source, text := range templates {
+		if source != NotificationTemplateDefault && !slices.Contains(NotificationSourceList[:], source) {
+			return fmt.Errorf("unknown template source %s", source)
+		}
+		if _, err := ParseNotificationTemplate(text, time.UTC); err != nil {
+			return fmt.Errorf("template %s: %w", source, err)
+		}
+	}
+	return nil
+}
+
+// ParseNotificationTemplate 解析通知模板，时间相关的函数按 loc 输出
+func ParseNotificationTemplate(text string, loc *time.Location) (*template.Template, error) {
+	if len(text) > notificationTemplateMaxSize {
+		return nil, fmt.Errorf("template is larger than %d bytes", notificationTemplateMaxSize)
+	}
+	return template.New("notification").Option("missingkey=zero").Funcs(notificationTemplateFuncs(loc)).Parse(text)
+}
+
+// RenderNotificationTemplate 渲染通知模板
+func RenderNotificationTemplate(text string, data *NotificationTemplateData, loc *time.Location) (string, error) {
+	tmpl, err := ParseNotificationTemplate(text, loc)
+	if err != nil {
+		return "", err
+	}
+	w := &limitedWriter{limit: notificationTemplateMaxOutput}
+	if err := tmpl.Execute(w, data); err != nil {
+		return "", err
+	}
+	return w.sb.String(), nil
+}
+
+// limitedWriter 超出上限后写入失败，模板渲染随之中止
+type limitedWriter struct {
+	sb    strings.Builder
+	limit int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.sb.Len()+len(p) > w.limit {
+		return 0, errNotificationTemplateOutput
+	}
+	return w.sb.Write(p)
+}
+
+func notificationTemplateFuncs(loc *time.Location) template.FuncMap {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return template.FuncMap{
+		"bytes":    humanizeBytes,
+		"duration": humanizeDuration,
+		"percent": func(v any) string {
+			return fmt.Sprintf("%.2f%%", toFloat(v))
+		},
+		"round": func(v any, precision int) float64 {
+			p := math.Pow10(precision)
+			return math.Round(toFloat(v)*p) / p
+		},
+		"datetime": func(t any) string {
+			return formatTemplateTime(t, time.DateTime, loc)
+		},
+		"timeFormat": func(layout string, t any) string {
+			return formatTemplateTime(t, layout, loc)
+		},
+		"timeIn": func(name string, t any) (string, error) {
+			l, err := time.LoadLocation(name)
+			if err != nil {
+				return "", err
+			}
+			return formatTemplateTime(t, time.DateTime, l), nil
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"join":  strings.Join,
+		"default": func(def, v any) any {
+			if v == nil || fmt.Sprint(v) == "" {
+				return def
+			}
+			return v
+		},
+	}
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case uint8:
+		return float64(n)
+	case uint32:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	case float64:
+		return n
+	case time.Duration:
+		return n.Seconds()
+	}
+	return 0
+}
+
+// humanizeBytes 以 1024 为进制格式化字节数
+func humanizeBytes(v any) string {
+	b := toFloat(v)
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	i := 0
+	for math.Abs(b) >= 1024 && i < len(units)-1 {
+		b /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", b, units[i])
+	}
+	return fmt.Sprintf("%.2f %s", b, units[i])
+}
+
+// humanizeDuration 格式化时长，数字按秒处理
+func humanizeDuration(v any) string {
+	d, ok := v.(time.Duration)
+	if !ok {
+		d = time.Duration(toFloat(v) * float64(time.Second))
+	}
+	d = d.Round(time.Second)
+	if d < 0 {
+		d = -d
+	}
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	if days > 0 {
+		return fmt.Sprintf("%dd%s", days, d)
+	}
+	return d.String()
+}
+
+func formatTemplateTime(v any, layout string, loc *time.Location) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.In(loc).Format(layout)
+	case *time.Time:
+		if t == nil {
+			return ""
+		}
+		return t.In(loc).Format(layout)
+	}
+	return ""
+}
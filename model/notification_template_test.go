@@ -0,0 +1,87 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRenderNotificationTemplate(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiry := now.Add(36 * time.Hour)
+	server := &Server{
+		Name:  "web-1",
+		Host:  &Host{MemTotal: 8 << 30},
+		State: &HostState{CPU: 97.456, MemUsed: 3 << 29},
+	}
+	event := NotificationEvent{
+		Source:     NotificationSourceAlert,
+		Rule:       "cpu",
+		Severity:   NotificationSeverityCritical,
+		State:      NotificationStateFiring,
+		Duration:   26*time.Hour + 90*time.Second,
+		Metrics:    []NotificationMetric{{Type: "cpu", Value: 97.456, Max: 90}},
+		CertExpiry: &expiry,
+	}
+	data := NewNotificationTemplateData(event, "default message", server, now)
+
+	cases := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{"message", "{{.Message}}", "default message"},
+		{"event", "{{upper .SeverityName}} {{.Rule}} {{.State}} on {{.Server.Name}}", "CRITICAL cpu firing on web-1"},
+		{"bytes", "{{bytes .Server.MemUsed}} / {{bytes .Server.MemTotal}}", "1.50 GiB / 8.00 GiB"},
+		{"metrics", "{{range .Metrics}}{{.Type}}={{round .Value 1}}>{{.Max}}{{end}}", "cpu=97.5>90"},
+		{"percent", "{{percent .Server.CPU}}", "97.46%"},
+		{"duration", "{{duration .Duration}}", "1d2h1m30s"},
+		{"time", "{{datetime .Time}} {{timeFormat \"2006-01-02\" .CertExpiry}}", "2024-01-01 08:00:00 2024-01-02"},
+		{"default", "{{default \"none\" .Reporter}}", "none"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := RenderNotificationTemplate(c.tmpl, data, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("RenderNotificationTemplate() = %q, want %q", got, c.want)
+			}
+		})
+	}
+
+	// 模板只能访问服务器数据的副本
+	if _, err := RenderNotificationTemplate("{{.Server.TaskStream}}", data, loc); err == nil {
+		t.Error("expected internal server fields to be unavailable")
+	}
+
+	// 渲染结果超出上限时中止，不会无限输出
+	start := time.Now()
+	if _, err := RenderNotificationTemplate("{{range 2000000000}}{{.Message}}{{end}}", data, loc); err == nil {
+		t.Error("expected runaway template output to be rejected")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("runaway template took %v", elapsed)
+	}
+}
+
+func TestValidateNotificationTemplates(t *testing.T) {
+	cases := []struct {
+		templates map[string]string
+		wantErr   bool
+	}{
+		{nil, false},
+		{map[string]string{NotificationTemplateDefault: "{{.Message}}", NotificationSourceService: "{{.Reporter}}"}, false},
+		{map[string]string{"unknown": "{{.Message}}"}, true},
+		{map[string]string{NotificationSourceAlert: "{{.Message"}, true},
+		{map[string]string{NotificationSourceAlert: "{{nofunc .Message}}"}, true},
+	}
+
+	for _, c := range cases {
+		if err := ValidateNotificationTemplates(c.templates); (err != nil) != c.wantErr {
+			t.Errorf("ValidateNotificationTemplates(%v) error = %v, wantErr %v", c.templates, err, c.wantErr)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package model
 
 import (
+	"context"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -210,3 +212,37 @@ func TestNotification(t *testing.T) {
 		execCase(t, c)
 	}
 }
+
+func TestNotificationSendWithResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(time.Second)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(strings.Repeat("x", 1<<20)))
+	}))
+	defer ts.Close()
+
+	bundle := func(url string) *NotificationServerBundle {
+		return &NotificationServerBundle{
+			Notification: &Notification{URL: url, RequestMethod: NotificationRequestMethodPOST, RequestType: NotificationRequestTypeJSON, RequestBody: `{"m":"#NEZHA#"}`},
+			Loc:          time.UTC,
+		}
+	}
+
+	status, body, err := bundle(ts.URL).SendWithResponse(context.Background(), msg)
+	if err == nil || status != http.StatusBadGateway || len(body) != 4096 {
+		t.Fatalf("expected a limited error body, got %d %d %v", status, len(body), err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, _, err := bundle(ts.URL+"/slow").SendWithResponse(ctx, msg); err == nil {
+		t.Fatal("expected the request to be cancelled by the context")
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("the request did not respect the context deadline")
+	}
+}
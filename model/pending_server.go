@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+)
+
+// PendingServer 开启注册审核后，未知 Agent 在管理员审核前的注册请求
+type PendingServer struct {
+	Common
+	UUID       string    `gorm:"uniqueIndex" json:"uuid"`
+	IP         string    `json:"ip,omitempty"` // 最近一次连接的 IP
+	HostRaw    string    `gorm:"type:longtext" json:"-"`
+	Host       *Host     `gorm:"-" json:"host,omitempty"` // Agent 上报的主机信息
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Rejected   bool      `json:"rejected,omitempty"` // 已拒绝的请求保留记录，删除后 Agent 可重新申请
+}
+
+func (p *PendingServer) BeforeSave(tx *gorm.DB) error {
+	if p.Host == nil {
+		p.HostRaw = ""
+		return nil
+	}
+	if data, err := json.Marshal(p.Host); err != nil {
+		return err
+	} else {
+		p.HostRaw = string(data)
+	}
+	return nil
+}
+
+func (p *PendingServer) AfterFind(tx *gorm.DB) error {
+	if p.HostRaw == "" {
+		return nil
+	}
+	p.Host = new(Host)
+	return json.Unmarshal([]byte(p.HostRaw), p.Host)
+}
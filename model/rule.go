@@ -1,12 +1,15 @@
 package model
 
 import (
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"gorm.io/gorm"
 
+	"github.com/nezhahq/nezha/pkg/expr"
 	"github.com/nezhahq/nezha/pkg/utils"
 )
 
@@ -15,6 +18,24 @@ const (
 	RuleCoverIgnoreAll
 )
 
+// RuleExpressionVars 表达式规则中可使用的变量
+var RuleExpressionVars = []string{
+	"cpu", "cpu_cores", "gpu_max",
+	"memory", "mem_used", "mem_total", "mem_free",
+	"swap", "swap_used", "swap_total", "swap_free",
+	"disk", "disk_used", "disk_total", "disk_free",
+	"net_in_speed", "net_out_speed", "net_all_speed",
+	"transfer_in", "transfer_out", "transfer_all",
+	"load1", "load5", "load15",
+	"tcp_conn_count", "udp_conn_count", "process_count",
+	"temperature_max", "uptime",
+}
+
+var cpuCoresRegexp = regexp.MustCompile(`(\d+) (?:Virtual|Physical) Core`)
+
+// ruleStateStaleAfter 超过该时长未上报的服务器状态不再用于表达式规则
+const ruleStateStaleAfter = 30 * time.Second
+
 type NResult struct {
 	N uint64
 }
@@ -22,8 +43,9 @@ type NResult struct {
 type Rule struct {
 	// 指标类型，cpu、memory、swap、disk、net_in_speed、net_out_speed
 	// net_all_speed、transfer_in、transfer_out、transfer_all、offline
-	// transfer_in_cycle、transfer_out_cycle、transfer_all_cycle
+	// transfer_in_cycle、transfer_out_cycle、transfer_all_cycle、expression
 	Type          string          `json:"type"`
+	Expression    string          `json:"expression,omitempty" validate:"optional"`                                                 // 表达式规则，结果为 true 时视为未通过
 	Min           float64         `json:"min,omitempty" validate:"optional"`                                                        // 最小阈值 (百分比、字节 kb ÷ 1024)
 	Max           float64         `json:"max,omitempty" validate:"optional"`                                                        // 最大阈值 (百分比、字节 kb ÷ 1024)
 	CycleStart    *time.Time      `json:"cycle_start,omitempty" validate:"optional"`                                                // 流量统计的开始时间
@@ -36,6 +58,8 @@ type Rule struct {
 	// 只作为缓存使用，记录下次该检测的时间
 	NextTransferAt  map[uint64]time.Time `json:"-"`
 	LastCycleStatus map[uint64]bool      `json:"-"`
+
+	compiledExpression *expr.Expr
 }
 
 func percentage(used, total uint64) float64 {
@@ -45,15 +69,32 @@ func percentage(used, total uint64) float64 {
 	return float64(used) * 100 / float64(total)
 }
 
-// Snapshot 未通过规则返回 false, 通过返回 true
-func (u *Rule) Snapshot(cycleTransferStats *CycleTransferStats, server *Server, db *gorm.DB) bool {
+// Covers 按 Cover 与 Ignore 判断规则是否覆盖该服务器
+func (u *Rule) Covers(serverID uint64) bool {
 	// 监控全部但是排除了此服务器
-	if u.Cover == RuleCoverAll && u.Ignore[server.ID] {
-		return true
+	if u.Cover == RuleCoverAll && u.Ignore[serverID] {
+		return false
 	}
 	// 忽略全部但是指定监控了此服务器
-	if u.Cover == RuleCoverIgnoreAll && !u.Ignore[server.ID] {
-		return true
+	if u.Cover == RuleCoverIgnoreAll && !u.Ignore[serverID] {
+		return false
+	}
+	return true
+}
+
+// Snapshot 未通过规则返回 false, 通过返回 true，覆盖范围由调用方通过 Covers 判断
+func (u *Rule) Snapshot(cycleTransferStats *CycleTransferStats, server *Server, db *gorm.DB) bool {
+	if u.IsExpressionRule() {
+		// 没有可用的状态数据（从未上报或已离线）时视为无数据，不参与判断
+		if !hasFreshState(server) {
+			return true
+		}
+		if u.compiledExpression == nil {
+			if err := u.CompileExpression(); err != nil {
+				return true
+			}
+		}
+		return !u.compiledExpression.Eval(expressionValues(server))
 	}
 
 	// 循环区间流量检测 · 短期无需重复检测
@@ -171,6 +212,25 @@ func (u *Rule) Snapshot(cycleTransferStats *CycleTransferStats, server *Server,
 	return true
 }
 
+// Value 返回规则对应指标的当前值，离线规则为离线的秒数，周期流量规则为最近一次统计的流量
+func (u *Rule) Value(cycleTransferStats *CycleTransferStats, server *Server) float64 {
+	switch {
+	case u.IsExpressionRule():
+		return 0
+	case u.IsTransferDurationRule():
+		if cycleTransferStats == nil {
+			return 0
+		}
+		return float64(cycleTransferStats.Transfer[server.ID])
+	case u.IsOfflineRule():
+		if server.LastActive.IsZero() {
+			return 0
+		}
+		return time.Since(server.LastActive).Seconds()
+	}
+	return expressionValues(server)[u.Type]
+}
+
 // IsTransferDurationRule 判断该规则是否属于周期流量规则 属于则返回true
 func (u *Rule) IsTransferDurationRule() bool {
 	return strings.HasSuffix(u.Type, "_cycle")
@@ -180,6 +240,88 @@ func (u *Rule) IsOfflineRule() bool {
 	return u.Type == "offline"
 }
 
+func (u *Rule) IsExpressionRule() bool {
+	return u.Type == "expression"
+}
+
+// CompileExpression 编译表达式规则并缓存
+func (u *Rule) CompileExpression() error {
+	e, err := expr.Compile(u.Expression, RuleExpressionVars)
+	if err != nil {
+		return err
+	}
+	u.compiledExpression = e
+	return nil
+}
+
+// hasFreshState 服务器最近上报过状态，离线超过 ruleStateStaleAfter 的状态视为过期
+func hasFreshState(server *Server) bool {
+	return server.State != nil && !server.LastActive.IsZero() &&
+		time.Since(server.LastActive) <= ruleStateStaleAfter
+}
+
+// expressionValues 根据服务器状态计算表达式规则的变量值
+func expressionValues(server *Server) map[string]float64 {
+	state, host := server.State, server.Host
+	if state == nil {
+		state = &HostState{}
+	}
+	if host == nil {
+		host = &Host{}
+	}
+
+	vars := map[string]float64{
+		"cpu":            state.CPU,
+		"cpu_cores":      float64(cpuCores(host.CPU)),
+		"memory":         percentage(state.MemUsed, host.MemTotal),
+		"mem_used":       float64(state.MemUsed),
+		"mem_total":      float64(host.MemTotal),
+		"mem_free":       float64(utils.SubUintChecked(host.MemTotal, state.MemUsed)),
+		"swap":           percentage(state.SwapUsed, host.SwapTotal),
+		"swap_used":      float64(state.SwapUsed),
+		"swap_total":     float64(host.SwapTotal),
+		"swap_free":      float64(utils.SubUintChecked(host.SwapTotal, state.SwapUsed)),
+		"disk":           percentage(state.DiskUsed, host.DiskTotal),
+		"disk_used":      float64(state.DiskUsed),
+		"disk_total":     float64(host.DiskTotal),
+		"disk_free":      float64(utils.SubUintChecked(host.DiskTotal, state.DiskUsed)),
+		"net_in_speed":   float64(state.NetInSpeed),
+		"net_out_speed":  float64(state.NetOutSpeed),
+		"net_all_speed":  float64(state.NetInSpeed + state.NetOutSpeed),
+		"transfer_in":    float64(state.NetInTransfer),
+		"transfer_out":   float64(state.NetOutTransfer),
+		"transfer_all":   float64(state.NetInTransfer + state.NetOutTransfer),
+		"load1":          state.Load1,
+		"load5":          state.Load5,
+		"load15":         state.Load15,
+		"tcp_conn_count": float64(state.TcpConnCount),
+		"udp_conn_count": float64(state.UdpConnCount),
+		"process_count":  float64(state.ProcessCount),
+		"uptime":         float64(state.Uptime),
+	}
+	if len(state.GPU) > 0 {
+		vars["gpu_max"] = slices.Max(state.GPU)
+	}
+	for _, t := range state.Temperatures {
+		vars["temperature_max"] = max(vars["temperature_max"], t.Temperature)
+	}
+	return vars
+}
+
+// cpuCores 从 Agent 上报的 CPU 描述中解析核心数，如 "Intel(R) Xeon(R) 2 Virtual Core"
+func cpuCores(cpus []string) int {
+	var cores int
+	for _, c := range cpus {
+		if m := cpuCoresRegexp.FindStringSubmatch(c); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			cores += n
+		} else {
+			cores++
+		}
+	}
+	return cores
+}
+
 // GetTransferDurationStart 获取周期流量的起始时间
 func (u *Rule) GetTransferDurationStart() time.Time {
 	// Accept uppercase and lowercase
@@ -32,8 +32,9 @@ type Server struct {
 	GeoIP      *GeoIP     `gorm:"-" json:"geoip,omitempty"`
 	LastActive time.Time  `gorm:"-" json:"last_active,omitempty"`
 
-	TaskStream  pb.NezhaService_RequestTaskServer `gorm:"-" json:"-"`
-	ConfigCache chan any                          `gorm:"-" json:"-"`
+	TaskStream    pb.NezhaService_RequestTaskServer `gorm:"-" json:"-"`
+	ConfigCache   chan any                          `gorm:"-" json:"-"`
+	AgentFeatures []string                          `gorm:"-" json:"-"` // 任务流连接时 Agent 声明支持的功能
 
 	PrevTransferInSnapshot  uint64 `gorm:"-" json:"-"` // 上次数据点时的入站使用量
 	PrevTransferOutSnapshot uint64 `gorm:"-" json:"-"` // 上次数据点时的出站使用量
@@ -52,11 +53,17 @@ func (s *Server) CopyFromRunningServer(old *Server) {
 	s.GeoIP = old.GeoIP
 	s.LastActive = old.LastActive
 	s.TaskStream = old.TaskStream
+	s.AgentFeatures = old.AgentFeatures
 	s.ConfigCache = old.ConfigCache
 	s.PrevTransferInSnapshot = old.PrevTransferInSnapshot
 	s.PrevTransferOutSnapshot = old.PrevTransferOutSnapshot
 }
 
+// Supports 判断服务器的 Agent 是否声明支持某项功能
+func (s *Server) Supports(feature string) bool {
+	return slices.Contains(s.AgentFeatures, feature)
+}
+
 func (s *Server) AfterFind(tx *gorm.DB) error {
 	if s.DDNSProfilesRaw != "" {
 		if err := json.Unmarshal([]byte(s.DDNSProfilesRaw), &s.DDNSProfiles); err != nil {
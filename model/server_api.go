@@ -41,3 +41,16 @@ type ServerTaskResponse struct {
 	Failure []uint64 `json:"failure,omitempty" validate:"optional"`
 	Offline []uint64 `json:"offline,omitempty" validate:"optional"`
 }
+
+type ServerMetricsResponse struct {
+	ServerID uint64              `json:"server_id"`
+	Metric   string              `json:"metric"`
+	Tier     MetricTier          `json:"tier"`
+	Step     uint64              `json:"step"` // 秒
+	Points   []ServerMetricPoint `json:"points"`
+}
+
+type PendingServerApproveForm struct {
+	Name         string   `json:"name,omitempty" validate:"optional"` // 为空时自动生成
+	ServerGroups []uint64 `json:"server_groups,omitempty" validate:"optional"`
+}
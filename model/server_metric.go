@@ -0,0 +1,174 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+type MetricTier uint8
+
+const (
+	MetricTierRaw MetricTier = iota
+	MetricTierMinute
+	MetricTierHour
+	MetricTierDay
+)
+
+// Resolution 返回该存储层级单个数据点覆盖的时长
+func (t MetricTier) Resolution(rawInterval time.Duration) time.Duration {
+	switch t {
+	case MetricTierMinute:
+		return time.Minute
+	case MetricTierHour:
+		return time.Hour
+	case MetricTierDay:
+		return time.Hour * 24
+	default:
+		return rawInterval
+	}
+}
+
+// ServerMetricFields 可查询的指标名称，与 HostState 的 json 字段保持一致
+var ServerMetricFields = []string{
+	"cpu", "mem_used", "swap_used", "disk_used",
+	"net_in_transfer", "net_out_transfer", "net_in_speed", "net_out_speed",
+	"uptime", "load_1", "load_5", "load_15",
+	"tcp_conn_count", "udp_conn_count", "process_count",
+	"temperature_max", "gpu_max",
+}
+
+func IsValidServerMetric(metric string) bool {
+	return slices.Contains(ServerMetricFields, metric)
+}
+
+type ServerMetric struct {
+	ID        uint64     `gorm:"primaryKey" json:"-"`
+	ServerID  uint64     `gorm:"index:idx_server_metric_server_tier_created_at" json:"server_id"`
+	Tier      MetricTier `gorm:"index:idx_server_metric_server_tier_created_at" json:"tier"`
+	CreatedAt time.Time  `gorm:"index:idx_server_metric_server_tier_created_at" json:"created_at"`
+
+	CPU            float64 `gorm:"column:cpu" json:"cpu"`
+	MemUsed        float64 `gorm:"column:mem_used" json:"mem_used"`
+	SwapUsed       float64 `gorm:"column:swap_used" json:"swap_used"`
+	DiskUsed       float64 `gorm:"column:disk_used" json:"disk_used"`
+	NetInTransfer  float64 `gorm:"column:net_in_transfer" json:"net_in_transfer"`
+	NetOutTransfer float64 `gorm:"column:net_out_transfer" json:"net_out_transfer"`
+	NetInSpeed     float64 `gorm:"column:net_in_speed" json:"net_in_speed"`
+	NetOutSpeed    float64 `gorm:"column:net_out_speed" json:"net_out_speed"`
+	Uptime         float64 `gorm:"column:uptime" json:"uptime"`
+	Load1          float64 `gorm:"column:load_1" json:"load_1"`
+	Load5          float64 `gorm:"column:load_5" json:"load_5"`
+	Load15         float64 `gorm:"column:load_15" json:"load_15"`
+	TcpConnCount   float64 `gorm:"column:tcp_conn_count" json:"tcp_conn_count"`
+	UdpConnCount   float64 `gorm:"column:udp_conn_count" json:"udp_conn_count"`
+	ProcessCount   float64 `gorm:"column:process_count" json:"process_count"`
+	TemperatureMax float64 `gorm:"column:temperature_max" json:"temperature_max"`
+	GPUMax         float64 `gorm:"column:gpu_max" json:"gpu_max"`
+}
+
+// NewServerMetric 将上报的 HostState 转换为原始精度的指标数据点
+func NewServerMetric(serverID uint64, s *HostState, at time.Time) ServerMetric {
+	m := ServerMetric{
+		ServerID:       serverID,
+		Tier:           MetricTierRaw,
+		CreatedAt:      at,
+		CPU:            s.CPU,
+		MemUsed:        float64(s.MemUsed),
+		SwapUsed:       float64(s.SwapUsed),
+		DiskUsed:       float64(s.DiskUsed),
+		NetInTransfer:  float64(s.NetInTransfer),
+		NetOutTransfer: float64(s.NetOutTransfer),
+		NetInSpeed:     float64(s.NetInSpeed),
+		NetOutSpeed:    float64(s.NetOutSpeed),
+		Uptime:         float64(s.Uptime),
+		Load1:          s.Load1,
+		Load5:          s.Load5,
+		Load15:         s.Load15,
+		TcpConnCount:   float64(s.TcpConnCount),
+		UdpConnCount:   float64(s.UdpConnCount),
+		ProcessCount:   float64(s.ProcessCount),
+	}
+	for _, t := range s.Temperatures {
+		m.TemperatureMax = max(m.TemperatureMax, t.Temperature)
+	}
+	if len(s.GPU) > 0 {
+		m.GPUMax = slices.Max(s.GPU)
+	}
+	return m
+}
+
+// Value 按指标名称取值
+func (m *ServerMetric) Value(metric string) float64 {
+	switch metric {
+	case "cpu":
+		return m.CPU
+	case "mem_used":
+		return m.MemUsed
+	case "swap_used":
+		return m.SwapUsed
+	case "disk_used":
+		return m.DiskUsed
+	case "net_in_transfer":
+		return m.NetInTransfer
+	case "net_out_transfer":
+		return m.NetOutTransfer
+	case "net_in_speed":
+		return m.NetInSpeed
+	case "net_out_speed":
+		return m.NetOutSpeed
+	case "uptime":
+		return m.Uptime
+	case "load_1":
+		return m.Load1
+	case "load_5":
+		return m.Load5
+	case "load_15":
+		return m.Load15
+	case "tcp_conn_count":
+		return m.TcpConnCount
+	case "udp_conn_count":
+		return m.UdpConnCount
+	case "process_count":
+		return m.ProcessCount
+	case "temperature_max":
+		return m.TemperatureMax
+	case "gpu_max":
+		return m.GPUMax
+	}
+	return 0
+}
+
+type ServerMetricPoint struct {
+	Timestamp int64   `json:"ts"` // 毫秒时间戳
+	Value     float64 `json:"value"`
+}
+
+// DownsampleMetricPoints 将数据点按 step 对齐分桶并取平均值，输入需按时间升序排列
+func DownsampleMetricPoints(points []ServerMetricPoint, step time.Duration) []ServerMetricPoint {
+	stepMs := step.Milliseconds()
+	if stepMs <= 0 {
+		return points
+	}
+
+	var (
+		ret    []ServerMetricPoint
+		bucket int64 = -1
+		sum    float64
+		count  int
+	)
+	for _, p := range points {
+		b := p.Timestamp - p.Timestamp%stepMs
+		if b != bucket {
+			if count > 0 {
+				ret = append(ret, ServerMetricPoint{Timestamp: bucket, Value: sum / float64(count)})
+			}
+			bucket, sum, count = b, 0, 0
+		}
+		sum += p.Value
+		count++
+	}
+	if count > 0 {
+		ret = append(ret, ServerMetricPoint{Timestamp: bucket, Value: sum / float64(count)})
+	}
+	return ret
+}
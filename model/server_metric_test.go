@@ -0,0 +1,47 @@
+package model
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDownsampleMetricPoints(t *testing.T) {
+	points := []ServerMetricPoint{
+		{Timestamp: 0, Value: 1},
+		{Timestamp: 30_000, Value: 3},
+		{Timestamp: 60_000, Value: 5},
+		{Timestamp: 150_000, Value: 7},
+		{Timestamp: 170_000, Value: 9},
+	}
+
+	cases := []struct {
+		step time.Duration
+		exp  []ServerMetricPoint
+	}{
+		{
+			step: 0,
+			exp:  points,
+		},
+		{
+			step: time.Minute,
+			exp: []ServerMetricPoint{
+				{Timestamp: 0, Value: 2},
+				{Timestamp: 60_000, Value: 5},
+				{Timestamp: 120_000, Value: 8},
+			},
+		},
+		{
+			step: time.Hour,
+			exp: []ServerMetricPoint{
+				{Timestamp: 0, Value: 5},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		if got := DownsampleMetricPoints(points, c.step); !slices.Equal(got, c.exp) {
+			t.Errorf("step %v: expected %v, got %v", c.step, c.exp, got)
+		}
+	}
+}
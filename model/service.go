@@ -3,6 +3,8 @@ package model
 import (
 	"fmt"
 	"log"
+	"net"
+	"strings"
 
 	"github.com/goccy/go-json"
 	"github.com/robfig/cron/v3"
@@ -26,6 +28,9 @@ const (
 	TaskTypeFM
 	TaskTypeReportConfig
 	TaskTypeApplyConfig
+	TaskTypeDNS
+	TaskTypeUDP
+	TaskTypeTLS
 )
 
 type TerminalTask struct {
@@ -65,22 +70,146 @@ type Service struct {
 	FailTriggerTasks    []uint64 `gorm:"-" json:"fail_trigger_tasks"`    // 失败时执行的触发任务id
 	RecoverTriggerTasks []uint64 `gorm:"-" json:"recover_trigger_tasks"` // 恢复时执行的触发任务id
 
+	ServerGroupsRaw        string   `gorm:"default:'[]'" json:"-"`
+	ExcludeServerGroupsRaw string   `gorm:"default:'[]'" json:"-"`
+	ServerGroups           []uint64 `gorm:"-" json:"server_groups"`         // 执行监控的服务器分组，指定后忽略 Cover 与 SkipServers
+	ExcludeServerGroups    []uint64 `gorm:"-" json:"exclude_server_groups"` // 排除的服务器分组
+
 	MinLatency    float32 `json:"min_latency"`
 	MaxLatency    float32 `json:"max_latency"`
 	LatencyNotify bool    `json:"latency_notify,omitempty"`
 
+	DashboardProbe bool `gorm:"default: false" json:"dashboard_probe,omitempty"` // 由面板自身执行监控，仅管理员可开启，结果的监测点为 singleton.DashboardReporter
+
+	// 至少多少个监测点失败时才判定服务故障，为 0 时按所有监测点的汇总结果判定
+	Quorum uint64 `gorm:"default: 0" json:"quorum,omitempty"`
+
+	// 服务状态的判定参数，为 0 时使用默认值
+	WindowSamples     uint64  `json:"window_samples,omitempty"`     // 统计当前状态的样本数，默认 30
+	WindowDuration    uint64  `json:"window_duration,omitempty"`    // 统计当前状态的时长（秒），按检查间隔换算为样本数
+	GoodThreshold     float32 `json:"good_threshold,omitempty"`     // 可用率高于该值时为正常，默认 95
+	DegradedThreshold float32 `json:"degraded_threshold,omitempty"` // 可用率高于该值时为低可用，默认 80
+	FailureThreshold  uint64  `json:"failure_threshold,omitempty"`  // 连续失败多少次后判定为故障并发送通知，默认 1
+
+	HTTPCheckRaw string     `gorm:"type:longtext" json:"-"`
+	HTTPCheck    *HTTPCheck `gorm:"-" json:"http_check,omitempty"` // HTTP 监控的请求与断言，未设置时只检查请求是否成功
+	DNSCheckRaw  string     `gorm:"type:longtext" json:"-"`
+	DNSCheck     *DNSCheck  `gorm:"-" json:"dns_check,omitempty"`
+	UDPCheckRaw  string     `gorm:"type:longtext" json:"-"`
+	UDPCheck     *UDPCheck  `gorm:"-" json:"udp_check,omitempty"`
+	TLSCheckRaw  string     `gorm:"type:longtext" json:"-"`
+	TLSCheck     *TLSCheck  `gorm:"-" json:"tls_check,omitempty"`
+
 	SkipServers map[uint64]bool `gorm:"-" json:"skip_servers"`
 	CronJobID   cron.EntryID    `gorm:"-" json:"-"`
 }
 
+// PB 生成下发给未声明 AgentFeatureServiceCheck 的 Agent 的任务，Data 为监控目标
 func (m *Service) PB() *pb.Task {
+	return m.encodeTask(nil)
+}
+
+// CheckPB 生成带检查配置的任务，下发给声明了 AgentFeatureServiceCheck 的 Agent 或由面板执行。
+// 设置了 HTTP 检查时 Data 为 JSON 格式的 HTTPCheckTask，DNS、UDP、TLS 监控的 Data 始终为 JSON 格式的检查任务
+func (m *Service) CheckPB() *pb.Task {
+	var task any
+	switch m.Type {
+	case TaskTypeHTTPGet:
+		if m.HTTPCheck != nil {
+			task = HTTPCheckTask{URL: m.Target, HTTPCheck: *m.HTTPCheck}
+		}
+	case TaskTypeDNS:
+		task = DNSCheckTask{Domain: m.Target, DNSCheck: derefOrZero(m.DNSCheck)}
+	case TaskTypeUDP:
+		task = UDPCheckTask{Address: m.Target, UDPCheck: derefOrZero(m.UDPCheck)}
+	case TaskTypeTLS:
+		task = TLSCheckTask{Address: m.Target, TLSCheck: derefOrZero(m.TLSCheck)}
+	}
+	return m.encodeTask(task)
+}
+
+// RequiresServiceCheck 判断监控是否需要 Agent 支持 AgentFeatureServiceCheck，
+// 旧版 Agent 不支持 HTTP 检查的断言以及 DNS、UDP、TLS 监控
+func (m *Service) RequiresServiceCheck() bool {
+	switch m.Type {
+	case TaskTypeHTTPGet:
+		return m.HTTPCheck != nil
+	case TaskTypeDNS, TaskTypeUDP, TaskTypeTLS:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *Service) encodeTask(task any) *pb.Task {
+	data := m.Target
+	if task != nil {
+		if b, err := json.Marshal(task); err == nil {
+			data = string(b)
+		} else {
+			log.Printf("NEZHA>> Failed to encode check of service %d: %v", m.ID, err)
+		}
+	}
 	return &pb.Task{
 		Id:   m.ID,
 		Type: uint64(m.Type),
-		Data: m.Target,
+		Data: data,
 	}
 }
 
+// ValidateCheck 按监控类型校验目标与检查配置，并填充默认值
+func (m *Service) ValidateCheck() error {
+	checks := map[uint8]bool{
+		TaskTypeHTTPGet: m.HTTPCheck != nil,
+		TaskTypeDNS:     m.DNSCheck != nil,
+		TaskTypeUDP:     m.UDPCheck != nil,
+		TaskTypeTLS:     m.TLSCheck != nil,
+	}
+	for t, set := range checks {
+		if set && t != m.Type {
+			return fmt.Errorf("check config does not match service type %d", m.Type)
+		}
+	}
+
+	switch m.Type {
+	case TaskTypeHTTPGet:
+		if m.HTTPCheck != nil {
+			return m.HTTPCheck.Normalize()
+		}
+	case TaskTypeDNS:
+		if m.Target == "" || strings.ContainsAny(m.Target, "/: ") {
+			return fmt.Errorf("invalid domain %s", m.Target)
+		}
+		if m.DNSCheck == nil {
+			m.DNSCheck = &DNSCheck{}
+		}
+		return m.DNSCheck.Normalize()
+	case TaskTypeUDP, TaskTypeTLS:
+		if _, port, err := net.SplitHostPort(m.Target); err != nil || port == "" {
+			return fmt.Errorf("invalid address %s, expected host:port", m.Target)
+		}
+		if m.Type == TaskTypeUDP {
+			if m.UDPCheck == nil {
+				m.UDPCheck = &UDPCheck{}
+			}
+			return m.UDPCheck.Normalize()
+		}
+		if m.TLSCheck == nil {
+			m.TLSCheck = &TLSCheck{}
+		}
+		return m.TLSCheck.Normalize()
+	}
+	return nil
+}
+
+func derefOrZero[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
+
 // CronSpec 返回服务监控请求间隔对应的 cron 表达式
 func (m *Service) CronSpec() string {
 	if m.Duration == 0 {
@@ -90,6 +219,15 @@ func (m *Service) CronSpec() string {
 	return fmt.Sprintf("@every %ds", m.Duration)
 }
 
+// QuorumReached 判断失败的监测点数量是否达到判定服务故障的数量，
+// 有结果的监测点少于 Quorum 时要求所有监测点均失败
+func (m *Service) QuorumReached(failing, active int) bool {
+	if m.Quorum == 0 || failing == 0 {
+		return false
+	}
+	return uint64(failing) >= min(m.Quorum, uint64(active))
+}
+
 func (m *Service) BeforeSave(tx *gorm.DB) error {
 	if data, err := json.Marshal(m.SkipServers); err != nil {
 		return err
@@ -106,9 +244,51 @@ func (m *Service) BeforeSave(tx *gorm.DB) error {
 	} else {
 		m.RecoverTriggerTasksRaw = string(data)
 	}
+	if data, err := json.Marshal(m.ServerGroups); err != nil {
+		return err
+	} else {
+		m.ServerGroupsRaw = string(data)
+	}
+	if data, err := json.Marshal(m.ExcludeServerGroups); err != nil {
+		return err
+	} else {
+		m.ExcludeServerGroupsRaw = string(data)
+	}
+	var err error
+	if m.HTTPCheckRaw, err = marshalCheck(m.HTTPCheck); err != nil {
+		return err
+	}
+	if m.DNSCheckRaw, err = marshalCheck(m.DNSCheck); err != nil {
+		return err
+	}
+	if m.UDPCheckRaw, err = marshalCheck(m.UDPCheck); err != nil {
+		return err
+	}
+	if m.TLSCheckRaw, err = marshalCheck(m.TLSCheck); err != nil {
+		return err
+	}
 	return nil
 }
 
+func marshalCheck[T any](check *T) (string, error) {
+	if check == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(check)
+	return string(data), err
+}
+
+func unmarshalCheck[T any](raw string) (*T, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	check := new(T)
+	if err := json.Unmarshal([]byte(raw), check); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
 func (m *Service) AfterFind(tx *gorm.DB) error {
 	m.SkipServers = make(map[uint64]bool)
 	if err := json.Unmarshal([]byte(m.SkipServersRaw), &m.SkipServers); err != nil {
@@ -124,9 +304,45 @@ func (m *Service) AfterFind(tx *gorm.DB) error {
 		return err
 	}
 
+	// 加载服务器分组
+	if err := json.Unmarshal([]byte(m.ServerGroupsRaw), &m.ServerGroups); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(m.ExcludeServerGroupsRaw), &m.ExcludeServerGroups); err != nil {
+		return err
+	}
+
+	// 加载检查配置
+	var err error
+	if m.HTTPCheck, err = unmarshalCheck[HTTPCheck](m.HTTPCheckRaw); err != nil {
+		return err
+	}
+	if m.DNSCheck, err = unmarshalCheck[DNSCheck](m.DNSCheckRaw); err != nil {
+		return err
+	}
+	if m.UDPCheck, err = unmarshalCheck[UDPCheck](m.UDPCheckRaw); err != nil {
+		return err
+	}
+	if m.TLSCheck, err = unmarshalCheck[TLSCheck](m.TLSCheckRaw); err != nil {
+		return err
+	}
+	if err := m.ValidateCheck(); err != nil {
+		log.Printf("NEZHA>> Invalid check config of service %d: %v", m.ID, err)
+	}
+
 	return nil
 }
 
+// IsLatencyTask 判断该任务类型是否按监测点记录延迟
+func IsLatencyTask(t uint64) bool {
+	switch t {
+	case TaskTypeTCPPing, TaskTypeICMPPing, TaskTypeDNS, TaskTypeUDP, TaskTypeTLS:
+		return true
+	default:
+		return false
+	}
+}
+
 // IsServiceSentinelNeeded 判断该任务类型是否需要进行服务监控 需要则返回true
 func IsServiceSentinelNeeded(t uint64) bool {
 	switch t {
@@ -18,6 +18,19 @@ type ServiceForm struct {
 	RecoverTriggerTasks []uint64        `json:"recover_trigger_tasks,omitempty"`
 	SkipServers         map[uint64]bool `json:"skip_servers,omitempty"`
 	NotificationGroupID uint64          `json:"notification_group_id,omitempty"`
+	ServerGroups        []uint64        `json:"server_groups,omitempty" validate:"optional"`
+	ExcludeServerGroups []uint64        `json:"exclude_server_groups,omitempty" validate:"optional"`
+	HTTPCheck           *HTTPCheck      `json:"http_check,omitempty" validate:"optional"`
+	DNSCheck            *DNSCheck       `json:"dns_check,omitempty" validate:"optional"`
+	UDPCheck            *UDPCheck       `json:"udp_check,omitempty" validate:"optional"`
+	TLSCheck            *TLSCheck       `json:"tls_check,omitempty" validate:"optional"`
+	DashboardProbe      bool            `json:"dashboard_probe,omitempty" validate:"optional"`
+	Quorum              uint64          `json:"quorum,omitempty" validate:"optional"`
+	WindowSamples       uint64          `json:"window_samples,omitempty" validate:"optional"`
+	WindowDuration      uint64          `json:"window_duration,omitempty" validate:"optional"`
+	GoodThreshold       float32         `json:"good_threshold,omitempty" validate:"optional"`
+	DegradedThreshold   float32         `json:"degraded_threshold,omitempty" validate:"optional"`
+	FailureThreshold    uint64          `json:"failure_threshold,omitempty" validate:"optional"`
 }
 
 type ServiceResponseItem struct {
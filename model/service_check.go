@@ -0,0 +1,311 @@
+package model
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	HTTPCheckRedirectFollow = "follow"
+	HTTPCheckRedirectNone   = "none"
+)
+
+const (
+	HTTPCheckDefaultMaxResponseSize = 1 << 20
+	HTTPCheckMaxResponseSize        = 16 << 20
+	HTTPCheckDefaultMaxRedirects    = 10
+)
+
+var httpCheckMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// HTTPCheck HTTP 服务监控的请求与断言，未设置的字段使用默认值
+type HTTPCheck struct {
+	Method          string            `json:"method,omitempty"` // 默认 GET
+	Headers         map[string]string `json:"headers,omitempty"`
+	Body            string            `json:"body,omitempty"`
+	StatusMin       int               `json:"status_min,omitempty"`        // 期望的状态码下限，默认 200
+	StatusMax       int               `json:"status_max,omitempty"`        // 期望的状态码上限，默认 299
+	BodyContains    string            `json:"body_contains,omitempty"`     // 响应内容需包含的字符串
+	BodyRegex       string            `json:"body_regex,omitempty"`        // 响应内容需匹配的正则表达式
+	JSONPath        string            `json:"json_path,omitempty"`         // 响应内容中需存在的 JSON 路径（gjson 语法）
+	JSONValue       string            `json:"json_value,omitempty"`        // JSON 路径的期望值，为空时只检查路径是否存在
+	ExpectHeaders   map[string]string `json:"expect_headers,omitempty"`    // 响应头需包含的值
+	MaxResponseSize int64             `json:"max_response_size,omitempty"` // 响应内容的最大字节数，默认 1 MiB
+	Redirect        string            `json:"redirect,omitempty"`          // follow（默认）、none
+	MaxRedirects    int               `json:"max_redirects,omitempty"`     // 跟随重定向的最大次数，默认 10
+
+	bodyRegex *regexp.Regexp
+}
+
+// HTTPCheckTask 下发给 Agent 的 HTTP 监控任务
+type HTTPCheckTask struct {
+	URL string `json:"url"`
+	HTTPCheck
+}
+
+// Normalize 校验 HTTP 检查配置并填充默认值
+func (c *HTTPCheck) Normalize() error {
+	c.Method = strings.ToUpper(strings.TrimSpace(c.Method))
+	if c.Method == "" {
+		c.Method = http.MethodGet
+	}
+	if !slices.Contains(httpCheckMethods, c.Method) {
+		return fmt.Errorf("unsupported method %s", c.Method)
+	}
+
+	if c.StatusMin == 0 && c.StatusMax == 0 {
+		c.StatusMin, c.StatusMax = 200, 299
+	} else if c.StatusMax == 0 {
+		c.StatusMax = c.StatusMin
+	}
+	if c.StatusMin < 100 || c.StatusMax > 599 || c.StatusMin > c.StatusMax {
+		return fmt.Errorf("invalid status code range %d-%d", c.StatusMin, c.StatusMax)
+	}
+
+	if c.BodyRegex != "" {
+		re, err := regexp.Compile(c.BodyRegex)
+		if err != nil {
+			return fmt.Errorf("invalid body regex: %w", err)
+		}
+		c.bodyRegex = re
+	}
+	if c.JSONPath == "" && c.JSONValue != "" {
+		return fmt.Errorf("json_value requires json_path")
+	}
+
+	if c.MaxResponseSize == 0 {
+		c.MaxResponseSize = HTTPCheckDefaultMaxResponseSize
+	}
+	if c.MaxResponseSize < 0 || c.MaxResponseSize > HTTPCheckMaxResponseSize {
+		return fmt.Errorf("max_response_size must be between 1 and %d", HTTPCheckMaxResponseSize)
+	}
+
+	switch c.Redirect {
+	case "":
+		c.Redirect = HTTPCheckRedirectFollow
+	case HTTPCheckRedirectFollow, HTTPCheckRedirectNone:
+	default:
+		return fmt.Errorf("unknown redirect policy %s", c.Redirect)
+	}
+	if c.MaxRedirects == 0 {
+		c.MaxRedirects = HTTPCheckDefaultMaxRedirects
+	}
+	if c.MaxRedirects < 0 {
+		return fmt.Errorf("invalid max_redirects %d", c.MaxRedirects)
+	}
+	return nil
+}
+
+// CheckRedirect 按重定向策略返回 http.Client 的 CheckRedirect
+func (c *HTTPCheck) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if c.Redirect == HTTPCheckRedirectNone {
+		return http.ErrUseLastResponse
+	}
+	maxRedirects := c.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = HTTPCheckDefaultMaxRedirects
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return nil
+}
+
+// Assert 检查响应是否满足断言，body 最多读取 MaxResponseSize+1 字节，
+// 返回的错误作为失败原因上报
+func (c *HTTPCheck) Assert(statusCode int, header http.Header, body []byte) error {
+	if statusCode < c.StatusMin || statusCode > c.StatusMax {
+		return fmt.Errorf("unexpected status code %d, expected %d-%d", statusCode, c.StatusMin, c.StatusMax)
+	}
+	if c.MaxResponseSize > 0 && int64(len(body)) > c.MaxResponseSize {
+		return fmt.Errorf("response body is larger than %d bytes", c.MaxResponseSize)
+	}
+	for k, v := range c.ExpectHeaders {
+		if got := header.Get(k); !strings.Contains(got, v) {
+			return fmt.Errorf("header %s is %q, expected to contain %q", k, got, v)
+		}
+	}
+	if c.BodyContains != "" && !bytes.Contains(body, []byte(c.BodyContains)) {
+		return fmt.Errorf("response body does not contain %q", c.BodyContains)
+	}
+	if c.BodyRegex != "" {
+		re := c.bodyRegex
+		if re == nil {
+			var err error
+			if re, err = regexp.Compile(c.BodyRegex); err != nil {
+				return fmt.Errorf("invalid body regex: %w", err)
+			}
+		}
+		if !re.Match(body) {
+			return fmt.Errorf("response body does not match %q", c.BodyRegex)
+		}
+	}
+	if c.JSONPath != "" {
+		if !gjson.ValidBytes(body) {
+			return fmt.Errorf("response body is not valid JSON")
+		}
+		result := gjson.GetBytes(body, c.JSONPath)
+		if !result.Exists() {
+			return fmt.Errorf("JSON path %s does not exist", c.JSONPath)
+		}
+		if c.JSONValue != "" && result.String() != c.JSONValue {
+			return fmt.Errorf("JSON path %s is %q, expected %q", c.JSONPath, result.String(), c.JSONValue)
+		}
+	}
+	return nil
+}
+
+var dnsRecordTypes = []string{"A", "AAAA", "CNAME", "MX", "NS", "TXT", "SRV", "CAA", "PTR", "SOA"}
+
+// DNSCheck DNS 解析监控，Target 为需要解析的域名
+type DNSCheck struct {
+	RecordType string   `json:"record_type,omitempty"` // 默认 A
+	Expected   []string `json:"expected,omitempty"`    // 解析结果需包含的记录，为空时只检查是否有解析结果
+	Resolver   string   `json:"resolver,omitempty"`    // 指定的 DNS 服务器 host[:port]，为空时使用系统配置
+}
+
+// DNSCheckTask 下发给 Agent 的 DNS 监控任务
+type DNSCheckTask struct {
+	Domain string `json:"domain"`
+	DNSCheck
+}
+
+// Normalize 校验 DNS 检查配置并填充默认值
+func (c *DNSCheck) Normalize() error {
+	c.RecordType = strings.ToUpper(strings.TrimSpace(c.RecordType))
+	if c.RecordType == "" {
+		c.RecordType = "A"
+	}
+	if !slices.Contains(dnsRecordTypes, c.RecordType) {
+		return fmt.Errorf("unsupported record type %s", c.RecordType)
+	}
+	if c.Resolver != "" {
+		if _, _, err := net.SplitHostPort(c.Resolver); err != nil {
+			c.Resolver = net.JoinHostPort(strings.Trim(c.Resolver, "[]"), "53")
+		}
+	}
+	return nil
+}
+
+// Assert 检查解析结果是否包含期望的记录，比较时忽略大小写与末尾的点
+func (c *DNSCheck) Assert(answers []string) error {
+	if len(answers) == 0 {
+		return fmt.Errorf("no %s records", c.RecordType)
+	}
+	normalize := func(s string) string {
+		return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), ".")
+	}
+	got := make([]string, len(answers))
+	for i, a := range answers {
+		got[i] = normalize(a)
+	}
+	for _, e := range c.Expected {
+		if !slices.Contains(got, normalize(e)) {
+			return fmt.Errorf("%s records %v do not contain %s", c.RecordType, answers, e)
+		}
+	}
+	return nil
+}
+
+// UDPCheck UDP 探测，Target 为 host:port
+type UDPCheck struct {
+	Payload  string `json:"payload,omitempty"`  // 发送的内容
+	Expected string `json:"expected,omitempty"` // 响应需包含的内容，为空时收到任意响应即视为成功
+	Hex      bool   `json:"hex,omitempty"`      // Payload 与 Expected 为十六进制编码
+}
+
+// UDPCheckTask 下发给 Agent 的 UDP 监控任务
+type UDPCheckTask struct {
+	Address string `json:"address"`
+	UDPCheck
+}
+
+// Normalize 校验 UDP 检查配置
+func (c *UDPCheck) Normalize() error {
+	if _, err := c.PayloadBytes(); err != nil {
+		return err
+	}
+	if _, err := c.ExpectedBytes(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *UDPCheck) PayloadBytes() ([]byte, error) {
+	return c.decode("payload", c.Payload)
+}
+
+func (c *UDPCheck) ExpectedBytes() ([]byte, error) {
+	return c.decode("expected", c.Expected)
+}
+
+func (c *UDPCheck) decode(name, s string) ([]byte, error) {
+	if !c.Hex {
+		return []byte(s), nil
+	}
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex %s: %w", name, err)
+	}
+	return b, nil
+}
+
+// Assert 检查响应是否包含期望的内容
+func (c *UDPCheck) Assert(resp []byte) error {
+	expected, err := c.ExpectedBytes()
+	if err != nil {
+		return err
+	}
+	if len(expected) > 0 && !bytes.Contains(resp, expected) {
+		return fmt.Errorf("response does not contain %q", c.Expected)
+	}
+	return nil
+}
+
+// TLSCheck TLS 握手监控，用于 SMTP、IMAPS、gRPC 等非 HTTP 的 TLS 端口，Target 为 host:port。
+// 与 HTTP 监控相同，成功时上报 "颁发者|过期时间" 以复用证书过期提醒
+type TLSCheck struct {
+	ServerName string   `json:"server_name,omitempty"` // SNI，默认为 Target 中的主机名
+	ALPN       []string `json:"alpn,omitempty"`        // 如 h2
+	SkipVerify bool     `json:"skip_verify,omitempty"` // 不校验证书链，只检查握手是否成功
+}
+
+// TLSCheckTask 下发给 Agent 的 TLS 监控任务
+type TLSCheckTask struct {
+	Address string `json:"address"`
+	TLSCheck
+}
+
+// Normalize 校验 TLS 检查配置
+func (c *TLSCheck) Normalize() error {
+	c.ServerName = strings.TrimSpace(c.ServerName)
+	for _, p := range c.ALPN {
+		if p == "" {
+			return fmt.Errorf("empty ALPN protocol")
+		}
+	}
+	return nil
+}
+
+// ServerNameFor 返回握手使用的 SNI
+func (c *TLSCheck) ServerNameFor(address string) string {
+	if c.ServerName != "" {
+		return c.ServerName
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
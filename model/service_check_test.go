@@ -0,0 +1,193 @@
+package model
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+func TestHTTPCheckNormalize(t *testing.T) {
+	cases := []struct {
+		name    string
+		check   HTTPCheck
+		wantErr bool
+	}{
+		{"defaults", HTTPCheck{}, false},
+		{"single status", HTTPCheck{StatusMin: 204}, false},
+		{"bad method", HTTPCheck{Method: "TRACE"}, true},
+		{"bad status range", HTTPCheck{StatusMin: 300, StatusMax: 200}, true},
+		{"bad regex", HTTPCheck{BodyRegex: "("}, true},
+		{"value without path", HTTPCheck{JSONValue: "ok"}, true},
+		{"bad redirect", HTTPCheck{Redirect: "sometimes"}, true},
+		{"too large", HTTPCheck{MaxResponseSize: HTTPCheckMaxResponseSize + 1}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.check.Normalize(); (err != nil) != c.wantErr {
+				t.Fatalf("Normalize() error = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+
+	c := HTTPCheck{StatusMin: 204}
+	_ = c.Normalize()
+	if c.Method != http.MethodGet || c.StatusMax != 204 || c.Redirect != HTTPCheckRedirectFollow ||
+		c.MaxResponseSize != HTTPCheckDefaultMaxResponseSize || c.MaxRedirects != HTTPCheckDefaultMaxRedirects {
+		t.Fatalf("unexpected defaults: %+v", c)
+	}
+}
+
+func TestHTTPCheckAssert(t *testing.T) {
+	header := http.Header{"Content-Type": []string{"application/json; charset=utf-8"}}
+	body := []byte(`{"status":"ok","version":{"major":2}}`)
+
+	cases := []struct {
+		name    string
+		check   HTTPCheck
+		status  int
+		wantErr string
+	}{
+		{"pass", HTTPCheck{BodyContains: `"ok"`, BodyRegex: `major":\d`, JSONPath: "version.major", JSONValue: "2",
+			ExpectHeaders: map[string]string{"Content-Type": "application/json"}}, 200, ""},
+		{"status", HTTPCheck{}, 503, "unexpected status code 503"},
+		{"contains", HTTPCheck{BodyContains: "healthy"}, 200, "does not contain"},
+		{"regex", HTTPCheck{BodyRegex: `^<html>`}, 200, "does not match"},
+		{"json missing", HTTPCheck{JSONPath: "checks.db"}, 200, "does not exist"},
+		{"json value", HTTPCheck{JSONPath: "status", JSONValue: "degraded"}, 200, `is "ok", expected "degraded"`},
+		{"header", HTTPCheck{ExpectHeaders: map[string]string{"Cache-Control": "no-cache"}}, 200, "header Cache-Control"},
+		{"size", HTTPCheck{MaxResponseSize: 10}, 200, "larger than 10 bytes"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.check.Normalize(); err != nil {
+				t.Fatal(err)
+			}
+			err := c.check.Assert(c.status, header, body)
+			if c.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Assert() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Fatalf("Assert() error = %v, want %q", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestServicePBWithHTTPCheck(t *testing.T) {
+	s := Service{Type: TaskTypeHTTPGet, Target: "https://example.com/health"}
+	if s.PB().Data != s.Target {
+		t.Fatalf("unexpected task data: %s", s.PB().Data)
+	}
+
+	if s.RequiresServiceCheck() {
+		t.Fatal("plain http service should be sent to every agent")
+	}
+
+	s.HTTPCheck = &HTTPCheck{Method: http.MethodPost, Body: "ping", StatusMin: 200, StatusMax: 204}
+	if s.PB().Data != s.Target {
+		t.Fatalf("agent task data should stay a plain URL: %s", s.PB().Data)
+	}
+	if !s.RequiresServiceCheck() {
+		t.Fatal("http service with checks should require check support")
+	}
+	var task HTTPCheckTask
+	if err := json.Unmarshal([]byte(s.CheckPB().Data), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.URL != s.Target || task.Method != http.MethodPost || task.Body != "ping" || task.StatusMax != 204 {
+		t.Fatalf("unexpected task: %+v", task)
+	}
+}
+
+func TestServiceValidateCheck(t *testing.T) {
+	cases := []struct {
+		name    string
+		service Service
+		wantErr bool
+	}{
+		{"icmp", Service{Type: TaskTypeICMPPing, Target: "1.1.1.1"}, false},
+		{"http check on tcp", Service{Type: TaskTypeTCPPing, Target: "1.1.1.1:80", HTTPCheck: &HTTPCheck{}}, true},
+		{"dns default", Service{Type: TaskTypeDNS, Target: "example.com"}, false},
+		{"dns bad domain", Service{Type: TaskTypeDNS, Target: "https://example.com"}, true},
+		{"dns bad record", Service{Type: TaskTypeDNS, Target: "example.com", DNSCheck: &DNSCheck{RecordType: "ANY"}}, true},
+		{"udp", Service{Type: TaskTypeUDP, Target: "192.0.2.1:53", UDPCheck: &UDPCheck{Payload: "00 01", Hex: true}}, false},
+		{"udp bad hex", Service{Type: TaskTypeUDP, Target: "192.0.2.1:53", UDPCheck: &UDPCheck{Payload: "zz", Hex: true}}, true},
+		{"udp missing port", Service{Type: TaskTypeUDP, Target: "192.0.2.1"}, true},
+		{"tls", Service{Type: TaskTypeTLS, Target: "smtp.example.com:465"}, false},
+		{"tls with udp check", Service{Type: TaskTypeTLS, Target: "smtp.example.com:465", UDPCheck: &UDPCheck{}}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.service.ValidateCheck(); (err != nil) != c.wantErr {
+				t.Fatalf("ValidateCheck() error = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestServiceCheckAssert(t *testing.T) {
+	dns := DNSCheck{RecordType: "CNAME", Expected: []string{"Target.example.com"}}
+	if err := dns.Assert([]string{"target.example.com."}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dns.Assert([]string{"other.example.com."}); err == nil {
+		t.Fatal("expected dns assertion to fail")
+	}
+	if err := dns.Assert(nil); err == nil {
+		t.Fatal("expected dns assertion to fail without answers")
+	}
+
+	udp := UDPCheck{Expected: "6f6b", Hex: true}
+	if err := udp.Assert([]byte("status: ok")); err != nil {
+		t.Fatal(err)
+	}
+	if err := udp.Assert([]byte("fail")); err == nil {
+		t.Fatal("expected udp assertion to fail")
+	}
+
+	tlsCheck := TLSCheck{}
+	if got := tlsCheck.ServerNameFor("imap.example.com:993"); got != "imap.example.com" {
+		t.Fatalf("unexpected server name %s", got)
+	}
+
+	dnsService := Service{Type: TaskTypeDNS, Target: "example.com"}
+	if !dnsService.RequiresServiceCheck() {
+		t.Fatal("dns service should require check support")
+	}
+	var task DNSCheckTask
+	if err := json.Unmarshal([]byte(dnsService.CheckPB().Data), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.Domain != "example.com" {
+		t.Fatalf("unexpected task: %+v", task)
+	}
+}
+
+func TestServiceQuorumReached(t *testing.T) {
+	cases := []struct {
+		quorum          uint64
+		failing, active int
+		want            bool
+	}{
+		{0, 3, 3, false},
+		{2, 1, 5, false},
+		{2, 2, 5, true},
+		{3, 1, 1, true},
+		{3, 0, 0, false},
+	}
+
+	for _, c := range cases {
+		s := Service{Quorum: c.quorum}
+		if got := s.QuorumReached(c.failing, c.active); got != c.want {
+			t.Errorf("QuorumReached(%d, %d) with quorum %d = %v, want %v", c.failing, c.active, c.quorum, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// ServiceDailyStat 服务监控每日的汇总数据，由 server_id = 0 的监控记录生成，保留时长独立于监控记录
+type ServiceDailyStat struct {
+	ID        uint64    `gorm:"primaryKey" json:"-"`
+	CreatedAt time.Time `gorm:"<-:create" json:"-"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"-"`
+	ServiceID uint64    `gorm:"uniqueIndex:idx_service_daily_stat_service_id_date" json:"service_id"`
+	Date      string    `gorm:"uniqueIndex:idx_service_daily_stat_service_id_date;size:10" json:"date"` // 面板时区的日期，YYYY-MM-DD
+	Up        uint64    `json:"up"`
+	Down      uint64    `json:"down"`
+	AvgDelay  float32   `json:"avg_delay"` // 平均延迟，毫秒，按检查成功次数加权
+	P95Delay  float32   `json:"p95_delay"` // 各汇总记录平均延迟的 95 分位数，毫秒
+}
+
+// NewServiceDailyStat 将同一服务在同一天的监控记录汇总为每日数据
+func NewServiceDailyStat(serviceID uint64, date string, histories []ServiceHistory) ServiceDailyStat {
+	stat := ServiceDailyStat{ServiceID: serviceID, Date: date}
+
+	var totalDelay float64
+	delays := make([]float32, 0, len(histories))
+	for _, h := range histories {
+		stat.Up += h.Up
+		stat.Down += h.Down
+		// 全部失败的记录没有有效延迟
+		if h.Up == 0 {
+			continue
+		}
+		totalDelay += float64(h.AvgDelay) * float64(h.Up)
+		delays = append(delays, h.AvgDelay)
+	}
+	if stat.Up > 0 {
+		stat.AvgDelay = float32(totalDelay / float64(stat.Up))
+	}
+	stat.P95Delay = percentile(delays, 95)
+	return stat
+}
+
+// percentile 按最近秩法计算分位数
+func percentile(values []float32, p float64) float32 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// ServiceStats 服务监控在指定日期范围内的可用性
+type ServiceStats struct {
+	ServiceID   uint64             `json:"service_id"`
+	ServiceName string             `json:"service_name"`
+	Up          uint64             `json:"up"`
+	Down        uint64             `json:"down"`
+	Uptime      *float64           `json:"uptime,omitempty"` // 可用率（百分比），没有数据时为空
+	AvgDelay    float32            `json:"avg_delay"`
+	Daily       []ServiceDailyStat `json:"daily"` // 有数据的日期，按日期升序
+}
+
+// NewServiceStats 汇总每日数据，daily 需按日期升序排列
+func NewServiceStats(serviceID uint64, name string, daily []ServiceDailyStat) *ServiceStats {
+	s := &ServiceStats{ServiceID: serviceID, ServiceName: name, Daily: daily}
+	if s.Daily == nil {
+		s.Daily = make([]ServiceDailyStat, 0)
+	}
+
+	var totalDelay float64
+	for _, d := range daily {
+		s.Up += d.Up
+		s.Down += d.Down
+		totalDelay += float64(d.AvgDelay) * float64(d.Up)
+	}
+	if s.Up+s.Down > 0 {
+		uptime := float64(s.Up) * 100 / float64(s.Up+s.Down)
+		s.Uptime = &uptime
+	}
+	if s.Up > 0 {
+		s.AvgDelay = float32(totalDelay / float64(s.Up))
+	}
+	return s
+}
@@ -0,0 +1,75 @@
+package model
+
+import "testing"
+
+func TestNewServiceDailyStat(t *testing.T) {
+	histories := []ServiceHistory{
+		{Up: 10, Down: 0, AvgDelay: 10},
+		{Up: 30, Down: 0, AvgDelay: 20},
+		{Up: 0, Down: 5, AvgDelay: 0},
+		{Up: 10, Down: 5, AvgDelay: 100},
+	}
+
+	stat := NewServiceDailyStat(1, "2024-01-01", histories)
+	if stat.ServiceID != 1 || stat.Date != "2024-01-01" {
+		t.Fatalf("unexpected key: %d %s", stat.ServiceID, stat.Date)
+	}
+	if stat.Up != 50 || stat.Down != 10 {
+		t.Fatalf("expected 50/10, got %d/%d", stat.Up, stat.Down)
+	}
+	// (10*10 + 30*20 + 10*100) / 50
+	if stat.AvgDelay != 34 {
+		t.Fatalf("expected avg delay 34, got %v", stat.AvgDelay)
+	}
+	if stat.P95Delay != 100 {
+		t.Fatalf("expected p95 delay 100, got %v", stat.P95Delay)
+	}
+
+	empty := NewServiceDailyStat(1, "2024-01-01", []ServiceHistory{{Down: 3}})
+	if empty.AvgDelay != 0 || empty.P95Delay != 0 || empty.Down != 3 {
+		t.Fatalf("unexpected stat for failed checks: %+v", empty)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := make([]float32, 0, 100)
+	for i := 100; i > 0; i-- {
+		values = append(values, float32(i))
+	}
+
+	cases := []struct {
+		p   float64
+		exp float32
+	}{
+		{p: 95, exp: 95},
+		{p: 50, exp: 50},
+		{p: 100, exp: 100},
+		{p: 0, exp: 1},
+	}
+	for _, c := range cases {
+		if got := percentile(values, c.p); got != c.exp {
+			t.Errorf("p%v: expected %v, got %v", c.p, c.exp, got)
+		}
+	}
+	if values[0] != 100 {
+		t.Fatal("percentile should not modify the input")
+	}
+}
+
+func TestNewServiceStats(t *testing.T) {
+	s := NewServiceStats(1, "web", nil)
+	if s.Uptime != nil || s.Daily == nil {
+		t.Fatalf("unexpected stats without data: %+v", s)
+	}
+
+	s = NewServiceStats(1, "web", []ServiceDailyStat{
+		{Date: "2024-01-01", Up: 90, Down: 10, AvgDelay: 10},
+		{Date: "2024-01-02", Up: 10, Down: 90, AvgDelay: 100},
+	})
+	if s.Up != 100 || s.Down != 100 || s.Uptime == nil || *s.Uptime != 50 {
+		t.Fatalf("unexpected uptime: %+v", s)
+	}
+	if s.AvgDelay != 19 {
+		t.Fatalf("expected avg delay 19, got %v", s.AvgDelay)
+	}
+}
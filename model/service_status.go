@@ -0,0 +1,79 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	ServiceDefaultWindowSamples     = 30
+	ServiceMaxWindowSamples         = 1440
+	ServiceDefaultGoodThreshold     = 95
+	ServiceDefaultDegradedThreshold = 80
+	ServiceMinSampleInterval        = 30 * time.Second // 当前状态最多每 30 秒记录一个样本
+)
+
+// SampleInterval 当前状态相邻样本的间隔，为检查间隔与最小样本间隔中的较大值
+func (m *Service) SampleInterval() time.Duration {
+	return max(ServiceMinSampleInterval, time.Duration(m.Duration)*time.Second)
+}
+
+// SampleGate 记录样本后至少间隔多久才记录下一个样本，预留半个检查间隔以容忍上报时间的抖动
+func (m *Service) SampleGate() time.Duration {
+	return m.SampleInterval() - time.Duration(m.Duration)*time.Second/2
+}
+
+// StatusWindowSize 统计当前状态的样本数，设置了 WindowDuration 时按样本间隔换算
+func (m *Service) StatusWindowSize() int {
+	size := uint64(ServiceDefaultWindowSamples)
+	switch {
+	case m.WindowDuration > 0:
+		interval := uint64(m.SampleInterval() / time.Second)
+		size = max(1, (m.WindowDuration+interval-1)/interval)
+	case m.WindowSamples > 0:
+		size = m.WindowSamples
+	}
+	return int(min(size, ServiceMaxWindowSamples))
+}
+
+// StatusWindow 统计当前状态覆盖的时长
+func (m *Service) StatusWindow() time.Duration {
+	return time.Duration(m.StatusWindowSize()) * m.SampleInterval()
+}
+
+// StatusThresholds 判定正常与低可用的可用率阈值
+func (m *Service) StatusThresholds() (good, degraded float32) {
+	good, degraded = ServiceDefaultGoodThreshold, ServiceDefaultDegradedThreshold
+	if m.GoodThreshold > 0 {
+		good = m.GoodThreshold
+	}
+	if m.DegradedThreshold > 0 {
+		degraded = m.DegradedThreshold
+	}
+	return
+}
+
+// DownFailureCount 判定为故障前需要连续失败的次数
+func (m *Service) DownFailureCount() uint64 {
+	return max(1, m.FailureThreshold)
+}
+
+// ValidateStatusSettings 校验服务状态的判定参数
+func (m *Service) ValidateStatusSettings() error {
+	if m.WindowSamples > 0 && m.WindowDuration > 0 {
+		return fmt.Errorf("window_samples and window_duration cannot be set at the same time")
+	}
+	if m.WindowSamples > ServiceMaxWindowSamples {
+		return fmt.Errorf("window_samples must not exceed %d", ServiceMaxWindowSamples)
+	}
+	if m.WindowDuration > 0 && m.WindowDuration < m.Duration {
+		return fmt.Errorf("window_duration must not be shorter than the check interval %ds", m.Duration)
+	}
+	if m.GoodThreshold < 0 || m.GoodThreshold > 100 || m.DegradedThreshold < 0 || m.DegradedThreshold > 100 {
+		return fmt.Errorf("thresholds must be between 0 and 100")
+	}
+	if good, degraded := m.StatusThresholds(); degraded > good {
+		return fmt.Errorf("degraded_threshold %.2f is higher than good_threshold %.2f", degraded, good)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServiceStatusWindow(t *testing.T) {
+	cases := []struct {
+		name     string
+		service  Service
+		size     int
+		interval time.Duration
+	}{
+		{"default", Service{Duration: 30}, 30, 30 * time.Second},
+		{"fast check", Service{Duration: 5}, 30, 30 * time.Second},
+		{"samples", Service{Duration: 60, WindowSamples: 10}, 10, time.Minute},
+		{"duration", Service{Duration: 600, WindowDuration: 3600}, 6, 10 * time.Minute},
+		{"duration rounds up", Service{Duration: 60, WindowDuration: 90}, 2, time.Minute},
+		{"capped", Service{Duration: 30, WindowSamples: 100000}, ServiceMaxWindowSamples, 30 * time.Second},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.service.StatusWindowSize(); got != c.size {
+				t.Errorf("StatusWindowSize() = %d, want %d", got, c.size)
+			}
+			if got := c.service.SampleInterval(); got != c.interval {
+				t.Errorf("SampleInterval() = %s, want %s", got, c.interval)
+			}
+		})
+	}
+}
+
+func TestServiceSampleGate(t *testing.T) {
+	cases := []struct {
+		duration uint64
+		jitter   time.Duration
+		samples  int // 10 分钟内记录的样本数
+	}{
+		{30, time.Second, 20},
+		{60, 2 * time.Second, 10},
+		{300, 10 * time.Second, 2},
+		{10, 0, 20},
+	}
+
+	for _, c := range cases {
+		s := Service{Duration: c.duration}
+		start := time.Unix(0, 0)
+		var next time.Time
+		var samples int
+		// 上报时间在检查间隔附近交替提前或延后
+		for i := 0; ; i++ {
+			offset := c.jitter
+			if i%2 == 1 {
+				offset = -c.jitter
+			}
+			now := start.Add(time.Duration(i)*time.Duration(c.duration)*time.Second + offset)
+			if now.Sub(start) >= 10*time.Minute {
+				break
+			}
+			if next.IsZero() || next.Before(now) {
+				next = now.Add(s.SampleGate())
+				samples++
+			}
+		}
+		if samples != c.samples {
+			t.Errorf("duration %ds jitter %s: recorded %d samples, want %d", c.duration, c.jitter, samples, c.samples)
+		}
+	}
+}
+
+func TestServiceValidateStatusSettings(t *testing.T) {
+	cases := []struct {
+		name    string
+		service Service
+		wantErr bool
+	}{
+		{"default", Service{Duration: 30}, false},
+		{"both windows", Service{Duration: 30, WindowSamples: 10, WindowDuration: 600}, true},
+		{"window shorter than interval", Service{Duration: 600, WindowDuration: 60}, true},
+		{"thresholds", Service{Duration: 30, GoodThreshold: 99.5, DegradedThreshold: 90}, false},
+		{"degraded above default good", Service{Duration: 30, DegradedThreshold: 98}, true},
+		{"out of range", Service{Duration: 30, GoodThreshold: 101}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.service.ValidateStatusSettings(); (err != nil) != c.wantErr {
+				t.Fatalf("ValidateStatusSettings() error = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package model
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+)
+
+const (
+	StatusPageIncidentTypeIncident uint8 = iota
+	StatusPageIncidentTypeMaintenance
+)
+
+// 事件公告的状态，事件与维护公告分别使用各自的状态
+const (
+	StatusPageIncidentInvestigating = "investigating"
+	StatusPageIncidentIdentified    = "identified"
+	StatusPageIncidentMonitoring    = "monitoring"
+	StatusPageIncidentResolved      = "resolved"
+
+	StatusPageMaintenanceScheduled  = "scheduled"
+	StatusPageMaintenanceInProgress = "in_progress"
+	StatusPageMaintenanceCompleted  = "completed"
+)
+
+var statusPageSlugRegexp = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// StatusPage 对外公开的状态页，将服务监控分组为组件展示可用性与事件公告
+type StatusPage struct {
+	Common
+	Name          string                `json:"name"`
+	Slug          string                `gorm:"uniqueIndex" json:"slug"` // 访问路径，只能包含小写字母、数字与连字符
+	Description   string                `json:"description,omitempty"`
+	EnableFeed    bool                  `json:"enable_feed,omitempty"` // 提供事件公告的 Atom 订阅
+	ComponentsRaw string                `gorm:"type:longtext" json:"-"`
+	Components    []StatusPageComponent `gorm:"-" json:"components"`
+}
+
+// StatusPageComponent 状态页中的组件，由一个或多个服务监控组成
+type StatusPageComponent struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Services    []uint64 `json:"services"`
+}
+
+func (p *StatusPage) BeforeSave(tx *gorm.DB) error {
+	if data, err := json.Marshal(p.Components); err != nil {
+		return err
+	} else {
+		p.ComponentsRaw = string(data)
+	}
+	return nil
+}
+
+func (p *StatusPage) AfterFind(tx *gorm.DB) error {
+	if p.ComponentsRaw == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(p.ComponentsRaw), &p.Components)
+}
+
+// Validate 校验状态页的访问路径与组件
+func (p *StatusPage) Validate() error {
+	if !statusPageSlugRegexp.MatchString(p.Slug) {
+		return fmt.Errorf("invalid slug %q", p.Slug)
+	}
+	names := make(map[string]bool, len(p.Components))
+	for _, c := range p.Components {
+		if c.Name == "" {
+			return fmt.Errorf("component name is empty")
+		}
+		if names[c.Name] {
+			return fmt.Errorf("duplicate component %s", c.Name)
+		}
+		names[c.Name] = true
+		if len(c.Services) == 0 {
+			return fmt.Errorf("component %s has no services", c.Name)
+		}
+	}
+	return nil
+}
+
+// ServiceIDs 状态页引用的所有服务监控
+func (p *StatusPage) ServiceIDs() []uint64 {
+	var ids []uint64
+	for _, c := range p.Components {
+		for _, id := range c.Services {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// StatusPageIncident 管理员发布的事件或维护公告
+type StatusPageIncident struct {
+	Common
+	StatusPageID  uint64     `gorm:"index" json:"status_page_id"`
+	Type          uint8      `json:"type"` // 0: 事件 1: 维护
+	Title         string     `json:"title"`
+	Status        string     `json:"status"` // 最近一次更新的状态
+	ComponentsRaw string     `gorm:"type:longtext" json:"-"`
+	Components    []string   `gorm:"-" json:"components"` // 受影响的组件名称
+	StartedAt     time.Time  `gorm:"index" json:"started_at"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+
+	Updates []StatusPageIncidentUpdate `gorm:"-" json:"updates,omitempty"`
+}
+
+// StatusPageIncidentUpdate 公告的一次进展更新
+type StatusPageIncidentUpdate struct {
+	Common
+	IncidentID uint64 `gorm:"index" json:"incident_id"`
+	Status     string `json:"status"`
+	Content    string `json:"content"`
+}
+
+func (i *StatusPageIncident) BeforeSave(tx *gorm.DB) error {
+	if data, err := json.Marshal(i.Components); err != nil {
+		return err
+	} else {
+		i.ComponentsRaw = string(data)
+	}
+	return nil
+}
+
+func (i *StatusPageIncident) AfterFind(tx *gorm.DB) error {
+	if i.ComponentsRaw == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(i.ComponentsRaw), &i.Components)
+}
+
+func (i *StatusPageIncident) Resolved() bool {
+	return i.ResolvedAt != nil
+}
+
+// ValidStatus 判断状态是否适用于该类型的公告
+func (i *StatusPageIncident) ValidStatus(status string) bool {
+	switch i.Type {
+	case StatusPageIncidentTypeIncident:
+		return slices.Contains([]string{StatusPageIncidentInvestigating, StatusPageIncidentIdentified,
+			StatusPageIncidentMonitoring, StatusPageIncidentResolved}, status)
+	case StatusPageIncidentTypeMaintenance:
+		return slices.Contains([]string{StatusPageMaintenanceScheduled, StatusPageMaintenanceInProgress,
+			StatusPageMaintenanceCompleted}, status)
+	}
+	return false
+}
+
+// IsFinalStatus 判断状态是否表示公告已结束
+func IsFinalStatus(status string) bool {
+	return status == StatusPageIncidentResolved || status == StatusPageMaintenanceCompleted
+}
+
+// AtomFeed 生成事件公告的 Atom 订阅，incidents 需按时间倒序排列并包含更新记录
+func (p *StatusPage) AtomFeed(link string, incidents []*StatusPageIncident) ([]byte, error) {
+	feed := atomFeed{
+		XMLNS:    "http://www.w3.org/2005/Atom",
+		ID:       link,
+		Title:    p.Name,
+		Subtitle: p.Description,
+		Link:     []atomLink{{Href: link, Rel: "self"}},
+	}
+	latest := p.UpdatedAt
+	for _, i := range incidents {
+		updated := i.UpdatedAt
+		var content strings.Builder
+		for _, u := range i.Updates {
+			if u.CreatedAt.After(updated) {
+				updated = u.CreatedAt
+			}
+			fmt.Fprintf(&content, "[%s] %s %s\n", u.CreatedAt.UTC().Format(time.DateTime), u.Status, u.Content)
+		}
+		if updated.After(latest) {
+			latest = updated
+		}
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:        fmt.Sprintf("%s#incident-%d", link, i.ID),
+			Title:     fmt.Sprintf("[%s] %s", i.Status, i.Title),
+			Published: i.StartedAt.UTC().Format(time.RFC3339),
+			Updated:   updated.UTC().Format(time.RFC3339),
+			Content:   atomContent{Type: "text", Body: strings.TrimSpace(content.String())},
+		})
+	}
+
+	feed.Updated = latest.UTC().Format(time.RFC3339)
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	XMLNS    string      `xml:"xmlns,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Link     []atomLink  `xml:"link"`
+	Updated  string      `xml:"updated"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+type StatusPageForm struct {
+	Name        string                `json:"name" minLength:"1"`
+	Slug        string                `json:"slug" minLength:"1"`
+	Description string                `json:"description,omitempty" validate:"optional"`
+	EnableFeed  bool                  `json:"enable_feed,omitempty" validate:"optional"`
+	Components  []StatusPageComponent `json:"components"`
+}
+
+type StatusPageIncidentForm struct {
+	Type       uint8     `json:"type,omitempty" validate:"optional"` // 0: 事件 1: 维护
+	Title      string    `json:"title" minLength:"1"`
+	Status     string    `json:"status"`
+	Content    string    `json:"content" minLength:"1"`
+	Components []string  `json:"components,omitempty" validate:"optional"`
+	StartedAt  time.Time `json:"started_at,omitempty" validate:"optional"` // 为空时为当前时间，维护公告可以设置为将来的时间
+}
+
+type StatusPageIncidentUpdateForm struct {
+	Status  string `json:"status"`
+	Content string `json:"content" minLength:"1"`
+}
+
+// StatusPageResponse 状态页的公开数据
+type StatusPageResponse struct {
+	Name        string                      `json:"name"`
+	Description string                      `json:"description,omitempty"`
+	Status      uint8                       `json:"status"` // 所有组件中最差的状态
+	Components  []StatusPageComponentStatus `json:"components"`
+	Incidents   []*StatusPageIncident       `json:"incidents"` // 未结束及最近结束的公告
+	GeneratedAt time.Time                   `json:"generated_at"`
+}
+
+// StatusPageComponentStatus 组件的当前状态与可用性
+type StatusPageComponentStatus struct {
+	Name          string                    `json:"name"`
+	Description   string                    `json:"description,omitempty"`
+	Status        uint8                     `json:"status"` // 1: 无数据 2: 正常 3: 低可用 4: 故障
+	InMaintenance bool                      `json:"in_maintenance,omitempty"`
+	Uptime        StatusPageUptime          `json:"uptime"`
+	Daily         []StatusPageDailyUptime   `json:"daily"` // 最近 90 天每日的检查次数，按日期升序
+	Services      []StatusPageServiceStatus `json:"services"`
+}
+
+// StatusPageServiceStatus 组件内单个服务监控的当前状态与可用性
+type StatusPageServiceStatus struct {
+	Name          string           `json:"name"`
+	Status        uint8            `json:"status"`
+	InMaintenance bool             `json:"in_maintenance,omitempty"`
+	Uptime        StatusPageUptime `json:"uptime"`
+}
+
+// StatusPageUptime 不同时间范围内的可用率（百分比），没有数据时为空
+type StatusPageUptime struct {
+	Day7  *float64 `json:"7d,omitempty"`
+	Day30 *float64 `json:"30d,omitempty"`
+	Day90 *float64 `json:"90d,omitempty"`
+}
+
+type StatusPageDailyUptime struct {
+	Date string `json:"date"` // 面板时区的日期，YYYY-MM-DD
+	Up   uint64 `json:"up"`
+	Down uint64 `json:"down"`
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusPageValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		page    StatusPage
+		wantErr bool
+	}{
+		{"valid", StatusPage{Slug: "public-status", Components: []StatusPageComponent{{Name: "API", Services: []uint64{1}}}}, false},
+		{"bad slug", StatusPage{Slug: "Public Status"}, true},
+		{"trailing hyphen", StatusPage{Slug: "status-"}, true},
+		{"duplicate component", StatusPage{Slug: "status", Components: []StatusPageComponent{
+			{Name: "API", Services: []uint64{1}}, {Name: "API", Services: []uint64{2}},
+		}}, true},
+		{"empty component", StatusPage{Slug: "status", Components: []StatusPageComponent{{Name: "API"}}}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.page.Validate(); (err != nil) != c.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+
+	page := StatusPage{Components: []StatusPageComponent{
+		{Name: "API", Services: []uint64{1, 2}}, {Name: "Web", Services: []uint64{2, 3}},
+	}}
+	if ids := page.ServiceIDs(); len(ids) != 3 {
+		t.Fatalf("expected 3 distinct services, got %v", ids)
+	}
+}
+
+func TestStatusPageIncidentStatus(t *testing.T) {
+	incident := StatusPageIncident{Type: StatusPageIncidentTypeIncident}
+	if !incident.ValidStatus(StatusPageIncidentInvestigating) || incident.ValidStatus(StatusPageMaintenanceScheduled) {
+		t.Fatal("unexpected incident status validation")
+	}
+	maintenance := StatusPageIncident{Type: StatusPageIncidentTypeMaintenance}
+	if !maintenance.ValidStatus(StatusPageMaintenanceInProgress) || maintenance.ValidStatus(StatusPageIncidentResolved) {
+		t.Fatal("unexpected maintenance status validation")
+	}
+	if !IsFinalStatus(StatusPageIncidentResolved) || !IsFinalStatus(StatusPageMaintenanceCompleted) || IsFinalStatus(StatusPageIncidentMonitoring) {
+		t.Fatal("unexpected final status")
+	}
+}
+
+func TestStatusPageAtomFeed(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	page := StatusPage{Name: "Example Status"}
+	page.UpdatedAt = created
+
+	incident := &StatusPageIncident{Title: "API outage", Status: StatusPageIncidentResolved, StartedAt: created.Add(time.Hour)}
+	incident.ID = 7
+	incident.UpdatedAt = created.Add(time.Hour)
+	incident.Updates = []StatusPageIncidentUpdate{
+		{Status: StatusPageIncidentResolved, Content: "Fixed"},
+		{Status: StatusPageIncidentInvestigating, Content: "Looking into it"},
+	}
+	incident.Updates[0].CreatedAt = created.Add(2 * time.Hour)
+	incident.Updates[1].CreatedAt = created.Add(time.Hour)
+
+	data, err := page.AtomFeed("https://example.com/api/v1/status-page/example/feed", []*StatusPageIncident{incident})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var feed atomFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("invalid feed: %v\n%s", err, data)
+	}
+	if feed.Updated != "2024-01-01T02:00:00Z" || len(feed.Entries) != 1 {
+		t.Fatalf("unexpected feed: %+v", feed)
+	}
+	entry := feed.Entries[0]
+	if entry.ID != "https://example.com/api/v1/status-page/example/feed#incident-7" ||
+		entry.Title != "[resolved] API outage" || entry.Updated != "2024-01-01T02:00:00Z" ||
+		!strings.Contains(entry.Content.Body, "Looking into it") {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+}
@@ -0,0 +1,527 @@
+// Package expr 实现报警规则使用的表达式语言。
+//
+// 表达式由数字、变量、算术运算（+ - * /）、比较运算（== != < <= > >=）
+// 与逻辑运算（&& || !，或 and or not）组成，最终结果必须为布尔值。
+// 数字可以带有容量单位（K、KB、KiB、M、MB、MiB …… P、PB、PiB）或百分号，
+// 例如 "memory > 90% && disk_free < 10 GiB"。
+package expr
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Error 表达式语法或类型错误
+type Error struct {
+	Pos int // 出错位置（从 1 开始的字符偏移）
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+// Expr 编译后的表达式
+type Expr struct {
+	src  string
+	root node
+	vars []string
+}
+
+// Compile 编译表达式，vars 为允许使用的变量名
+func Compile(src string, vars []string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, vars: make(map[string]bool, len(vars))}
+	for _, v := range vars {
+		p.vars[v] = true
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+	if root.kind() != kindBool {
+		return nil, &Error{Pos: 1, Msg: "expression must evaluate to a boolean"}
+	}
+
+	e := &Expr{src: src, root: root}
+	for v := range p.used {
+		e.vars = append(e.vars, v)
+	}
+	slices.Sort(e.vars)
+	return e, nil
+}
+
+// Eval 使用给定的变量值计算表达式，未提供的变量视为 0
+func (e *Expr) Eval(vars map[string]float64) bool {
+	return e.root.boolean(vars)
+}
+
+// Vars 返回表达式中引用的变量名
+func (e *Expr) Vars() []string {
+	return e.vars
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+type tokenKind uint8
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value float64
+	pos   int
+}
+
+var units = map[string]float64{
+	"k": 1e3, "kb": 1e3, "kib": 1 << 10,
+	"m": 1e6, "mb": 1e6, "mib": 1 << 20,
+	"g": 1e9, "gb": 1e9, "gib": 1 << 30,
+	"t": 1e12, "tb": 1e12, "tib": 1 << 40,
+	"p": 1e15, "pb": 1e15, "pib": 1 << 50,
+}
+
+var keywordOps = map[string]string{
+	"and": "&&",
+	"or":  "||",
+	"not": "!",
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r)
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	rs := []rune(src)
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(rs) && (unicode.IsDigit(rs[i]) || rs[i] == '.') {
+				i++
+			}
+			v, err := strconv.ParseFloat(string(rs[start:i]), 64)
+			if err != nil {
+				return nil, &Error{Pos: start + 1, Msg: fmt.Sprintf("invalid number %q", string(rs[start:i]))}
+			}
+
+			// 可选的单位，允许与数字之间有空格
+			j := i
+			for j < len(rs) && rs[j] == ' ' {
+				j++
+			}
+			if j < len(rs) && rs[j] == '%' {
+				i = j + 1
+			} else if j < len(rs) && isIdentStart(rs[j]) {
+				k := j
+				for k < len(rs) && isIdentPart(rs[k]) {
+					k++
+				}
+				if m, ok := units[strings.ToLower(string(rs[j:k]))]; ok {
+					v *= m
+					i = k
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(rs[start:i]), value: v, pos: start + 1})
+		case isIdentStart(r):
+			start := i
+			for i < len(rs) && isIdentPart(rs[i]) {
+				i++
+			}
+			text := string(rs[start:i])
+			if op, ok := keywordOps[strings.ToLower(text)]; ok {
+				tokens = append(tokens, token{kind: tokenOp, text: op, pos: start + 1})
+			} else {
+				tokens = append(tokens, token{kind: tokenIdent, text: text, pos: start + 1})
+			}
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i + 1})
+			i++
+		default:
+			var op string
+			if i+1 < len(rs) {
+				switch two := string(rs[i : i+2]); two {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					op = two
+				}
+			}
+			if op == "" {
+				switch r {
+				case '+', '-', '*', '/', '<', '>', '!':
+					op = string(r)
+				default:
+					return nil, &Error{Pos: i + 1, Msg: fmt.Sprintf("unexpected character %q", r)}
+				}
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i + 1})
+			i += len([]rune(op))
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, text: "end of expression", pos: len(rs) + 1}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	vars   map[string]bool
+	used   map[string]bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) acceptOp(ops ...string) (token, bool) {
+	t := p.peek()
+	if t.kind != tokenOp {
+		return t, false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return p.next(), true
+		}
+	}
+	return t, false
+}
+
+func expect(t token, n node, k kind) error {
+	if n.kind() != k {
+		return &Error{Pos: t.pos, Msg: fmt.Sprintf("operator %q requires %s operands", t.text, k)}
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.acceptOp("||")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if err := expect(t, left, kindBool); err != nil {
+			return nil, err
+		}
+		if err := expect(t, right, kindBool); err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.acceptOp("&&")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if err := expect(t, left, kindBool); err != nil {
+			return nil, err
+		}
+		if err := expect(t, right, kindBool); err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	if t, ok := p.acceptOp("!"); ok {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if err := expect(t, x, kindBool); err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: t.text, x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := p.acceptOp("==", "!=", "<", "<=", ">", ">=")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if err := expect(t, left, kindNumber); err != nil {
+		return nil, err
+	}
+	if err := expect(t, right, kindNumber); err != nil {
+		return nil, err
+	}
+	if t2, ok := p.acceptOp("==", "!=", "<", "<=", ">", ">="); ok {
+		return nil, &Error{Pos: t2.pos, Msg: "comparisons cannot be chained, use && instead"}
+	}
+	return &binaryNode{op: t.text, left: left, right: right}, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.acceptOp("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		if err := expect(t, left, kindNumber); err != nil {
+			return nil, err
+		}
+		if err := expect(t, right, kindNumber); err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.acceptOp("*", "/")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := expect(t, left, kindNumber); err != nil {
+			return nil, err
+		}
+		if err := expect(t, right, kindNumber); err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if t, ok := p.acceptOp("-", "+"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := expect(t, x, kindNumber); err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: t.text, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		return numberNode(t.value), nil
+	case tokenIdent:
+		if !p.vars[t.text] {
+			return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unknown variable %q", t.text)}
+		}
+		if p.used == nil {
+			p.used = make(map[string]bool)
+		}
+		p.used[t.text] = true
+		return varNode(t.text), nil
+	case tokenLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokenRParen {
+			return nil, &Error{Pos: r.pos, Msg: fmt.Sprintf("expected \")\" but got %q", r.text)}
+		}
+		return x, nil
+	case tokenEOF:
+		return nil, &Error{Pos: t.pos, Msg: "unexpected end of expression"}
+	default:
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+}
+
+type kind uint8
+
+const (
+	kindNumber kind = iota
+	kindBool
+)
+
+func (k kind) String() string {
+	if k == kindBool {
+		return "boolean"
+	}
+	return "numeric"
+}
+
+type node interface {
+	kind() kind
+	number(vars map[string]float64) float64
+	boolean(vars map[string]float64) bool
+}
+
+type numberNode float64
+
+func (n numberNode) kind() kind                        { return kindNumber }
+func (n numberNode) number(map[string]float64) float64 { return float64(n) }
+func (n numberNode) boolean(map[string]float64) bool   { return n != 0 }
+
+type varNode string
+
+func (n varNode) kind() kind                             { return kindNumber }
+func (n varNode) number(vars map[string]float64) float64 { return vars[string(n)] }
+func (n varNode) boolean(vars map[string]float64) bool   { return vars[string(n)] != 0 }
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n *unaryNode) kind() kind {
+	if n.op == "!" {
+		return kindBool
+	}
+	return kindNumber
+}
+
+func (n *unaryNode) number(vars map[string]float64) float64 {
+	if n.op == "-" {
+		return -n.x.number(vars)
+	}
+	return n.x.number(vars)
+}
+
+func (n *unaryNode) boolean(vars map[string]float64) bool {
+	return !n.x.boolean(vars)
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n *binaryNode) kind() kind {
+	switch n.op {
+	case "+", "-", "*", "/":
+		return kindNumber
+	default:
+		return kindBool
+	}
+}
+
+func (n *binaryNode) number(vars map[string]float64) float64 {
+	l, r := n.left.number(vars), n.right.number(vars)
+	switch n.op {
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	case "/":
+		if r == 0 {
+			return math.NaN()
+		}
+		return l / r
+	}
+	return 0
+}
+
+func (n *binaryNode) boolean(vars map[string]float64) bool {
+	switch n.op {
+	case "&&":
+		return n.left.boolean(vars) && n.right.boolean(vars)
+	case "||":
+		return n.left.boolean(vars) || n.right.boolean(vars)
+	}
+
+	l, r := n.left.number(vars), n.right.number(vars)
+	switch n.op {
+	case "==":
+		return l == r
+	case "!=":
+		return l != r
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package expr
+
+import (
+	"slices"
+	"testing"
+)
+
+var testVars = []string{"cpu", "memory", "swap", "disk_free", "load1", "cpu_cores"}
+
+func TestEval(t *testing.T) {
+	vars := map[string]float64{
+		"cpu":       50,
+		"memory":    95,
+		"swap":      60,
+		"disk_free": 8 * (1 << 30),
+		"load1":     9,
+		"cpu_cores": 4,
+	}
+
+	cases := []struct {
+		src string
+		exp bool
+	}{
+		{"memory > 90% && swap > 50%", true},
+		{"memory > 90 AND swap > 70", false},
+		{"memory > 99 or swap > 50", true},
+		{"disk_free < 10 GiB", true},
+		{"disk_free < 5GiB", false},
+		{"disk_free < 10GB", true},
+		{"load1 / cpu_cores > 2", true},
+		{"load1 / (cpu_cores * 2) > 2", false},
+		{"!(cpu >= 50)", false},
+		{"not cpu < 10", true},
+		{"-cpu + 100 == 50", true},
+		{"cpu - 10 * 2 != 30", false},
+		{"load1 / 0 > 1", false},
+	}
+
+	for _, c := range cases {
+		e, err := Compile(c.src, testVars)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", c.src, err)
+		}
+		if got := e.Eval(vars); got != c.exp {
+			t.Errorf("%q: expected %v, got %v", c.src, c.exp, got)
+		}
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	cases := []struct {
+		src string
+		pos int
+	}{
+		{"", 1},
+		{"cpu", 1},
+		{"cpu > ", 7},
+		{"mem > 90", 1},
+		{"cpu > 90 &&", 12},
+		{"(cpu > 90", 10},
+		{"cpu > 90 + (memory > 1)", 10},
+		{"cpu && memory > 1", 5},
+		{"0 < cpu < 90", 9},
+		{"cpu > 90 $", 10},
+		{"cpu > 1.2.3", 7},
+	}
+
+	for _, c := range cases {
+		_, err := Compile(c.src, testVars)
+		if err == nil {
+			t.Errorf("%q: expected error", c.src)
+			continue
+		}
+		if e, ok := err.(*Error); !ok || e.Pos != c.pos {
+			t.Errorf("%q: expected error at position %d, got %v", c.src, c.pos, err)
+		}
+	}
+}
+
+func TestVars(t *testing.T) {
+	e, err := Compile("memory > 90 && (swap > 50 || memory > 99)", testVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(e.Vars(), []string{"memory", "swap"}) {
+		t.Errorf("unexpected vars %v", e.Vars())
+	}
+}
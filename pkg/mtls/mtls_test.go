@@ -0,0 +1,95 @@
+package mtls
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+)
+
+func TestIssueAndVerify(t *testing.T) {
+	certPEM, keyPEM, err := NewCA("test ca", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := LoadCA(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issued, err := ca.Issue("agent", 48*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !issued.NotAfter.Equal(ca.Cert.NotAfter) {
+		t.Fatalf("expected the certificate to expire with the ca, got %v", issued.NotAfter)
+	}
+
+	cert, err := x509.ParseCertificate(issued.CertDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject.CommonName != "agent" || cert.SerialNumber.Cmp(issued.Serial) != 0 {
+		t.Fatalf("unexpected certificate %v %v", cert.Subject, cert.SerialNumber)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:     ca.CertPool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		t.Fatalf("failed to verify the certificate: %v", err)
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(issued.KeyDER); err != nil {
+		t.Fatal(err)
+	}
+
+	other, _, err := NewCA("other ca", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(other)
+	otherCert, _ := x509.ParseCertificate(block.Bytes)
+	pool := x509.NewCertPool()
+	pool.AddCert(otherCert)
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err == nil {
+		t.Fatal("expected the certificate to be rejected by another ca")
+	}
+}
+
+func TestCRL(t *testing.T) {
+	certPEM, keyPEM, err := NewCA("test ca", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := LoadCA(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued, err := ca.Issue("agent", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crlPEM, err := ca.CRL(2, map[string]time.Time{SerialString(issued.Serial): time.Now()}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(crlPEM)
+	if block == nil || block.Type != "X509 CRL" {
+		t.Fatal("invalid crl pem")
+	}
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crl.CheckSignatureFrom(ca.Cert); err != nil {
+		t.Fatal(err)
+	}
+	if crl.Number.Int64() != 2 || len(crl.RevokedCertificateEntries) != 1 ||
+		crl.RevokedCertificateEntries[0].SerialNumber.Cmp(issued.Serial) != 0 {
+		t.Fatalf("unexpected crl %v %v", crl.Number, crl.RevokedCertificateEntries)
+	}
+
+	if _, err := ca.CRL(1, map[string]time.Time{"not hex": time.Now()}, time.Hour); err == nil {
+		t.Fatal("expected an invalid serial to be rejected")
+	}
+}
@@ -0,0 +1,220 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+const (
+	telegramAPIURL    = "https://api.telegram.org"
+	telegramMaxLength = 4096
+	discordMaxLength  = 2000
+	ntfyServerURL     = "https://ntfy.sh"
+)
+
+// Telegram Bot API
+type Telegram struct {
+	BotToken        string `json:"bot_token"`
+	ChatID          string `json:"chat_id"`
+	MessageThreadID int64  `json:"message_thread_id,omitempty"`
+	APIURL          string `json:"api_url,omitempty"` // 自建 Bot API 服务器地址
+
+	client *http.Client
+}
+
+func (t *Telegram) init(ns *model.NotificationServerBundle) error {
+	if err := required("bot_token", t.BotToken); err != nil {
+		return err
+	}
+	if err := required("chat_id", t.ChatID); err != nil {
+		return err
+	}
+	if t.APIURL == "" {
+		t.APIURL = telegramAPIURL
+	} else if err := validateURL("api_url", t.APIURL); err != nil {
+		return err
+	}
+	t.client = httpClient(ns.Notification)
+	return nil
+}
+
+func (t *Telegram) Send(ctx context.Context, message string) error {
+	payload := map[string]any{
+		"chat_id":                  t.ChatID,
+		"text":                     truncate(message, telegramMaxLength),
+		"disable_web_page_preview": true,
+	}
+	if t.MessageThreadID != 0 {
+		payload["message_thread_id"] = t.MessageThreadID
+	}
+	reqURL := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(t.APIURL, "/"), t.BotToken)
+	return postJSON(ctx, t.client, reqURL, payload, nil)
+}
+
+// Slack Incoming Webhook
+type Slack struct {
+	WebhookURL string `json:"webhook_url"`
+
+	client *http.Client
+}
+
+func (s *Slack) init(ns *model.NotificationServerBundle) error {
+	if err := validateURL("webhook_url", s.WebhookURL); err != nil {
+		return err
+	}
+	s.client = httpClient(ns.Notification)
+	return nil
+}
+
+func (s *Slack) Send(ctx context.Context, message string) error {
+	return postJSON(ctx, s.client, s.WebhookURL, map[string]any{"text": message}, nil)
+}
+
+// Discord Webhook
+type Discord struct {
+	WebhookURL string `json:"webhook_url"`
+	Username   string `json:"username,omitempty"`
+
+	client *http.Client
+}
+
+func (d *Discord) init(ns *model.NotificationServerBundle) error {
+	if err := validateURL("webhook_url", d.WebhookURL); err != nil {
+		return err
+	}
+	d.client = httpClient(ns.Notification)
+	return nil
+}
+
+func (d *Discord) Send(ctx context.Context, message string) error {
+	payload := map[string]any{"content": truncate(message, discordMaxLength)}
+	if d.Username != "" {
+		payload["username"] = d.Username
+	}
+	return postJSON(ctx, d.client, d.WebhookURL, payload, nil)
+}
+
+// Matrix 通过 Client-Server API 向房间发送消息
+type Matrix struct {
+	Homeserver  string `json:"homeserver"`
+	AccessToken string `json:"access_token"`
+	RoomID      string `json:"room_id"`
+
+	client *http.Client
+}
+
+func (m *Matrix) init(ns *model.NotificationServerBundle) error {
+	if err := validateURL("homeserver", m.Homeserver); err != nil {
+		return err
+	}
+	if err := required("access_token", m.AccessToken); err != nil {
+		return err
+	}
+	if err := required("room_id", m.RoomID); err != nil {
+		return err
+	}
+	m.client = httpClient(ns.Notification)
+	return nil
+}
+
+func (m *Matrix) Send(ctx context.Context, message string) error {
+	body, err := json.Marshal(map[string]any{
+		"msgtype": "m.text",
+		"body":    message,
+	})
+	if err != nil {
+		return err
+	}
+	txnID := fmt.Sprintf("nezha-%d", time.Now().UnixNano())
+	reqURL := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimSuffix(m.Homeserver, "/"), url.PathEscape(m.RoomID), txnID)
+	return doRequest(ctx, m.client, http.MethodPut, reqURL, "application/json", body, map[string]string{
+		"Authorization": "Bearer " + m.AccessToken,
+	})
+}
+
+// Ntfy 推送
+type Ntfy struct {
+	ServerURL string `json:"server_url,omitempty"`
+	Topic     string `json:"topic"`
+	Token     string `json:"token,omitempty"`
+	Priority  int    `json:"priority,omitempty"` // 1-5，为 0 时使用服务端默认值
+
+	client *http.Client
+}
+
+func (n *Ntfy) init(ns *model.NotificationServerBundle) error {
+	if n.ServerURL == "" {
+		n.ServerURL = ntfyServerURL
+	} else if err := validateURL("server_url", n.ServerURL); err != nil {
+		return err
+	}
+	if err := required("topic", n.Topic); err != nil {
+		return err
+	}
+	if n.Priority < 0 || n.Priority > 5 {
+		return fmt.Errorf("invalid priority: %d", n.Priority)
+	}
+	n.client = httpClient(ns.Notification)
+	return nil
+}
+
+func (n *Ntfy) Send(ctx context.Context, message string) error {
+	header := map[string]string{
+		"Title": mime.QEncoding.Encode("UTF-8", title(message)),
+	}
+	if n.Priority > 0 {
+		header["Priority"] = fmt.Sprintf("%d", n.Priority)
+	}
+	if n.Token != "" {
+		header["Authorization"] = "Bearer " + n.Token
+	}
+	reqURL := fmt.Sprintf("%s/%s", strings.TrimSuffix(n.ServerURL, "/"), url.PathEscape(n.Topic))
+	return doRequest(ctx, n.client, http.MethodPost, reqURL, "text/plain; charset=utf-8", []byte(message), header)
+}
+
+// Gotify 推送
+type Gotify struct {
+	ServerURL string `json:"server_url"`
+	AppToken  string `json:"app_token"`
+	Priority  *int   `json:"priority,omitempty"`
+
+	client *http.Client
+}
+
+func (g *Gotify) init(ns *model.NotificationServerBundle) error {
+	if err := validateURL("server_url", g.ServerURL); err != nil {
+		return err
+	}
+	if err := required("app_token", g.AppToken); err != nil {
+		return err
+	}
+	if g.Priority != nil && (*g.Priority < 0 || *g.Priority > 10) {
+		return fmt.Errorf("invalid priority: %d", *g.Priority)
+	}
+	g.client = httpClient(ns.Notification)
+	return nil
+}
+
+func (g *Gotify) Send(ctx context.Context, message string) error {
+	payload := map[string]any{
+		"title":   title(message),
+		"message": message,
+	}
+	if g.Priority != nil {
+		payload["priority"] = *g.Priority
+	}
+	reqURL := strings.TrimSuffix(g.ServerURL, "/") + "/message"
+	return postJSON(ctx, g.client, reqURL, payload, map[string]string{
+		"X-Gotify-Key": g.AppToken,
+	})
+}
@@ -0,0 +1,167 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+const (
+	EmailSecurityStartTLS = "starttls"
+	EmailSecurityTLS      = "tls"
+	EmailSecurityNone     = "none"
+)
+
+const (
+	emailDialTimeout = time.Second * 10
+	emailSendTimeout = time.Minute
+)
+
+// Email SMTP 邮件
+type Email struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`
+	Security string   `json:"security,omitempty"` // starttls（默认）、tls（隐式 TLS）或 none
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Subject  string   `json:"subject,omitempty"` // 为空时使用消息的第一行
+
+	skipVerify bool
+}
+
+func (e *Email) init(ns *model.NotificationServerBundle) error {
+	if err := required("host", e.Host); err != nil {
+		return err
+	}
+	if e.Port < 1 || e.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", e.Port)
+	}
+	switch e.Security {
+	case "":
+		e.Security = EmailSecurityStartTLS
+	case EmailSecurityStartTLS, EmailSecurityTLS, EmailSecurityNone:
+	default:
+		return fmt.Errorf("invalid security: %s", e.Security)
+	}
+	if _, err := mail.ParseAddress(e.From); err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+	if len(e.To) == 0 {
+		return errEmptyRecipient
+	}
+	for _, to := range e.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("invalid to address %s: %w", to, err)
+		}
+	}
+	if strings.ContainsAny(e.Subject, "\r\n") {
+		return errors.New("invalid subject")
+	}
+
+	n := ns.Notification
+	e.skipVerify = n.VerifyTLS == nil || !*n.VerifyTLS
+	return nil
+}
+
+func (e *Email) Send(ctx context.Context, message string) error {
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+	tlsConfig := &tls.Config{ServerName: e.Host, InsecureSkipVerify: e.skipVerify}
+	dialer := &net.Dialer{Timeout: emailDialTimeout}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if e.Security == EmailSecurityTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(emailSendTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if e.Security == EmailSecurityStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.Username, e.Password, e.Host)); err != nil {
+			return err
+		}
+	}
+
+	from, _ := mail.ParseAddress(e.From)
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		addr, _ := mail.ParseAddress(to)
+		if err := c.Rcpt(addr.Address); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(e.buildMessage(message, time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (e *Email) buildMessage(message string, now time.Time) []byte {
+	subject := e.Subject
+	if subject == "" {
+		subject = title(message)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", e.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	qp.Write([]byte(message))
+	qp.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,205 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/goccy/go-json"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+const (
+	defaultTitle    = "Nezha Monitoring"
+	maxResponseBody = 4096
+)
+
+// Response 通知方式返回的 HTTP 响应，用于测试发送时展示
+type Response struct {
+	StatusCode int
+	Body       string
+}
+
+type responseKey struct{}
+
+// WithResponse 返回记录 HTTP 响应的 context，发送后可从 r 中读取响应
+func WithResponse(ctx context.Context, r *Response) context.Context {
+	return context.WithValue(ctx, responseKey{}, r)
+}
+
+// RecordResponse 将 HTTP 响应记录到 context 中
+func RecordResponse(ctx context.Context, statusCode int, body string) {
+	if r, ok := ctx.Value(responseKey{}).(*Response); ok {
+		r.StatusCode = statusCode
+		r.Body = body
+	}
+}
+
+// Notifier 通知方式驱动
+type Notifier interface {
+	Send(ctx context.Context, message string) error
+}
+
+type driver interface {
+	Notifier
+	// init 校验配置并填充默认值
+	init(ns *model.NotificationServerBundle) error
+}
+
+// New 按通知方式的类型解析配置并创建驱动，配置不合法时返回错误
+func New(ns *model.NotificationServerBundle) (Notifier, error) {
+	n := ns.Notification
+
+	var d driver
+	switch n.Type {
+	case "", model.NotificationTypeTemplate:
+		return &Template{bundle: ns}, nil
+	case model.NotificationTypeEmail:
+		d = &Email{}
+	case model.NotificationTypeTelegram:
+		d = &Telegram{}
+	case model.NotificationTypeSlack:
+		d = &Slack{}
+	case model.NotificationTypeDiscord:
+		d = &Discord{}
+	case model.NotificationTypeMatrix:
+		d = &Matrix{}
+	case model.NotificationTypeNtfy:
+		d = &Ntfy{}
+	case model.NotificationTypeGotify:
+		d = &Gotify{}
+	case model.NotificationTypeWebhook:
+		d = &Webhook{}
+	default:
+		return nil, fmt.Errorf("unknown notification type %s", n.Type)
+	}
+
+	config := n.Config
+	if config == "" {
+		config = "{}"
+	}
+	if err := json.Unmarshal([]byte(config), d); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := d.init(ns); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Send 创建驱动并发送通知
+func Send(ctx context.Context, ns *model.NotificationServerBundle, message string) error {
+	n, err := New(ns)
+	if err != nil {
+		return err
+	}
+	return n.Send(ctx, message)
+}
+
+func httpClient(n *model.Notification) *http.Client {
+	if n.VerifyTLS != nil && *n.VerifyTLS {
+		return utils.HttpClient
+	}
+	return utils.HttpClientSkipTlsVerify
+}
+
+func doRequest(ctx context.Context, client *http.Client, method, reqURL, contentType string, body []byte, header map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return redactURLError(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return redactURLError(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	_, _ = io.Copy(io.Discard, resp.Body)
+	RecordResponse(ctx, resp.StatusCode, string(respBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%d@%s %s", resp.StatusCode, resp.Status, string(respBody))
+	}
+	return nil
+}
+
+// redactURLError 请求地址中可能包含 Bot Token、Webhook 密钥等凭据，错误信息中只保留协议与主机
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	redacted := "(invalid url)"
+	if u, parseErr := url.Parse(urlErr.URL); parseErr == nil && u.Host != "" {
+		redacted = u.Scheme + "://" + u.Host
+	}
+	return fmt.Errorf("%s %s: %w", urlErr.Op, redacted, urlErr.Err)
+}
+
+func postJSON(ctx context.Context, client *http.Client, reqURL string, payload any, header map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return doRequest(ctx, client, http.MethodPost, reqURL, "application/json", body, header)
+}
+
+func validateURL(name, s string) error {
+	if s == "" {
+		return fmt.Errorf("%s is not set", name)
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid %s: %s", name, s)
+	}
+	return nil
+}
+
+func required(name, s string) error {
+	if strings.TrimSpace(s) == "" {
+		return fmt.Errorf("%s is not set", name)
+	}
+	return nil
+}
+
+// title 取消息的第一行作为标题
+func title(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return defaultTitle
+	}
+	return truncate(line, 100)
+}
+
+// truncate 按字符数截断，避免超过平台的消息长度限制
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
+
+var errEmptyRecipient = errors.New("need to specify at least one recipient")
@@ -0,0 +1,209 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+type testRequest struct {
+	method string
+	path   string
+	header http.Header
+	body   string
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *testRequest) {
+	t.Helper()
+	req := &testRequest{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req.method = r.Method
+		req.path = r.URL.Path
+		req.header = r.Header
+		req.body = string(body)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, req
+}
+
+func newBundle(notificationType string, config any) *model.NotificationServerBundle {
+	data, _ := json.Marshal(config)
+	return &model.NotificationServerBundle{
+		Notification: &model.Notification{
+			Type:   notificationType,
+			Config: string(data),
+		},
+		Loc: time.UTC,
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	cases := []struct {
+		name    string
+		typ     string
+		config  any
+		wantErr bool
+	}{
+		{"template", model.NotificationTypeTemplate, nil, false},
+		{"unknown type", "pager", map[string]any{}, true},
+		{"email ok", model.NotificationTypeEmail, map[string]any{"host": "smtp.example.com", "port": 587, "from": "Nezha <a@example.com>", "to": []string{"b@example.com"}}, false},
+		{"email bad security", model.NotificationTypeEmail, map[string]any{"host": "smtp.example.com", "port": 465, "security": "ssl", "from": "a@example.com", "to": []string{"b@example.com"}}, true},
+		{"email no recipient", model.NotificationTypeEmail, map[string]any{"host": "smtp.example.com", "port": 25, "from": "a@example.com"}, true},
+		{"email bad port", model.NotificationTypeEmail, map[string]any{"host": "smtp.example.com", "from": "a@example.com", "to": []string{"b@example.com"}}, true},
+		{"telegram missing chat", model.NotificationTypeTelegram, map[string]any{"bot_token": "t"}, true},
+		{"slack bad url", model.NotificationTypeSlack, map[string]any{"webhook_url": "ftp://example.com"}, true},
+		{"discord ok", model.NotificationTypeDiscord, map[string]any{"webhook_url": "https://discord.com/api/webhooks/1/x"}, false},
+		{"matrix missing room", model.NotificationTypeMatrix, map[string]any{"homeserver": "https://matrix.org", "access_token": "t"}, true},
+		{"ntfy default server", model.NotificationTypeNtfy, map[string]any{"topic": "alerts"}, false},
+		{"ntfy bad priority", model.NotificationTypeNtfy, map[string]any{"topic": "alerts", "priority": 6}, true},
+		{"gotify missing token", model.NotificationTypeGotify, map[string]any{"server_url": "https://gotify.example.com"}, true},
+		{"webhook missing url", model.NotificationTypeWebhook, map[string]any{}, true},
+		{"invalid json", model.NotificationTypeWebhook, "{", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := New(newBundle(c.typ, c.config))
+			if (err != nil) != c.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	ts, req := newTestServer(t)
+	message := "[Incident] server alert\ndetail"
+
+	cases := []struct {
+		typ    string
+		config map[string]any
+		check  func(t *testing.T)
+	}{
+		{model.NotificationTypeTelegram, map[string]any{"bot_token": "token", "chat_id": "42", "api_url": ts.URL}, func(t *testing.T) {
+			if req.path != "/bottoken/sendMessage" || !strings.Contains(req.body, `"chat_id":"42"`) {
+				t.Fatalf("unexpected request: %s %s", req.path, req.body)
+			}
+		}},
+		{model.NotificationTypeSlack, map[string]any{"webhook_url": ts.URL + "/slack"}, func(t *testing.T) {
+			if req.path != "/slack" || !strings.Contains(req.body, `"text"`) {
+				t.Fatalf("unexpected request: %s %s", req.path, req.body)
+			}
+		}},
+		{model.NotificationTypeMatrix, map[string]any{"homeserver": ts.URL, "access_token": "token", "room_id": "!room:example.com"}, func(t *testing.T) {
+			if req.method != http.MethodPut || !strings.HasPrefix(req.path, "/_matrix/client/v3/rooms/!room:example.com/send/m.room.message/") ||
+				req.header.Get("Authorization") != "Bearer token" {
+				t.Fatalf("unexpected request: %s %s", req.method, req.path)
+			}
+		}},
+		{model.NotificationTypeNtfy, map[string]any{"server_url": ts.URL, "topic": "alerts", "priority": 4}, func(t *testing.T) {
+			if req.path != "/alerts" || req.body != message || req.header.Get("Priority") != "4" || req.header.Get("Title") != "[Incident] server alert" {
+				t.Fatalf("unexpected request: %s %s %v", req.path, req.body, req.header)
+			}
+		}},
+		{model.NotificationTypeGotify, map[string]any{"server_url": ts.URL, "app_token": "key"}, func(t *testing.T) {
+			if req.path != "/message" || req.header.Get("X-Gotify-Key") != "key" {
+				t.Fatalf("unexpected request: %s %v", req.path, req.header)
+			}
+		}},
+		{model.NotificationTypeWebhook, map[string]any{"url": ts.URL + "/hook", "headers": map[string]string{"X-Token": "secret"}}, func(t *testing.T) {
+			var payload webhookPayload
+			if err := json.Unmarshal([]byte(req.body), &payload); err != nil {
+				t.Fatal(err)
+			}
+			if payload.Message != message || payload.Title != "[Incident] server alert" || req.header.Get("X-Token") != "secret" {
+				t.Fatalf("unexpected request: %s %v", req.body, req.header)
+			}
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.typ, func(t *testing.T) {
+			if err := Send(context.Background(), newBundle(c.typ, c.config), message); err != nil {
+				t.Fatal(err)
+			}
+			c.check(t)
+		})
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	e := &Email{From: "a@example.com", To: []string{"b@example.com", "c@example.com"}}
+	msg := string(e.buildMessage("[Incident] 服务器离线\ndetail", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	for _, want := range []string{
+		"To: b@example.com, c@example.com\r\n",
+		"Subject: =?UTF-8?q?",
+		"Date: Mon, 01 Jan 2024 00:00:00 +0000\r\n",
+		"Content-Transfer-Encoding: quoted-printable\r\n\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message does not contain %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestRecordResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		_, _ = io.WriteString(w, "body of "+r.URL.Path)
+	}))
+	t.Cleanup(ts.Close)
+
+	cases := []struct {
+		name       string
+		bundle     *model.NotificationServerBundle
+		wantStatus int
+		wantBody   string
+		wantErr    bool
+	}{
+		{"webhook", newBundle(model.NotificationTypeWebhook, map[string]any{"url": ts.URL + "/hook"}), http.StatusOK, "body of /hook", false},
+		{"webhook failed", newBundle(model.NotificationTypeWebhook, map[string]any{"url": ts.URL + "/fail"}), http.StatusBadRequest, "body of /fail", true},
+		{"template", &model.NotificationServerBundle{
+			Notification: &model.Notification{
+				URL:           ts.URL + "/template?msg=#NEZHA#",
+				RequestMethod: model.NotificationRequestMethodGET,
+			},
+			Loc: time.UTC,
+		}, http.StatusOK, "body of /template", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var resp Response
+			err := Send(WithResponse(context.Background(), &resp), c.bundle, "message")
+			if (err != nil) != c.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, c.wantErr)
+			}
+			if resp.StatusCode != c.wantStatus || resp.Body != c.wantBody {
+				t.Fatalf("unexpected response: %d %q", resp.StatusCode, resp.Body)
+			}
+		})
+	}
+}
+
+func TestSendRedactsURL(t *testing.T) {
+	ts, _ := newTestServer(t)
+	ts.Close()
+
+	err := Send(context.Background(), newBundle(model.NotificationTypeTelegram, map[string]any{
+		"bot_token": "123456:secret-token", "chat_id": "42", "api_url": ts.URL,
+	}), "message")
+	if err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Fatalf("bot token leaked in error: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+// Template 自定义请求模板，支持 #NEZHA# 等占位符
+type Template struct {
+	bundle *model.NotificationServerBundle
+}
+
+func (t *Template) Send(ctx context.Context, message string) error {
+	statusCode, body, err := t.bundle.SendWithResponse(ctx, message)
+	if statusCode != 0 {
+		RecordResponse(ctx, statusCode, body)
+	}
+	if err != nil {
+		return redactURLError(err)
+	}
+	return nil
+}
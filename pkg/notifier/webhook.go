@@ -0,0 +1,55 @@
+package notifier
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+// Webhook 以固定的 JSON 结构推送通知
+type Webhook struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	bundle *model.NotificationServerBundle
+	client *http.Client
+}
+
+type webhookServer struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+type webhookPayload struct {
+	Title   string         `json:"title"`
+	Message string         `json:"message"`
+	Time    time.Time      `json:"time"`
+	Server  *webhookServer `json:"server,omitempty"`
+}
+
+func (w *Webhook) init(ns *model.NotificationServerBundle) error {
+	if err := validateURL("url", w.URL); err != nil {
+		return err
+	}
+	w.bundle = ns
+	w.client = httpClient(ns.Notification)
+	return nil
+}
+
+func (w *Webhook) Send(ctx context.Context, message string) error {
+	now := time.Now()
+	if w.bundle.Loc != nil {
+		now = now.In(w.bundle.Loc)
+	}
+	payload := webhookPayload{
+		Title:   title(message),
+		Message: message,
+		Time:    now,
+	}
+	if s := w.bundle.Server; s != nil {
+		payload.Server = &webhookServer{ID: s.ID, Name: s.Name}
+	}
+	return postJSON(ctx, w.client, w.URL, payload, w.Headers)
+}
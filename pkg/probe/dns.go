@@ -0,0 +1,91 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+const resolvConf = "/etc/resolv.conf"
+
+// DNS 查询记录并检查解析结果，成功时返回解析到的记录
+func DNS(ctx context.Context, data string) (string, error) {
+	task := model.DNSCheckTask{Domain: data}
+	if _, err := decodeTask(data, &task); err != nil {
+		return "", err
+	}
+	if err := task.DNSCheck.Normalize(); err != nil {
+		return "", err
+	}
+
+	// 系统解析器通常位于本机，只检查自定义的解析服务器
+	var c dns.Client
+	server := task.Resolver
+	if server != "" {
+		c.Dialer = newDialer()
+	} else {
+		conf, err := dns.ClientConfigFromFile(resolvConf)
+		if err != nil {
+			return "", fmt.Errorf("no resolver available: %w", err)
+		}
+		if len(conf.Servers) == 0 {
+			return "", fmt.Errorf("no resolver available in %s", resolvConf)
+		}
+		server = net.JoinHostPort(conf.Servers[0], conf.Port)
+	}
+
+	qtype := dns.StringToType[task.RecordType]
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(task.Domain), qtype)
+	m.RecursionDesired = true
+
+	in, _, err := c.ExchangeContext(ctx, m, server)
+	if err != nil {
+		return "", err
+	}
+	if in.Rcode != dns.RcodeSuccess {
+		return "", fmt.Errorf("%s query for %s failed: %s", task.RecordType, task.Domain, dns.RcodeToString[in.Rcode])
+	}
+
+	var answers []string
+	for _, rr := range in.Answer {
+		if rr.Header().Rrtype == qtype {
+			answers = append(answers, rrValue(rr))
+		}
+	}
+	if err := task.Assert(answers); err != nil {
+		return "", err
+	}
+	return strings.Join(answers, ", "), nil
+}
+
+func rrValue(rr dns.RR) string {
+	switch r := rr.(type) {
+	case *dns.A:
+		return r.A.String()
+	case *dns.AAAA:
+		return r.AAAA.String()
+	case *dns.CNAME:
+		return r.Target
+	case *dns.MX:
+		return r.Mx
+	case *dns.NS:
+		return r.Ns
+	case *dns.TXT:
+		return strings.Join(r.Txt, "")
+	case *dns.SRV:
+		return r.Target
+	case *dns.PTR:
+		return r.Ptr
+	case *dns.CAA:
+		return r.Value
+	case *dns.SOA:
+		return r.Ns
+	}
+	return strings.TrimSpace(strings.TrimPrefix(rr.String(), rr.Header().String()))
+}
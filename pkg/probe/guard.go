@@ -0,0 +1,43 @@
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// ErrForbiddenTarget 面板不探测内网、回环与链路本地地址，避免被用于访问面板所在的内部网络
+var ErrForbiddenTarget = errors.New("target address is not allowed")
+
+// allowPrivate 为 true 时允许探测内网地址，仅用于测试
+var allowPrivate bool
+
+// checkIP 检查地址是否允许探测
+func checkIP(ip net.IP) error {
+	if allowPrivate {
+		return nil
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, ip)
+	}
+	return nil
+}
+
+// dialControl 在连接建立前检查解析后的地址，重定向与 DNS 重绑定同样会被拦截
+func dialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, host)
+	}
+	return checkIP(ip)
+}
+
+func newDialer() *net.Dialer {
+	return &net.Dialer{Control: dialControl}
+}
@@ -0,0 +1,118 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+)
+
+// ICMP 发送一次 ICMP Echo 请求，优先使用无需特权的 ICMP socket
+func ICMP(ctx context.Context, host string) error {
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, strings.TrimSpace(host))
+	if err != nil {
+		return err
+	}
+	if len(ips) == 0 {
+		return fmt.Errorf("no address for %s", host)
+	}
+	ip := ips[0].IP
+	if err := checkIP(ip); err != nil {
+		return err
+	}
+
+	var (
+		networks []string
+		reqType  icmp.Type
+		replyTyp icmp.Type
+		proto    int
+	)
+	if ip.To4() != nil {
+		networks = []string{"udp4", "ip4:icmp"}
+		reqType, replyTyp, proto = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply, protocolICMP
+	} else {
+		networks = []string{"udp6", "ip6:ipv6-icmp"}
+		reqType, replyTyp, proto = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply, protocolIPv6ICMP
+	}
+
+	var (
+		conn    *icmp.PacketConn
+		network string
+		errs    []error
+	)
+	for _, network = range networks {
+		if conn, err = icmp.ListenPacket(network, ""); err == nil {
+			break
+		}
+		errs = append(errs, err)
+	}
+	if conn == nil {
+		return fmt.Errorf("failed to open icmp socket: %w", errors.Join(errs...))
+	}
+	defer conn.Close()
+	setDeadline(ctx, conn)
+
+	id := os.Getpid() & 0xffff
+	msg := icmp.Message{
+		Type: reqType,
+		Body: &icmp.Echo{ID: id, Seq: 1, Data: []byte("nezha")},
+	}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return err
+	}
+
+	unprivileged := strings.HasPrefix(network, "udp")
+	var dst net.Addr = &net.IPAddr{IP: ip}
+	if unprivileged {
+		dst = &net.UDPAddr{IP: ip}
+	}
+	if _, err := conn.WriteTo(b, dst); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		reply, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil || reply.Type != replyTyp {
+			continue
+		}
+		echo, ok := reply.Body.(*icmp.Echo)
+		if !ok || echo.Seq != 1 {
+			continue
+		}
+		// 无特权 socket 的 ID 由内核改写，只能通过来源地址判断
+		if !unprivileged && echo.ID != id {
+			continue
+		}
+		if !peerIP(peer).Equal(ip) {
+			continue
+		}
+		return nil
+	}
+}
+
+func peerIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	}
+	return nil
+}
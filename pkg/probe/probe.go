@@ -0,0 +1,226 @@
+// Package probe 在面板进程内执行服务监控，结果与 Agent 上报的格式相同
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/nezhahq/nezha/model"
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+// Supported 判断面板是否可以执行该类型的监控
+func Supported(taskType uint8) bool {
+	switch taskType {
+	case model.TaskTypeHTTPGet, model.TaskTypeTCPPing, model.TaskTypeICMPPing,
+		model.TaskTypeDNS, model.TaskTypeUDP, model.TaskTypeTLS:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run 执行服务监控任务，Delay 为毫秒，失败时 Data 为失败原因
+func Run(ctx context.Context, task *pb.Task) *pb.TaskResult {
+	result := &pb.TaskResult{
+		Id:   task.GetId(),
+		Type: task.GetType(),
+	}
+
+	var (
+		data string
+		err  error
+	)
+	start := time.Now()
+	switch task.GetType() {
+	case model.TaskTypeHTTPGet:
+		data, err = HTTP(ctx, task.GetData())
+	case model.TaskTypeTCPPing:
+		err = TCP(ctx, task.GetData())
+	case model.TaskTypeICMPPing:
+		err = ICMP(ctx, task.GetData())
+	case model.TaskTypeDNS:
+		data, err = DNS(ctx, task.GetData())
+	case model.TaskTypeUDP:
+		err = UDP(ctx, task.GetData())
+	case model.TaskTypeTLS:
+		data, err = TLS(ctx, task.GetData())
+	default:
+		err = fmt.Errorf("unsupported task type %d", task.GetType())
+	}
+	result.Delay = float32(time.Since(start).Microseconds()) / 1000
+
+	if err != nil {
+		result.Data = err.Error()
+		return result
+	}
+	result.Successful = true
+	result.Data = data
+	return result
+}
+
+// decodeTask 解析 JSON 格式的检查任务，不是 JSON 时返回 false
+func decodeTask(data string, task any) (bool, error) {
+	if !strings.HasPrefix(strings.TrimSpace(data), "{") {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(data), task); err != nil {
+		return false, fmt.Errorf("invalid task: %w", err)
+	}
+	return true, nil
+}
+
+// HTTP 发送 HTTP 请求并检查断言，HTTPS 成功时返回 "颁发者|过期时间"
+func HTTP(ctx context.Context, data string) (string, error) {
+	task := model.HTTPCheckTask{URL: data}
+	if _, err := decodeTask(data, &task); err != nil {
+		return "", err
+	}
+	if err := task.HTTPCheck.Normalize(); err != nil {
+		return "", err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// 经过代理时无法检查目标地址
+	transport.Proxy = nil
+	transport.DialContext = newDialer().DialContext
+	client := &http.Client{
+		Transport:     transport,
+		CheckRedirect: task.CheckRedirect,
+	}
+	defer client.CloseIdleConnections()
+
+	req, err := http.NewRequestWithContext(ctx, task.Method, task.URL, strings.NewReader(task.Body))
+	if err != nil {
+		return "", err
+	}
+	for k, v := range task.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", certError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, task.MaxResponseSize+1))
+	if err != nil {
+		return "", err
+	}
+	if err := task.Assert(resp.StatusCode, resp.Header, body); err != nil {
+		return "", err
+	}
+	return certInfo(resp.TLS), nil
+}
+
+// TCP 检查端口是否可以建立连接
+func TCP(ctx context.Context, address string) error {
+	conn, err := newDialer().DialContext(ctx, "tcp", strings.TrimSpace(address))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// UDP 发送探测内容并检查响应
+func UDP(ctx context.Context, data string) error {
+	task := model.UDPCheckTask{Address: data}
+	if _, err := decodeTask(data, &task); err != nil {
+		return err
+	}
+	payload, err := task.PayloadBytes()
+	if err != nil {
+		return err
+	}
+
+	conn, err := newDialer().DialContext(ctx, "udp", task.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	setDeadline(ctx, conn)
+
+	if _, err := conn.Write(payload); err != nil {
+		return err
+	}
+	buf := make([]byte, 64*1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return err
+	}
+	return task.Assert(buf[:n])
+}
+
+// TLS 完成 TLS 握手，成功时返回 "颁发者|过期时间"
+func TLS(ctx context.Context, data string) (string, error) {
+	task := model.TLSCheckTask{Address: data}
+	if _, err := decodeTask(data, &task); err != nil {
+		return "", err
+	}
+
+	d := &tls.Dialer{
+		NetDialer: newDialer(),
+		Config: &tls.Config{
+			ServerName:         task.ServerNameFor(task.Address),
+			NextProtos:         task.ALPN,
+			InsecureSkipVerify: task.SkipVerify,
+		},
+	}
+	conn, err := d.DialContext(ctx, "tcp", task.Address)
+	if err != nil {
+		return "", certError(err)
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	if len(task.ALPN) > 0 && !slices.Contains(task.ALPN, state.NegotiatedProtocol) {
+		return "", fmt.Errorf("server does not support ALPN %v", task.ALPN)
+	}
+	return certInfo(&state), nil
+}
+
+// certError 证书校验失败时使用与 Agent 相同的前缀，用于触发证书提醒
+func certError(err error) error {
+	var (
+		verifyErr   *tls.CertificateVerificationError
+		unknownErr  x509.UnknownAuthorityError
+		hostnameErr x509.HostnameError
+		invalidErr  x509.CertificateInvalidError
+	)
+	if errors.As(err, &verifyErr) || errors.As(err, &unknownErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return fmt.Errorf("SSL证书错误：%w", err)
+	}
+	return err
+}
+
+func certInfo(state *tls.ConnectionState) string {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return ""
+	}
+	cert := state.PeerCertificates[0]
+	return cert.Issuer.CommonName + "|" + cert.NotAfter.String()
+}
+
+func setDeadline(ctx context.Context, conn interface{ SetDeadline(time.Time) error }) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Second * 10)
+	}
+	_ = conn.SetDeadline(deadline)
+}
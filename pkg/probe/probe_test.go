@@ -0,0 +1,169 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/nezhahq/nezha/model"
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+func TestMain(m *testing.M) {
+	// 测试使用本机服务
+	allowPrivate = true
+	os.Exit(m.Run())
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestRunHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	r := Run(testContext(t), &pb.Task{Id: 1, Type: model.TaskTypeHTTPGet, Data: srv.URL})
+	if r.Successful || !strings.Contains(r.Data, "401") {
+		t.Fatalf("expected status code failure, got %+v", r)
+	}
+
+	task, _ := json.Marshal(model.HTTPCheckTask{
+		URL: srv.URL,
+		HTTPCheck: model.HTTPCheck{
+			Headers:   map[string]string{"X-Token": "secret"},
+			JSONPath:  "status",
+			JSONValue: "ok",
+		},
+	})
+	r = Run(testContext(t), &pb.Task{Id: 1, Type: model.TaskTypeHTTPGet, Data: string(task)})
+	if !r.Successful || r.Id != 1 || r.Type != model.TaskTypeHTTPGet {
+		t.Fatalf("expected success, got %+v", r)
+	}
+}
+
+func TestRunHTTPSCertInfo(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	r := Run(testContext(t), &pb.Task{Type: model.TaskTypeHTTPGet, Data: srv.URL})
+	if r.Successful || !strings.HasPrefix(r.Data, "SSL证书错误：") {
+		t.Fatalf("expected certificate error, got %+v", r)
+	}
+
+	task, _ := json.Marshal(model.TLSCheckTask{
+		Address:  srv.Listener.Addr().String(),
+		TLSCheck: model.TLSCheck{SkipVerify: true},
+	})
+	r = Run(testContext(t), &pb.Task{Type: model.TaskTypeTLS, Data: string(task)})
+	if !r.Successful || len(strings.Split(r.Data, "|")) != 2 {
+		t.Fatalf("expected cert info, got %+v", r)
+	}
+}
+
+func TestRunTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+
+	r := Run(testContext(t), &pb.Task{Type: model.TaskTypeTCPPing, Data: addr})
+	if !r.Successful || r.Delay <= 0 {
+		t.Fatalf("expected success, got %+v", r)
+	}
+
+	ln.Close()
+	r = Run(testContext(t), &pb.Task{Type: model.TaskTypeTCPPing, Data: addr})
+	if r.Successful || r.Data == "" {
+		t.Fatalf("expected failure, got %+v", r)
+	}
+}
+
+func TestRunUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			conn.WriteTo(append([]byte("echo "), buf[:n]...), addr)
+		}
+	}()
+
+	for _, c := range []struct {
+		expected string
+		success  bool
+	}{
+		{"echo ping", true},
+		{"pong", false},
+	} {
+		task, _ := json.Marshal(model.UDPCheckTask{
+			Address:  conn.LocalAddr().String(),
+			UDPCheck: model.UDPCheck{Payload: "ping", Expected: c.expected},
+		})
+		r := Run(testContext(t), &pb.Task{Type: model.TaskTypeUDP, Data: string(task)})
+		if r.Successful != c.success {
+			t.Fatalf("expected %q success=%v, got %+v", c.expected, c.success, r)
+		}
+	}
+}
+
+func TestRunUnsupported(t *testing.T) {
+	if Supported(model.TaskTypeCommand) {
+		t.Fatal("command tasks should not be supported")
+	}
+	r := Run(testContext(t), &pb.Task{Type: model.TaskTypeCommand})
+	if r.Successful {
+		t.Fatalf("expected failure, got %+v", r)
+	}
+}
+
+func TestRunForbiddenTarget(t *testing.T) {
+	allowPrivate = false
+	defer func() { allowPrivate = true }()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	cases := []*pb.Task{
+		{Type: model.TaskTypeHTTPGet, Data: srv.URL},
+		{Type: model.TaskTypeTCPPing, Data: srv.Listener.Addr().String()},
+		{Type: model.TaskTypeTCPPing, Data: "10.0.0.1:80"},
+		{Type: model.TaskTypeTCPPing, Data: "[fe80::1]:80"},
+		{Type: model.TaskTypeUDP, Data: "192.168.1.1:53"},
+		{Type: model.TaskTypeTLS, Data: "169.254.169.254:443"},
+		{Type: model.TaskTypeICMPPing, Data: "127.0.0.1"},
+	}
+	for _, task := range cases {
+		r := Run(testContext(t), task)
+		if r.Successful || !strings.Contains(r.Data, ErrForbiddenTarget.Error()) {
+			t.Fatalf("expected %s to be refused, got %+v", task.Data, r)
+		}
+	}
+
+	if err := checkIP(net.ParseIP("1.1.1.1")); err != nil {
+		t.Fatalf("public address should be allowed: %v", err)
+	}
+}
@@ -2,18 +2,40 @@ package rpc
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
+	"log"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	petname "github.com/dustinkirkland/golang-petname"
 	"github.com/hashicorp/go-uuid"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	"github.com/nezhahq/nezha/model"
 	"github.com/nezhahq/nezha/service/singleton"
 )
 
+var (
+	errPendingApproval       = status.Error(codes.PermissionDenied, "服务器等待管理员审核")
+	errRegistrationDenied    = status.Error(codes.PermissionDenied, "服务器注册请求已被拒绝")
+	errCredentialUnsupported = status.Error(codes.FailedPrecondition, "Agent 不支持服务器凭据，请升级 Agent")
+	errMTLSRequiresTLS       = status.Error(codes.FailedPrecondition, "已强制 mTLS，请通过 HTTPS 端口连接")
+)
+
+// agentCertRenewBefore 客户端证书剩余有效期不足该时长时续签
+const agentCertRenewBefore = 30 * 24 * time.Hour
+
+// enrollMu 串行执行注册，同一令牌的并发请求共享第一次注册的结果
+var enrollMu sync.Mutex
+
 type authHandler struct {
 	ClientSecret string
 	ClientUUID   string
@@ -36,40 +58,287 @@ func (a *authHandler) Check(ctx context.Context) (uint64, error) {
 
 	ip, _ := ctx.Value(model.CtxKeyRealIP{}).(string)
 
+	// 开启 mTLS 后以客户端证书确定服务器身份，不再信任 client_uuid
+	if singleton.AgentCAShared.Enabled() {
+		if cert := peerCertificate(ctx); cert != nil {
+			clientID, ok := singleton.AgentCAShared.Lookup(cert)
+			if !ok {
+				model.BlockIP(singleton.DB, ip, model.WAFBlockReasonTypeAgentAuthFail, model.BlockIDgRPC)
+				return 0, status.Error(codes.Unauthenticated, "客户端证书无效或已吊销")
+			}
+			if _, ok := singleton.ServerShared.Get(clientID); ok {
+				model.UnblockIP(singleton.DB, ip, model.BlockIDgRPC)
+				// 证书即将过期时提前续签，已签发过更新的证书时不再重复签发
+				if time.Until(cert.NotAfter) < agentCertRenewBefore && !singleton.AgentCAShared.HasNewer(clientID, cert.NotAfter) {
+					renewCertificate(ctx, clientID, clientSecret, ip)
+				}
+				return clientID, nil
+			}
+		}
+	}
+
+	clientUUID := clientUUIDFromContext(ctx)
+	if _, err := uuid.ParseUUID(clientUUID); err != nil {
+		return 0, status.Error(codes.Unauthenticated, "客户端 UUID 不合法")
+	}
+
+	// 强制 mTLS 时未提供证书的 Agent 只能使用注册令牌注册，或使用服务器凭据续签证书
+	if singleton.AgentCAShared.Enabled() && singleton.Conf.AgentMTLS.Require {
+		if clientID, ok := singleton.ServerShared.UUIDToID(clientUUID); ok {
+			if cred, ok := singleton.AgentCredentialShared.Get(clientID); ok && cred.Verify(clientSecret) {
+				if !renewCertificate(ctx, clientID, clientSecret, ip) {
+					return 0, errMTLSRequiresTLS
+				}
+				return clientID, nil
+			}
+		}
+		return a.enroll(ctx, clientSecret, clientUUID, ip)
+	}
+
+	clientID, hasID := singleton.ServerShared.UUIDToID(clientUUID)
+	if hasID {
+		// 已绑定凭据的服务器不再接受用户密钥，凭据失效后只能使用注册令牌重新注册
+		if cred, ok := singleton.AgentCredentialShared.Get(clientID); ok {
+			if cred.Verify(clientSecret) {
+				model.UnblockIP(singleton.DB, ip, model.BlockIDgRPC)
+				singleton.AgentCredentialShared.Touch(clientID, ip)
+				singleton.AgentCredentialShared.ForgetEnrollment(clientID)
+				return clientID, nil
+			}
+			return a.enroll(ctx, clientSecret, clientUUID, ip)
+		}
+	}
+
 	singleton.UserLock.RLock()
 	userId, ok := singleton.AgentSecretToUserId[clientSecret]
-	if !ok {
-		singleton.UserLock.RUnlock()
-		model.BlockIP(singleton.DB, ip, model.WAFBlockReasonTypeAgentAuthFail, model.BlockIDgRPC)
-		return 0, status.Error(codes.Unauthenticated, "客户端认证失败")
-	}
 	singleton.UserLock.RUnlock()
+	if !ok || singleton.Conf.RequireAgentCredential {
+		return a.enroll(ctx, clientSecret, clientUUID, ip)
+	}
 
 	model.UnblockIP(singleton.DB, ip, model.BlockIDgRPC)
 
-	var clientUUID string
-	if value, ok := md["client_uuid"]; ok {
-		clientUUID = value[0]
+	if !hasID {
+		if singleton.Conf.RequireAgentApproval {
+			return 0, a.pending(clientUUID, userId, ip)
+		}
+		s, err := createServer(clientUUID, userId)
+		if err != nil {
+			return 0, status.Error(codes.Unauthenticated, err.Error())
+		}
+		clientID = s.ID
+	}
+
+	return clientID, nil
+}
+
+// hasCredential 判断 UUID 对应的服务器是否已绑定凭据
+func hasCredential(clientUUID string) bool {
+	clientID, ok := singleton.ServerShared.UUIDToID(clientUUID)
+	if !ok {
+		return false
 	}
+	_, ok = singleton.AgentCredentialShared.Get(clientID)
+	return ok
+}
 
-	if _, err := uuid.ParseUUID(clientUUID); err != nil {
-		return 0, status.Error(codes.Unauthenticated, "客户端 UUID 不合法")
+// enroll 使用注册令牌注册服务器，并通过响应头下发服务器专属凭据。
+// 注册串行执行，宽限期内相同令牌与 UUID 的重复请求返回同一凭据，只有不存在的令牌才会封禁 IP
+func (a *authHandler) enroll(ctx context.Context, token, clientUUID, ip string) (uint64, error) {
+	enrollMu.Lock()
+	defer enrollMu.Unlock()
+
+	if e, ok := singleton.AgentCredentialShared.RecentEnrollment(token, clientUUID); ok {
+		if _, ok := singleton.ServerShared.Get(e.ServerID); ok {
+			sendEnrollment(ctx, e)
+			return e.ServerID, nil
+		}
+	}
+
+	t, err := singleton.FindEnrollmentToken(token)
+	if err != nil {
+		// 凭据轮换后旧 Agent 仍会使用旧凭据重连，已绑定凭据的 UUID 不封禁 IP
+		if errors.Is(err, singleton.ErrEnrollmentTokenNotFound) && !hasCredential(clientUUID) {
+			model.BlockIP(singleton.DB, ip, model.WAFBlockReasonTypeAgentAuthFail, model.BlockIDgRPC)
+		}
+		return 0, status.Error(codes.Unauthenticated, "客户端认证失败")
+	}
+
+	// 不支持服务器凭据的 Agent 无法保存注册结果，不消耗令牌
+	if !agentSupports(ctx, model.AgentFeatureCredential) {
+		return 0, errCredentialUnsupported
+	}
+	// 强制 mTLS 时只有 TLS 连接能拿到证书，否则 Agent 之后将无法连接
+	if singleton.AgentCAShared.Enabled() && singleton.Conf.AgentMTLS.Require && !peerIsTLS(ctx) {
+		return 0, errMTLSRequiresTLS
 	}
 
 	clientID, hasID := singleton.ServerShared.UUIDToID(clientUUID)
-	if !hasID {
-		s := model.Server{UUID: clientUUID, Name: petname.Generate(2, "-"), Common: model.Common{
-			UserID: userId,
-		}}
-		if err := singleton.DB.Create(&s).Error; err != nil {
-			return 0, status.Error(codes.Unauthenticated, err.Error())
+	if !hasID && singleton.Conf.RequireAgentApproval {
+		// 开启审核时令牌同样需要审核，审核通过后 Agent 重连时才消耗令牌并下发凭据
+		return 0, a.pending(clientUUID, t.UserID, ip)
+	}
+	if hasID {
+		// 令牌只能用于注册新服务器或令牌所属用户有权限的服务器
+		server, _ := singleton.ServerShared.Get(clientID)
+		singleton.UserLock.RLock()
+		owner, ok := singleton.UserInfoMap[t.UserID]
+		isAdmin := ok && owner.Role.IsAdmin()
+		singleton.UserLock.RUnlock()
+		if server == nil || (server.UserID != t.UserID && !isAdmin) {
+			model.BlockIP(singleton.DB, ip, model.WAFBlockReasonTypeAgentAuthFail, model.BlockIDgRPC)
+			return 0, status.Error(codes.PermissionDenied, "注册令牌无权使用该 UUID")
 		}
+	}
 
-		model.InitServer(&s)
-		singleton.ServerShared.Update(&s, clientUUID)
+	if err := singleton.ConsumeEnrollmentToken(t); err != nil {
+		return 0, status.Error(codes.Unauthenticated, "客户端认证失败")
+	}
+	model.UnblockIP(singleton.DB, ip, model.BlockIDgRPC)
 
+	userID := t.UserID
+	if hasID {
+		server, _ := singleton.ServerShared.Get(clientID)
+		userID = server.UserID
+	} else {
+		s, err := createServer(clientUUID, t.UserID)
+		if err != nil {
+			return 0, status.Error(codes.Unauthenticated, err.Error())
+		}
 		clientID = s.ID
 	}
+	singleton.DB.Model(t).Update("server_id", clientID)
 
+	credential, err := singleton.AgentCredentialShared.Issue(clientID, userID)
+	if err != nil {
+		return 0, status.Error(codes.Internal, err.Error())
+	}
+	singleton.AgentCredentialShared.Touch(clientID, ip)
+	e := &singleton.Enrollment{
+		ClientUUID: clientUUID,
+		ServerID:   clientID,
+		Credential: credential,
+	}
+
+	// 证书与私钥只通过 TLS 连接下发，经反向代理终止 TLS 的连接无法使用客户端证书
+	if singleton.AgentCAShared.Enabled() && peerIsTLS(ctx) {
+		server, _ := singleton.ServerShared.Get(clientID)
+		_, issued, err := singleton.AgentCAShared.Issue(server)
+		if err != nil {
+			return 0, status.Error(codes.Internal, err.Error())
+		}
+		e.CertDER, e.KeyDER = issued.CertDER, issued.KeyDER
+	}
+
+	singleton.AgentCredentialShared.RememberEnrollment(token, e)
+	sendEnrollment(ctx, e)
 	return clientID, nil
 }
+
+// renewCertificate 为凭据有效的 Agent 重新签发客户端证书，连接不是 TLS 时返回 false
+func renewCertificate(ctx context.Context, clientID uint64, clientSecret, ip string) bool {
+	if !peerIsTLS(ctx) {
+		return false
+	}
+	cred, ok := singleton.AgentCredentialShared.Get(clientID)
+	if !ok || !cred.Verify(clientSecret) {
+		return false
+	}
+	server, ok := singleton.ServerShared.Get(clientID)
+	if !ok {
+		return false
+	}
+	_, issued, err := singleton.AgentCAShared.Issue(server)
+	if err != nil {
+		log.Printf("NEZHA>> Failed to renew certificate of server %d: %v", clientID, err)
+		return false
+	}
+
+	model.UnblockIP(singleton.DB, ip, model.BlockIDgRPC)
+	singleton.AgentCredentialShared.Touch(clientID, ip)
+	header := metadata.Pairs(model.MetadataKeyAgentCertificate, string(issued.CertDER))
+	header.Append(model.MetadataKeyAgentKey, string(issued.KeyDER))
+	if err := grpc.SendHeader(ctx, header); err != nil {
+		log.Printf("NEZHA>> Failed to send certificate to server %d: %v", clientID, err)
+	}
+	return true
+}
+
+// sendEnrollment 通过响应头下发注册结果
+func sendEnrollment(ctx context.Context, e *singleton.Enrollment) {
+	header := metadata.Pairs(model.MetadataKeyAgentCredential, e.Credential)
+	if len(e.CertDER) > 0 && peerIsTLS(ctx) {
+		header.Append(model.MetadataKeyAgentCertificate, string(e.CertDER))
+		header.Append(model.MetadataKeyAgentKey, string(e.KeyDER))
+	}
+	if err := grpc.SendHeader(ctx, header); err != nil {
+		log.Printf("NEZHA>> Failed to send credential to server %d: %v", e.ServerID, err)
+	}
+}
+
+// agentSupports 判断 Agent 是否声明支持某项功能
+func agentSupports(ctx context.Context, feature string) bool {
+	return slices.Contains(agentFeatures(ctx), feature)
+}
+
+// agentFeatures Agent 在请求元数据中声明支持的功能
+func agentFeatures(ctx context.Context) []string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return model.ParseAgentFeatures(md.Get(model.MetadataKeyAgentFeatures))
+}
+
+// pending 将未知 Agent 加入审核列表，审核通过前拒绝其所有请求
+func (a *authHandler) pending(clientUUID string, userID uint64, ip string) error {
+	p, err := singleton.PendingServerShared.Register(clientUUID, userID, ip)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if p.Rejected {
+		return errRegistrationDenied
+	}
+	return errPendingApproval
+}
+
+// peerIsTLS 判断 Agent 的连接本身是否经过 TLS
+func peerIsTLS(ctx context.Context) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	_, ok = p.AuthInfo.(credentials.TLSInfo)
+	return ok
+}
+
+// peerCertificate 返回已通过 CA 校验的客户端证书
+func peerCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.PeerCertificates) == 0 {
+		return nil
+	}
+	return info.State.PeerCertificates[0]
+}
+
+func clientUUIDFromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if value := md.Get("client_uuid"); len(value) > 0 {
+		return value[0]
+	}
+	return ""
+}
+
+func createServer(clientUUID string, userID uint64) (*model.Server, error) {
+	s := model.Server{UUID: clientUUID, Name: petname.Generate(2, "-"), Common: model.Common{
+		UserID: userID,
+	}}
+	if err := singleton.DB.Create(&s).Error; err != nil {
+		return nil, err
+	}
+
+	model.InitServer(&s)
+	singleton.ServerShared.Update(&s, clientUUID)
+	return &s, nil
+}
@@ -0,0 +1,51 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+func TestAgentSupports(t *testing.T) {
+	cases := []struct {
+		features []string
+		want     bool
+	}{
+		{nil, false},
+		{[]string{""}, false},
+		{[]string{"mtls"}, false},
+		{[]string{"mtls, credential"}, true},
+		{[]string{"mtls", "credential"}, true},
+		{[]string{"credentials"}, false},
+	}
+
+	for _, c := range cases {
+		md := metadata.MD{}
+		for _, f := range c.features {
+			md.Append(model.MetadataKeyAgentFeatures, f)
+		}
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		if got := agentSupports(ctx, model.AgentFeatureCredential); got != c.want {
+			t.Fatalf("agentSupports(%v) = %v, want %v", c.features, got, c.want)
+		}
+	}
+}
+
+func TestPeerIsTLS(t *testing.T) {
+	if peerIsTLS(context.Background()) {
+		t.Fatal("context without peer should not be TLS")
+	}
+	plain := peer.NewContext(context.Background(), &peer.Peer{})
+	if peerIsTLS(plain) {
+		t.Fatal("plaintext peer should not be TLS")
+	}
+	secure := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{}})
+	if !peerIsTLS(secure) {
+		t.Fatal("TLS peer should be TLS")
+	}
+}
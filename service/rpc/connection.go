@@ -0,0 +1,206 @@
+package rpc
+
+import (
+	"cmp"
+	"context"
+	"net/netip"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+var errDisconnected = status.Error(codes.Aborted, "连接已被管理员断开")
+
+type agentConn struct {
+	id          uint64
+	serverID    uint64
+	stream      string
+	ip          string
+	connectedAt time.Time
+	lastRecv    atomic.Int64 // UnixNano
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// touch 记录最近一次收到 Agent 数据的时间
+func (c *agentConn) touch() {
+	c.lastRecv.Store(time.Now().UnixNano())
+}
+
+func (c *agentConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+}
+
+// serve 在独立的 goroutine 中接收数据，连接被强制断开时立即返回以结束流
+func (c *agentConn) serve(recv func() error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- recv()
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-c.closed:
+		return errDisconnected
+	}
+}
+
+type connKey struct {
+	serverID uint64
+	stream   string
+}
+
+type dailyCount struct {
+	day   string
+	count uint64
+}
+
+// connRegistry 记录 Agent 当前的任务流与状态流
+type connRegistry struct {
+	mu     sync.RWMutex
+	nextID uint64
+	conns  map[uint64]*agentConn
+	opens  map[connKey]*dailyCount
+}
+
+func newConnRegistry() *connRegistry {
+	return &connRegistry{
+		conns: make(map[uint64]*agentConn),
+		opens: make(map[connKey]*dailyCount),
+	}
+}
+
+func (r *connRegistry) open(serverID uint64, stream, ip string) *agentConn {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	c := &agentConn{
+		id:          r.nextID,
+		serverID:    serverID,
+		stream:      stream,
+		ip:          ip,
+		connectedAt: now,
+		closed:      make(chan struct{}),
+	}
+	c.lastRecv.Store(now.UnixNano())
+	r.conns[c.id] = c
+
+	key := connKey{serverID: serverID, stream: stream}
+	day := now.In(singleton.Loc).Format(time.DateOnly)
+	if dc, ok := r.opens[key]; ok && dc.day == day {
+		dc.count++
+	} else {
+		r.opens[key] = &dailyCount{day: day, count: 1}
+	}
+	return c
+}
+
+func (r *connRegistry) remove(c *agentConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.conns, c.id)
+}
+
+// reconnects 当天的重连次数，第一次连接不计入
+func (r *connRegistry) reconnects(key connKey, now time.Time) uint64 {
+	dc, ok := r.opens[key]
+	if !ok || dc.day != now.In(singleton.Loc).Format(time.DateOnly) || dc.count == 0 {
+		return 0
+	}
+	return dc.count - 1
+}
+
+func (r *connRegistry) list() []*model.AgentConnection {
+	now := time.Now()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]*model.AgentConnection, 0, len(r.conns))
+	for _, c := range r.conns {
+		list = append(list, &model.AgentConnection{
+			ID:              c.id,
+			ServerID:        c.serverID,
+			Stream:          c.stream,
+			IP:              c.ip,
+			ConnectedAt:     c.connectedAt,
+			LastReceivedAt:  time.Unix(0, c.lastRecv.Load()),
+			ReconnectsToday: r.reconnects(connKey{serverID: c.serverID, stream: c.stream}, now),
+		})
+	}
+	slices.SortFunc(list, func(a, b *model.AgentConnection) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return list
+}
+
+// disconnect 关闭服务器的所有流，返回关闭的流数量
+func (r *connRegistry) disconnect(serverID uint64) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var n int
+	for _, c := range r.conns {
+		if c.serverID == serverID {
+			c.close()
+			n++
+		}
+	}
+	return n
+}
+
+// AgentConnections 当前连接的 Agent 流
+func (s *NezhaHandler) AgentConnections() []*model.AgentConnection {
+	list := s.conns.list()
+	for _, c := range list {
+		if server, ok := singleton.ServerShared.Get(c.ServerID); ok && server != nil {
+			c.ServerName = server.Name
+			if server.Host != nil {
+				c.AgentVersion = server.Host.Version
+			}
+		}
+	}
+	return list
+}
+
+// DisconnectAgent 强制断开服务器的任务流与状态流，返回断开的流数量
+func (s *NezhaHandler) DisconnectAgent(serverID uint64) int {
+	return s.conns.disconnect(serverID)
+}
+
+// streamIP 流式调用不经过 getRealIp 拦截器，在这里按相同的规则取 Agent IP
+func streamIP(ctx context.Context) string {
+	switch singleton.Conf.AgentRealIPHeader {
+	case "", model.ConfigUsePeerIP:
+	default:
+		if vals := metadata.ValueFromIncomingContext(ctx, singleton.Conf.AgentRealIPHeader); len(vals) > 0 {
+			if ip, err := utils.GetIPFromHeader(vals[0]); err == nil {
+				return ip
+			}
+		}
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	addrPort, err := netip.ParseAddrPort(p.Addr.String())
+	if err != nil {
+		return ""
+	}
+	return addrPort.Addr().String()
+}
@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+func TestConnRegistry(t *testing.T) {
+	singleton.Loc = time.UTC
+	r := newConnRegistry()
+
+	first := r.open(1, model.AgentStreamTask, "127.0.0.1")
+	r.remove(first)
+	task := r.open(1, model.AgentStreamTask, "127.0.0.1")
+	state := r.open(1, model.AgentStreamState, "127.0.0.1")
+	other := r.open(2, model.AgentStreamTask, "127.0.0.2")
+
+	list := r.list()
+	if len(list) != 3 {
+		t.Fatalf("expected 3 connections, got %d", len(list))
+	}
+	if list[0].ID != task.id || list[0].ReconnectsToday != 1 {
+		t.Fatalf("expected the task stream to have reconnected once, got %+v", list[0])
+	}
+	if list[1].ReconnectsToday != 0 || list[2].ReconnectsToday != 0 {
+		t.Fatalf("unexpected reconnects %+v %+v", list[1], list[2])
+	}
+
+	block := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- task.serve(func() error {
+			<-block
+			return nil
+		})
+	}()
+	if n := r.disconnect(1); n != 2 {
+		t.Fatalf("expected 2 streams to be closed, got %d", n)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, errDisconnected) {
+			t.Fatalf("unexpected error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("serve did not return after disconnect")
+	}
+	close(block)
+
+	select {
+	case <-state.closed:
+	default:
+		t.Fatal("expected the state stream to be closed")
+	}
+	select {
+	case <-other.closed:
+		t.Fatal("expected the other server to stay connected")
+	default:
+	}
+}
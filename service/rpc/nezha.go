@@ -7,6 +7,7 @@ import (
 	"log"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jinzhu/copier"
@@ -26,6 +27,9 @@ type NezhaHandler struct {
 	Auth          *authHandler
 	ioStreams     map[string]*ioStreamContext
 	ioStreamMutex *sync.RWMutex
+	conns         *connRegistry
+
+	taskStreamCount atomic.Int64 // 当前连接的 Agent 任务流数量
 }
 
 func NewNezhaHandler() *NezhaHandler {
@@ -33,9 +37,15 @@ func NewNezhaHandler() *NezhaHandler {
 		Auth:          &authHandler{},
 		ioStreamMutex: new(sync.RWMutex),
 		ioStreams:     make(map[string]*ioStreamContext),
+		conns:         newConnRegistry(),
 	}
 }
 
+// TaskStreamCount 返回当前连接的 Agent 任务流数量
+func (s *NezhaHandler) TaskStreamCount() int64 {
+	return s.taskStreamCount.Load()
+}
+
 func (s *NezhaHandler) RequestTask(stream pb.NezhaService_RequestTaskServer) error {
 	var clientID uint64
 	var err error
@@ -43,53 +53,75 @@ func (s *NezhaHandler) RequestTask(stream pb.NezhaService_RequestTaskServer) err
 		return err
 	}
 
+	s.taskStreamCount.Add(1)
+	defer s.taskStreamCount.Add(-1)
+
+	conn := s.conns.open(clientID, model.AgentStreamTask, streamIP(stream.Context()))
+	defer s.conns.remove(conn)
+
 	server, _ := singleton.ServerShared.Get(clientID)
 	server.TaskStream = stream
-	var result *pb.TaskResult
-	for {
-		result, err = stream.Recv()
-		if err != nil {
-			log.Printf("NEZHA>> RequestTask error: %v, clientID: %d\n", err, clientID)
-			return err
-		}
-		switch result.GetType() {
-		case model.TaskTypeCommand:
-			// 处理上报的计划任务
-			cr, _ := singleton.CronShared.Get(result.GetId())
-			if cr != nil {
-				// 保存当前服务器状态信息
-				var curServer model.Server
-				copier.Copy(&curServer, server)
-				if cr.PushSuccessful && result.GetSuccessful() {
-					singleton.NotificationShared.SendNotification(cr.NotificationGroupID, fmt.Sprintf("[%s] %s, %s\n%s", singleton.Localizer.T("Scheduled Task Executed Successfully"),
-						cr.Name, server.Name, result.GetData()), "", &curServer)
+	server.AgentFeatures = agentFeatures(stream.Context())
+	detach := singleton.TaskQueueShared.Attach(clientID, stream)
+	defer detach()
+
+	return conn.serve(func() error {
+		for {
+			result, err := stream.Recv()
+			if err != nil {
+				log.Printf("NEZHA>> RequestTask error: %v, clientID: %d\n", err, clientID)
+				return err
+			}
+			conn.touch()
+			singleton.TaskQueueShared.Ack(clientID, result)
+			switch result.GetType() {
+			case model.TaskTypeCommand:
+				// 处理上报的计划任务
+				cr, _ := singleton.CronShared.Get(result.GetId())
+				if cr != nil {
+					// 保存当前服务器状态信息
+					var curServer model.Server
+					copier.Copy(&curServer, server)
+					if cr.PushSuccessful && result.GetSuccessful() {
+						singleton.NotificationShared.SendNotification(cr.NotificationGroupID, model.NotificationEvent{
+							Source:   model.NotificationSourceCron,
+							Rule:     cr.Name,
+							Severity: model.NotificationSeverityInfo,
+						}, fmt.Sprintf("[%s] %s, %s\n%s", singleton.Localizer.T("Scheduled Task Executed Successfully"),
+							cr.Name, server.Name, result.GetData()), "", &curServer)
+					}
+					if !result.GetSuccessful() {
+						singleton.NotificationShared.SendNotification(cr.NotificationGroupID, model.NotificationEvent{
+							Source:   model.NotificationSourceCron,
+							Rule:     cr.Name,
+							Severity: model.NotificationSeverityWarning,
+							Error:    result.GetData(),
+						}, fmt.Sprintf("[%s] %s, %s\n%s", singleton.Localizer.T("Scheduled Task Executed Failed"),
+							cr.Name, server.Name, result.GetData()), "", &curServer)
+					}
+					singleton.DB.Model(cr).Updates(model.Cron{
+						LastExecutedAt: time.Now().Add(time.Second * -1 * time.Duration(result.GetDelay())),
+						LastResult:     result.GetSuccessful(),
+					})
 				}
-				if !result.GetSuccessful() {
-					singleton.NotificationShared.SendNotification(cr.NotificationGroupID, fmt.Sprintf("[%s] %s, %s\n%s", singleton.Localizer.T("Scheduled Task Executed Failed"),
-						cr.Name, server.Name, result.GetData()), "", &curServer)
+			case model.TaskTypeReportConfig:
+				if len(server.ConfigCache) < 1 {
+					if !result.GetSuccessful() {
+						server.ConfigCache <- errors.New(result.Data)
+						continue
+					}
+					server.ConfigCache <- result.Data
 				}
-				singleton.DB.Model(cr).Updates(model.Cron{
-					LastExecutedAt: time.Now().Add(time.Second * -1 * time.Duration(result.GetDelay())),
-					LastResult:     result.GetSuccessful(),
-				})
-			}
-		case model.TaskTypeReportConfig:
-			if len(server.ConfigCache) < 1 {
-				if !result.GetSuccessful() {
-					server.ConfigCache <- errors.New(result.Data)
-					continue
+			default:
+				if model.IsServiceSentinelNeeded(result.GetType()) {
+					singleton.ServiceSentinelShared.Dispatch(singleton.ReportData{
+						Data:     result,
+						Reporter: clientID,
+					})
 				}
-				server.ConfigCache <- result.Data
-			}
-		default:
-			if model.IsServiceSentinelNeeded(result.GetType()) {
-				singleton.ServiceSentinelShared.Dispatch(singleton.ReportData{
-					Data:     result,
-					Reporter: clientID,
-				})
 			}
 		}
-	}
+	})
 }
 
 func (s *NezhaHandler) ReportSystemState(stream pb.NezhaService_ReportSystemStateServer) error {
@@ -97,39 +129,52 @@ func (s *NezhaHandler) ReportSystemState(stream pb.NezhaService_ReportSystemStat
 	if err != nil {
 		return err
 	}
-	var state *pb.State
-	for {
-		state, err = stream.Recv()
-		if err != nil {
-			log.Printf("NEZHA>> ReportSystemState error: %v, clientID: %d\n", err, clientID)
-			return err
-		}
-		innerState := model.PB2State(state)
+	conn := s.conns.open(clientID, model.AgentStreamState, streamIP(stream.Context()))
+	defer s.conns.remove(conn)
 
-		server, ok := singleton.ServerShared.Get(clientID)
-		if !ok || server == nil {
-			return errors.New("server not found")
-		}
+	return conn.serve(func() error {
+		for {
+			state, err := stream.Recv()
+			if err != nil {
+				log.Printf("NEZHA>> ReportSystemState error: %v, clientID: %d\n", err, clientID)
+				return err
+			}
+			conn.touch()
+			innerState := model.PB2State(state)
 
-		server.LastActive = time.Now()
-		server.State = &innerState
+			server, ok := singleton.ServerShared.Get(clientID)
+			if !ok || server == nil {
+				return errors.New("server not found")
+			}
 
-		// 应对 dashboard / agent 重启的情况，如果从未记录过，先打点，等到小时时间点时入库
-		if server.PrevTransferInSnapshot == 0 || server.PrevTransferOutSnapshot == 0 {
-			server.PrevTransferInSnapshot = state.NetInTransfer
-			server.PrevTransferOutSnapshot = state.NetOutTransfer
-		}
+			server.LastActive = time.Now()
+			server.State = &innerState
+			singleton.ServerMetricShared.Record(clientID, &innerState)
 
-		if err = stream.Send(&pb.Receipt{Proced: true}); err != nil {
-			return err
+			// 应对 dashboard / agent 重启的情况，如果从未记录过，先打点，等到小时时间点时入库
+			if server.PrevTransferInSnapshot == 0 || server.PrevTransferOutSnapshot == 0 {
+				server.PrevTransferInSnapshot = state.NetInTransfer
+				server.PrevTransferOutSnapshot = state.NetOutTransfer
+			}
+
+			if err := stream.Send(&pb.Receipt{Proced: true}); err != nil {
+				return err
+			}
 		}
-	}
+	})
 }
 
 func (s *NezhaHandler) onReportSystemInfo(c context.Context, r *pb.Host) error {
 	var clientID uint64
 	var err error
 	if clientID, err = s.Auth.Check(c); err != nil {
+		// 等待审核的 Agent 只记录主机信息，供管理员审核时参考
+		if errors.Is(err, errPendingApproval) {
+			host := model.PB2Host(r)
+			if err := singleton.PendingServerShared.UpdateHost(clientUUIDFromContext(c), &host); err != nil {
+				log.Printf("NEZHA>> Failed to save host of pending server: %v", err)
+			}
+		}
 		return err
 	}
 	host := model.PB2Host(r)
@@ -250,6 +295,13 @@ func (s *NezhaHandler) ReportGeoIP(c context.Context, r *pb.GeoIP) (*pb.GeoIP, e
 		server.GeoIP.IP != geoip.IP {
 
 		singleton.NotificationShared.SendNotification(singleton.Conf.IPChangeNotificationGroupID,
+			model.NotificationEvent{
+				Source:    model.NotificationSourceIPChange,
+				Severity:  model.NotificationSeverityInfo,
+				ServerID:  server.ID,
+				State:     singleton.IPDesensitize(joinedIP),
+				PrevState: singleton.IPDesensitize(server.GeoIP.IP.Join()),
+			},
 			fmt.Sprintf(
 				"[%s] %s, %s => %s",
 				singleton.Localizer.T("IP Changed"),
@@ -0,0 +1,230 @@
+package singleton
+
+import (
+	"cmp"
+	"crypto/x509"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/mtls"
+)
+
+const (
+	agentCAValidity  = 10 * 365 * 24 * time.Hour
+	agentCRLValidity = 7 * 24 * time.Hour
+)
+
+// AgentCAClass 签发与校验 Agent 客户端证书，未开启 mTLS 时不加载 CA
+type AgentCAClass struct {
+	ca *mtls.CA
+
+	mu    sync.RWMutex
+	certs map[string]*model.AgentCertificate // 序列号 -> 证书
+}
+
+func NewAgentCAClass() (*AgentCAClass, error) {
+	c := &AgentCAClass{
+		certs: make(map[string]*model.AgentCertificate),
+	}
+	if !Conf.AgentMTLS.Enable {
+		return c, nil
+	}
+
+	var row model.AgentCA
+	if err := DB.Limit(1).Find(&row).Error; err != nil {
+		return nil, err
+	}
+	if row.ID == 0 {
+		certPEM, keyPEM, err := mtls.NewCA("Nezha Agent CA", agentCAValidity)
+		if err != nil {
+			return nil, err
+		}
+		row = model.AgentCA{CertPEM: string(certPEM), KeyPEM: string(keyPEM)}
+		if err := DB.Create(&row).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	ca, err := mtls.LoadCA([]byte(row.CertPEM), []byte(row.KeyPEM))
+	if err != nil {
+		return nil, err
+	}
+	c.ca = ca
+
+	var certs []*model.AgentCertificate
+	if err := DB.Find(&certs).Error; err != nil {
+		return nil, err
+	}
+	for _, cert := range certs {
+		c.certs[cert.Serial] = cert
+	}
+	return c, nil
+}
+
+// Enabled 是否已开启 mTLS
+func (c *AgentCAClass) Enabled() bool {
+	return c.ca != nil
+}
+
+// CertPool 用于 HTTPS 端口校验客户端证书的证书池
+func (c *AgentCAClass) CertPool() *x509.CertPool {
+	return c.ca.CertPool()
+}
+
+// CACertificate PEM 编码的 CA 证书
+func (c *AgentCAClass) CACertificate() string {
+	return string(mtls.EncodeCert(c.ca.Cert.Raw))
+}
+
+// Issue 为服务器签发客户端证书，证书的 CommonName 为服务器 UUID
+func (c *AgentCAClass) Issue(server *model.Server) (*model.AgentCertificate, *mtls.Issued, error) {
+	if !c.Enabled() {
+		return nil, nil, Localizer.ErrorT("agent mtls is not enabled")
+	}
+
+	issued, err := c.ca.Issue(server.UUID, time.Duration(Conf.AgentMTLS.CertValidity)*24*time.Hour)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert := &model.AgentCertificate{
+		Common:   model.Common{UserID: server.UserID},
+		ServerID: server.ID,
+		Serial:   mtls.SerialString(issued.Serial),
+		NotAfter: issued.NotAfter,
+	}
+	if err := DB.Create(cert).Error; err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.certs[cert.Serial] = cert
+	return cert, issued, nil
+}
+
+// Lookup 返回客户端证书对应的服务器，证书未登记、已过期或已吊销时返回 false
+func (c *AgentCAClass) Lookup(cert *x509.Certificate) (uint64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ac, ok := c.certs[mtls.SerialString(cert.SerialNumber)]
+	if !ok || !ac.Valid(time.Now()) {
+		return 0, false
+	}
+	return ac.ServerID, true
+}
+
+// HasNewer 判断服务器是否已有比 notAfter 更晚过期的有效证书
+func (c *AgentCAClass) HasNewer(serverID uint64, notAfter time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	for _, ac := range c.certs {
+		if ac.ServerID == serverID && ac.Valid(now) && ac.NotAfter.After(notAfter) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSortedList 按 ID 排序的已签发证书
+func (c *AgentCAClass) GetSortedList() []*model.AgentCertificate {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	list := make([]*model.AgentCertificate, 0, len(c.certs))
+	for _, cert := range c.certs {
+		cp := *cert
+		list = append(list, &cp)
+	}
+	slices.SortFunc(list, func(a, b *model.AgentCertificate) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return list
+}
+
+// Get 按 ID 查找已签发的证书
+func (c *AgentCAClass) Get(id uint64) (model.AgentCertificate, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, cert := range c.certs {
+		if cert.ID == id {
+			return *cert, true
+		}
+	}
+	return model.AgentCertificate{}, false
+}
+
+// Revoke 吊销所有满足 filter 的证书
+func (c *AgentCAClass) Revoke(filter func(*model.AgentCertificate) bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	var serials []string
+	for serial, cert := range c.certs {
+		if !cert.Revoked() && filter(cert) {
+			serials = append(serials, serial)
+		}
+	}
+	if len(serials) == 0 {
+		return nil
+	}
+	if err := DB.Model(&model.AgentCertificate{}).Where("serial IN (?)", serials).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	for _, serial := range serials {
+		cp := *c.certs[serial]
+		cp.RevokedAt = &now
+		c.certs[serial] = &cp
+	}
+	return nil
+}
+
+// RevokeByServer 吊销服务器的所有证书，删除服务器或吊销其凭据时调用
+func (c *AgentCAClass) RevokeByServer(idList []uint64) error {
+	return c.Revoke(func(cert *model.AgentCertificate) bool {
+		return slices.Contains(idList, cert.ServerID)
+	})
+}
+
+// CRL 生成包含所有未过期的已吊销证书的吊销列表
+func (c *AgentCAClass) CRL() (string, error) {
+	if !c.Enabled() {
+		return "", Localizer.ErrorT("agent mtls is not enabled")
+	}
+
+	now := time.Now()
+	revoked := make(map[string]time.Time)
+	c.mu.RLock()
+	for serial, cert := range c.certs {
+		if cert.Revoked() && now.Before(cert.NotAfter) {
+			revoked[serial] = *cert.RevokedAt
+		}
+	}
+	c.mu.RUnlock()
+
+	var number int64
+	if err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.AgentCA{}).Where("1 = 1").
+			UpdateColumn("crl_number", gorm.Expr("crl_number + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.AgentCA{}).Select("crl_number").Limit(1).Scan(&number).Error
+	}); err != nil {
+		return "", err
+	}
+
+	crl, err := c.ca.CRL(number, revoked, agentCRLValidity)
+	if err != nil {
+		return "", err
+	}
+	return string(crl), nil
+}
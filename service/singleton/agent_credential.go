@@ -0,0 +1,264 @@
+package singleton
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+const (
+	// 凭据最近使用信息的落库间隔，避免每次认证都写数据库
+	agentCredentialTouchInterval = time.Minute
+	// 注册成功后的宽限期，期间相同令牌与 UUID 的重复注册返回同一结果
+	agentEnrollmentGracePeriod = 2 * time.Minute
+)
+
+var (
+	// ErrEnrollmentTokenNotFound 不存在的注册令牌，与已使用或已过期的令牌区分
+	ErrEnrollmentTokenNotFound = errors.New("enrollment token not found")
+	errEnrollmentTokenUnusable = errors.New("enrollment token is invalid, expired or already used")
+)
+
+// Enrollment 一次注册的结果，只在宽限期内保存在内存中
+type Enrollment struct {
+	ClientUUID string
+	ServerID   uint64
+	Credential string
+	CertDER    []byte
+	KeyDER     []byte
+
+	expiresAt time.Time
+}
+
+// AgentCredentialClass 服务器专属的 Agent 凭据
+type AgentCredentialClass struct {
+	mu          sync.RWMutex
+	credentials map[uint64]*model.ServerCredential // server id -> 凭据
+	touchedAt   map[uint64]time.Time               // 最近一次将使用信息落库的时间
+	enrollments map[string]*Enrollment             // 注册令牌哈希 -> 宽限期内的注册结果
+}
+
+func NewAgentCredentialClass() *AgentCredentialClass {
+	var credentials []*model.ServerCredential
+	DB.Find(&credentials)
+
+	c := &AgentCredentialClass{
+		credentials: make(map[uint64]*model.ServerCredential, len(credentials)),
+		touchedAt:   make(map[uint64]time.Time),
+		enrollments: make(map[string]*Enrollment),
+	}
+	for _, cred := range credentials {
+		c.credentials[cred.ServerID] = cred
+	}
+	return c
+}
+
+// Get 返回服务器的凭据副本，服务器尚未绑定凭据时返回 false
+func (c *AgentCredentialClass) Get(serverID uint64) (model.ServerCredential, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cred, ok := c.credentials[serverID]
+	if !ok {
+		return model.ServerCredential{}, false
+	}
+	return *cred, true
+}
+
+// GetSortedList 按服务器 ID 排序的凭据列表
+func (c *AgentCredentialClass) GetSortedList() []*model.ServerCredential {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	list := make([]*model.ServerCredential, 0, len(c.credentials))
+	for _, cred := range c.credentials {
+		cp := *cred
+		list = append(list, &cp)
+	}
+	slices.SortFunc(list, func(a, b *model.ServerCredential) int {
+		return cmp.Compare(a.ServerID, b.ServerID)
+	})
+	return list
+}
+
+// Issue 为服务器生成新的凭据并替换旧凭据，返回凭据明文
+func (c *AgentCredentialClass) Issue(serverID, userID uint64) (string, error) {
+	secret, err := utils.GenerateRandomString(model.AgentCredentialLength)
+	if err != nil {
+		return "", err
+	}
+
+	cred := &model.ServerCredential{
+		Common:     model.Common{UserID: userID},
+		ServerID:   serverID,
+		SecretHash: model.HashAgentSecret(secret),
+	}
+	if err := DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "server_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"user_id":      userID,
+			"secret_hash":  cred.SecretHash,
+			"revoked_at":   nil,
+			"last_used_at": nil,
+			"last_used_ip": "",
+			"updated_at":   time.Now(),
+		}),
+	}).Create(cred).Error; err != nil {
+		return "", err
+	}
+	if err := DB.Where("server_id = ?", serverID).First(cred).Error; err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.credentials[serverID] = cred
+	delete(c.touchedAt, serverID)
+	c.forgetEnrollment(serverID)
+	return secret, nil
+}
+
+// RememberEnrollment 记录注册结果，Agent 未收到响应头而重试时可以取回同一凭据
+func (c *AgentCredentialClass) RememberEnrollment(token string, e *Enrollment) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, v := range c.enrollments {
+		if now.After(v.expiresAt) {
+			delete(c.enrollments, k)
+		}
+	}
+	e.expiresAt = now.Add(agentEnrollmentGracePeriod)
+	c.enrollments[model.HashAgentSecret(token)] = e
+}
+
+// RecentEnrollment 返回宽限期内相同令牌与 UUID 的注册结果
+func (c *AgentCredentialClass) RecentEnrollment(token, clientUUID string) (*Enrollment, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.enrollments[model.HashAgentSecret(token)]
+	if !ok || e.ClientUUID != clientUUID || time.Now().After(e.expiresAt) {
+		return nil, false
+	}
+	return e, true
+}
+
+// ForgetEnrollment Agent 已使用凭据连接后不再保留注册结果
+func (c *AgentCredentialClass) ForgetEnrollment(serverID uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.forgetEnrollment(serverID)
+}
+
+func (c *AgentCredentialClass) forgetEnrollment(serverID uint64) {
+	for k, v := range c.enrollments {
+		if v.ServerID == serverID {
+			delete(c.enrollments, k)
+		}
+	}
+}
+
+// Revoke 吊销服务器的凭据，之后该服务器只能使用新的注册令牌重新注册
+func (c *AgentCredentialClass) Revoke(serverID uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cred, ok := c.credentials[serverID]
+	if !ok {
+		return Localizer.ErrorT("server %d has no credential", serverID)
+	}
+	if cred.Revoked() {
+		return nil
+	}
+
+	now := time.Now()
+	if err := DB.Model(&model.ServerCredential{}).Where("server_id = ?", serverID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	cp := *cred
+	cp.RevokedAt = &now
+	c.credentials[serverID] = &cp
+	c.forgetEnrollment(serverID)
+	return nil
+}
+
+// Touch 记录凭据最近一次的使用时间与来源 IP
+func (c *AgentCredentialClass) Touch(serverID uint64, ip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cred, ok := c.credentials[serverID]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	cp := *cred
+	cp.LastUsedAt = &now
+	cp.LastUsedIP = ip
+	c.credentials[serverID] = &cp
+
+	if cred.LastUsedIP == ip && now.Sub(c.touchedAt[serverID]) < agentCredentialTouchInterval {
+		return
+	}
+	c.touchedAt[serverID] = now
+	DB.Model(&model.ServerCredential{}).Where("server_id = ?", serverID).
+		UpdateColumns(map[string]any{"last_used_at": now, "last_used_ip": ip})
+}
+
+// Delete 删除服务器时一并删除其凭据
+func (c *AgentCredentialClass) Delete(idList []uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	DB.Unscoped().Delete(&model.ServerCredential{}, "server_id IN (?)", idList)
+	for _, id := range idList {
+		delete(c.credentials, id)
+		delete(c.touchedAt, id)
+		c.forgetEnrollment(id)
+	}
+}
+
+// FindEnrollmentToken 按明文查找可用的注册令牌
+func FindEnrollmentToken(token string) (*model.AgentEnrollmentToken, error) {
+	if token == "" {
+		return nil, errEnrollmentTokenUnusable
+	}
+
+	var t model.AgentEnrollmentToken
+	result := DB.Where("token_hash = ?", model.HashAgentSecret(token)).Limit(1).Find(&t)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrEnrollmentTokenNotFound
+	}
+	if !t.Usable(time.Now()) {
+		return nil, errEnrollmentTokenUnusable
+	}
+	return &t, nil
+}
+
+// ConsumeEnrollmentToken 将注册令牌标记为已使用，并发使用同一令牌时只有一次能成功
+func ConsumeEnrollmentToken(t *model.AgentEnrollmentToken) error {
+	now := time.Now()
+	result := DB.Model(&model.AgentEnrollmentToken{}).Where("id = ? AND used_at IS NULL", t.ID).
+		UpdateColumn("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return errEnrollmentTokenUnusable
+	}
+	t.UsedAt = &now
+	return nil
+}
@@ -3,10 +3,13 @@ package singleton
 import (
 	"fmt"
 	"log"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jinzhu/copier"
+	"gorm.io/gorm"
 
 	"github.com/nezhahq/nezha/model"
 )
@@ -29,6 +32,8 @@ var (
 	alertsStore                   map[uint64]map[uint64][][]bool       // [alert_id][server_id] -> [timeTick][ruleId] 时间点对应的rule的检查结果
 	alertsPrevState               map[uint64]map[uint64]uint8          // [alert_id][server_id] -> 对应报警规则的上一次报警状态
 	AlertsCycleTransferStatsStore map[uint64]*model.CycleTransferStats // [alert_id] -> 对应报警规则的周期流量统计
+
+	alertStateLoaded atomic.Bool // 快照恢复前不保存，避免覆盖上一次的快照
 )
 
 // addCycleTransferStatsInfo 向AlertsCycleTransferStatsStore中添加周期流量报警统计信息
@@ -71,6 +76,7 @@ func AlertSentinelStart() {
 		alertsPrevState[alert.ID] = make(map[uint64]uint8)
 		addCycleTransferStatsInfo(alert)
 	}
+	loadAlertState()
 	AlertsLock.Unlock()
 
 	time.Sleep(time.Second * 10)
@@ -90,6 +96,71 @@ func AlertSentinelStart() {
 	}
 }
 
+// loadAlertState 从快照恢复各报警规则的检查结果与报警状态，调用时需持有 AlertsLock
+func loadAlertState() {
+	defer alertStateLoaded.Store(true)
+
+	var states []model.AlertState
+	if err := DB.Find(&states).Error; err != nil {
+		log.Printf("NEZHA>> Failed to load alert state: %v", err)
+		return
+	}
+
+	ruleCount := make(map[uint64]int, len(Alerts))
+	for _, alert := range Alerts {
+		ruleCount[alert.ID] = len(alert.Rules)
+	}
+	for _, state := range states {
+		count, ok := ruleCount[state.AlertRuleID]
+		if !ok {
+			continue
+		}
+		// 丢弃与当前规则数量不一致的检查结果
+		samples := state.Samples[:0]
+		for _, sample := range state.Samples {
+			if len(sample) == count {
+				samples = append(samples, sample)
+			}
+		}
+		alertsStore[state.AlertRuleID][state.ServerID] = samples
+		alertsPrevState[state.AlertRuleID][state.ServerID] = state.PrevState
+	}
+}
+
+// SaveAlertState 将各报警规则的检查结果与报警状态保存为快照
+func SaveAlertState() {
+	if !alertStateLoaded.Load() {
+		return
+	}
+
+	AlertsLock.Lock()
+	var states []model.AlertState
+	for alertID, servers := range alertsStore {
+		for serverID, samples := range servers {
+			states = append(states, model.AlertState{
+				AlertRuleID: alertID,
+				ServerID:    serverID,
+				PrevState:   alertsPrevState[alertID][serverID],
+				Samples:     slices.Clone(samples),
+			})
+		}
+	}
+	AlertsLock.Unlock()
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.AlertState{}).Error; err != nil {
+			return err
+		}
+		if len(states) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(states, 100).Error
+	})
+	if err != nil {
+		log.Printf("NEZHA>> Failed to save alert state: %v", err)
+	}
+}
+
 func OnRefreshOrAddAlert(alert *model.AlertRule) {
 	AlertsLock.Lock()
 	defer AlertsLock.Unlock()
@@ -126,6 +197,7 @@ func OnDeleteAlert(id []uint64) {
 		Alerts = currentAlerts
 		delete(AlertsCycleTransferStatsStore, i)
 	}
+	IncidentShared.ResolveByRule(model.IncidentSourceAlertRule, id)
 }
 
 // checkStatus 检查报警规则并发送报警
@@ -152,6 +224,19 @@ func checkStatus() {
 			if alert.UserID != server.UserID && !role.IsAdmin() {
 				continue
 			}
+			// 不在覆盖范围内的服务器，清理已有的检查结果与状态；指定分组后忽略规则的 Cover
+			if !ServerGroupShared.InScope(server.ID, alert.ServerGroups, alert.ExcludeServerGroups, func() bool {
+				return alert.Covers(server.ID)
+			}) {
+				duration := IncidentShared.Resolve(NotificationMuteLabel.ServerIncident(server.ID, alert.ID))
+				// 已发送过报警的服务器移出范围后不会再被检查，清理状态前发送恢复通知
+				if alertsPrevState[alert.ID][server.ID] == _RuleCheckFail {
+					sendAlertRecovery(alert, server, duration)
+				}
+				delete(alertsStore[alert.ID], server.ID)
+				delete(alertsPrevState[alert.ID], server.ID)
+				continue
+			}
 			alertsStore[alert.ID][server.ID] = append(alertsStore[alert.
 				ID][server.ID], alert.Snapshot(AlertsCycleTransferStatsStore[alert.ID], server, DB))
 			// 发送通知，分为触发报警和恢复通知
@@ -160,27 +245,34 @@ func checkStatus() {
 			curServer := model.Server{}
 			copier.Copy(&curServer, server)
 
+			// 维护期间只记录事件，不改变上次的检查状态，维护结束后按实际状态补发报警或恢复通知
+			inMaintenance := MaintenanceShared.ServerInMaintenance(server.ID)
+			prevState := alertsPrevState[alert.ID][server.ID]
+
 			// 本次未通过检查
 			if !passed {
 				// 始终触发模式或上次检查不为失败时触发报警（跳过单次触发+上次失败的情况）
-				if alert.TriggerMode == model.ModeAlwaysTrigger || alertsPrevState[alert.ID][server.ID] != _RuleCheckFail {
-					alertsPrevState[alert.ID][server.ID] = _RuleCheckFail
-					message := fmt.Sprintf("[%s] %s(%s) %s", Localizer.T("Incident"),
-						server.Name, IPDesensitize(server.GeoIP.IP.Join()), alert.Name)
-					go CronShared.SendTriggerTasks(alert.FailTriggerTasks, curServer.ID)
-					go NotificationShared.SendNotification(alert.NotificationGroupID, message, NotificationMuteLabel.ServerIncident(server.ID, alert.ID), &curServer)
-					// 清除恢复通知的静音缓存
-					NotificationShared.UnMuteNotification(alert.NotificationGroupID, NotificationMuteLabel.ServerIncidentResolved(server.ID, alert.ID))
+				if alert.TriggerMode == model.ModeAlwaysTrigger || prevState != _RuleCheckFail {
+					IncidentShared.Open(model.IncidentSourceAlertRule, alert.ID, server.ID, alert.UserID,
+						fmt.Sprintf("%s %s", server.Name, alert.Name), NotificationMuteLabel.ServerIncident(server.ID, alert.ID))
+					if !inMaintenance {
+						alertsPrevState[alert.ID][server.ID] = _RuleCheckFail
+						message := fmt.Sprintf("[%s] %s(%s) %s", Localizer.T("Incident"),
+							server.Name, IPDesensitize(server.GeoIP.IP.Join()), alert.Name)
+						event := alertEvent(alert, server, model.NotificationStateFiring, prevState)
+						event.Duration = IncidentShared.Since(NotificationMuteLabel.ServerIncident(server.ID, alert.ID))
+						go CronShared.SendTriggerTasks(alert.FailTriggerTasks, curServer.ID)
+						go NotificationShared.SendNotification(alert.NotificationGroupID, event, message, NotificationMuteLabel.ServerIncident(server.ID, alert.ID), &curServer)
+						// 清除恢复通知的静音缓存
+						NotificationShared.UnMuteNotification(alert.NotificationGroupID, NotificationMuteLabel.ServerIncidentResolved(server.ID, alert.ID))
+					}
 				}
-			} else {
+			} else if !inMaintenance || prevState != _RuleCheckFail {
+				// 已发送过报警的事件留到维护结束后再恢复，以便发送恢复通知
+				duration := IncidentShared.Resolve(NotificationMuteLabel.ServerIncident(server.ID, alert.ID))
 				// 本次通过检查但上一次的状态为失败，则发送恢复通知
-				if alertsPrevState[alert.ID][server.ID] == _RuleCheckFail {
-					message := fmt.Sprintf("[%s] %s(%s) %s", Localizer.T("Resolved"),
-						server.Name, IPDesensitize(server.GeoIP.IP.Join()), alert.Name)
-					go CronShared.SendTriggerTasks(alert.RecoverTriggerTasks, curServer.ID)
-					go NotificationShared.SendNotification(alert.NotificationGroupID, message, NotificationMuteLabel.ServerIncidentResolved(server.ID, alert.ID), &curServer)
-					// 清除失败通知的静音缓存
-					NotificationShared.UnMuteNotification(alert.NotificationGroupID, NotificationMuteLabel.ServerIncident(server.ID, alert.ID))
+				if prevState == _RuleCheckFail {
+					sendAlertRecovery(alert, server, duration)
 				}
 				alertsPrevState[alert.ID][server.ID] = _RuleCheckPass
 			}
@@ -192,3 +284,45 @@ func checkStatus() {
 		}
 	}
 }
+
+// sendAlertRecovery 发送报警规则的恢复通知并触发恢复任务
+func sendAlertRecovery(alert *model.AlertRule, server *model.Server, duration time.Duration) {
+	curServer := model.Server{}
+	copier.Copy(&curServer, server)
+
+	message := fmt.Sprintf("[%s] %s(%s) %s", Localizer.T("Resolved"),
+		server.Name, IPDesensitize(server.GeoIP.IP.Join()), alert.Name)
+	event := alertEvent(alert, server, model.NotificationStateResolved, _RuleCheckFail)
+	event.Duration = duration
+	go CronShared.SendTriggerTasks(alert.RecoverTriggerTasks, curServer.ID)
+	go NotificationShared.SendNotification(alert.NotificationGroupID, event, message, NotificationMuteLabel.ServerIncidentResolved(server.ID, alert.ID), &curServer)
+	// 清除失败通知的静音缓存
+	NotificationShared.UnMuteNotification(alert.NotificationGroupID, NotificationMuteLabel.ServerIncident(server.ID, alert.ID))
+}
+
+// alertEvent 报警规则的通知事件，恢复通知与报警通知使用相同的级别
+func alertEvent(alert *model.AlertRule, server *model.Server, state string, prevState uint8) model.NotificationEvent {
+	severity := alert.Severity
+	if severity == 0 {
+		severity = model.NotificationSeverityWarning
+	}
+	return model.NotificationEvent{
+		Source:    model.NotificationSourceAlert,
+		Rule:      alert.Name,
+		Severity:  severity,
+		ServerID:  server.ID,
+		State:     state,
+		PrevState: alertStateName(prevState),
+		Metrics:   alert.Metrics(AlertsCycleTransferStatsStore[alert.ID], server),
+	}
+}
+
+func alertStateName(state uint8) string {
+	switch state {
+	case _RuleCheckFail:
+		return model.NotificationStateFiring
+	case _RuleCheckPass:
+		return model.NotificationStateResolved
+	}
+	return ""
+}
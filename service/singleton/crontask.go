@@ -55,7 +55,7 @@ func NewCronClass() *CronClass {
 	// 向注册错误的计划任务所在通知组发送通知
 	for _, gid := range notificationGroupList {
 		notificationMsgMap[gid].WriteString(Localizer.T("] These tasks will not execute properly. Fix them in the admin dashboard."))
-		NotificationShared.SendNotification(gid, notificationMsgMap[gid].String(), "")
+		NotificationShared.SendNotification(gid, cronEvent("", model.NotificationSeverityWarning), notificationMsgMap[gid].String(), "")
 	}
 	cronx.Start()
 
@@ -141,41 +141,61 @@ func CronTrigger(cr *model.Cron, triggerServer ...uint64) func() {
 				return
 			}
 			if s, ok := ServerShared.Get(triggerServer[0]); ok {
-				if s.TaskStream != nil {
-					s.TaskStream.Send(&pb.Task{
-						Id:   cr.ID,
-						Data: cr.Command,
-						Type: model.TaskTypeCommand,
-					})
-				} else {
-					// 保存当前服务器状态信息
-					curServer := model.Server{}
-					copier.Copy(&curServer, s)
-					go NotificationShared.SendNotification(cr.NotificationGroupID, Localizer.Tf("[Task failed] %s: server %s is offline and cannot execute the task", cr.Name, s.Name), "", &curServer)
-				}
+				enqueueCronCommand(cr, s)
 			}
 			return
 		}
 
 		for _, s := range ServerShared.Range {
-			if cr.Cover == model.CronCoverAll && crIgnoreMap[s.ID] {
-				continue
-			}
-			if cr.Cover == model.CronCoverIgnoreAll && !crIgnoreMap[s.ID] {
+			if !ServerGroupShared.InScope(s.ID, cr.ServerGroups, cr.ExcludeServerGroups, func() bool {
+				if cr.Cover == model.CronCoverAll {
+					return !crIgnoreMap[s.ID]
+				}
+				return crIgnoreMap[s.ID]
+			}) {
 				continue
 			}
-			if s.TaskStream != nil {
-				s.TaskStream.Send(&pb.Task{
-					Id:   cr.ID,
-					Data: cr.Command,
-					Type: model.TaskTypeCommand,
-				})
-			} else {
-				// 保存当前服务器状态信息
-				curServer := model.Server{}
-				copier.Copy(&curServer, s)
-				go NotificationShared.SendNotification(cr.NotificationGroupID, Localizer.Tf("[Task failed] %s: server %s is offline and cannot execute the task", cr.Name, s.Name), "", &curServer)
-			}
+			enqueueCronCommand(cr, s)
 		}
 	}
 }
+
+// enqueueCronCommand 将计划任务的命令放入服务器的任务队列，Agent 离线时等待其重新连接
+func enqueueCronCommand(cr *model.Cron, s *model.Server) {
+	if _, err := TaskQueueShared.Enqueue(s, &pb.Task{
+		Id:   cr.ID,
+		Data: cr.Command,
+		Type: model.TaskTypeCommand,
+	}); err != nil {
+		// 保存当前服务器状态信息
+		curServer := model.Server{}
+		copier.Copy(&curServer, s)
+		go NotificationShared.SendNotification(cr.NotificationGroupID, cronEvent(cr.Name, model.NotificationSeverityWarning), Localizer.Tf("[Task failed] %s: server %s cannot execute the task: %v", cr.Name, s.Name, err), "", &curServer)
+	}
+}
+
+// notifyCommandExpired 命令在有效期内未能下发给 Agent 时通知
+func notifyCommandExpired(t *model.AgentTask) {
+	cr, ok := CronShared.Get(t.TaskID)
+	if !ok || cr == nil {
+		return
+	}
+	s, ok := ServerShared.Get(t.ServerID)
+	if !ok || s == nil {
+		return
+	}
+
+	// 保存当前服务器状态信息
+	curServer := model.Server{}
+	copier.Copy(&curServer, s)
+	go NotificationShared.SendNotification(cr.NotificationGroupID, cronEvent(cr.Name, model.NotificationSeverityWarning), Localizer.Tf("[Task failed] %s: server %s is offline and cannot execute the task", cr.Name, s.Name), "", &curServer)
+}
+
+// cronEvent 计划任务的通知事件
+func cronEvent(name string, severity uint8) model.NotificationEvent {
+	return model.NotificationEvent{
+		Source:   model.NotificationSourceCron,
+		Rule:     name,
+		Severity: severity,
+	}
+}
@@ -0,0 +1,144 @@
+package singleton
+
+import (
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+// IncidentClass 记录报警规则与服务监控的故障，未恢复的故障按静音标志缓存在内存中
+type IncidentClass struct {
+	mu   sync.RWMutex
+	open map[string]*model.Incident // [mute_label] -> 未恢复的故障
+}
+
+func NewIncidentClass() *IncidentClass {
+	var incidents []*model.Incident
+	DB.Where("resolved_at IS NULL").Find(&incidents)
+
+	open := make(map[string]*model.Incident, len(incidents))
+	for _, i := range incidents {
+		open[i.MuteLabel] = i
+	}
+
+	return &IncidentClass{
+		open: open,
+	}
+}
+
+// Open 创建故障记录，同一静音标志已有未恢复的故障时不重复创建
+func (c *IncidentClass) Open(source uint8, ruleID, serverID, userID uint64, name, muteLabel string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.open[muteLabel]; ok {
+		return
+	}
+
+	incident := &model.Incident{
+		Source:    source,
+		RuleID:    ruleID,
+		ServerID:  serverID,
+		Name:      name,
+		MuteLabel: muteLabel,
+		StartedAt: time.Now(),
+	}
+	incident.UserID = userID
+	if err := DB.Create(incident).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save incident: %v", err)
+		return
+	}
+	c.open[muteLabel] = incident
+}
+
+// Resolve 关闭静音标志对应的未恢复故障，返回故障持续的时间
+func (c *IncidentClass) Resolve(muteLabel string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	incident, ok := c.open[muteLabel]
+	if !ok {
+		return 0
+	}
+	now := time.Now()
+	c.resolve(incident, now)
+	return now.Sub(incident.StartedAt)
+}
+
+// Since 返回静音标志对应的未恢复故障已持续的时间
+func (c *IncidentClass) Since(muteLabel string) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	incident, ok := c.open[muteLabel]
+	if !ok {
+		return 0
+	}
+	return time.Since(incident.StartedAt)
+}
+
+// ResolveByRule 关闭已删除的报警规则或服务监控的未恢复故障
+func (c *IncidentClass) ResolveByRule(source uint8, ruleIDs []uint64) {
+	c.resolveFunc(func(i *model.Incident) bool {
+		return i.Source == source && slices.Contains(ruleIDs, i.RuleID)
+	})
+}
+
+// ResolveByServer 关闭已删除的服务器的未恢复故障
+func (c *IncidentClass) ResolveByServer(serverIDs []uint64) {
+	c.resolveFunc(func(i *model.Incident) bool {
+		return i.ServerID != 0 && slices.Contains(serverIDs, i.ServerID)
+	})
+}
+
+func (c *IncidentClass) resolveFunc(match func(*model.Incident) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for _, incident := range c.open {
+		if match(incident) {
+			c.resolve(incident, now)
+		}
+	}
+}
+
+func (c *IncidentClass) resolve(incident *model.Incident, now time.Time) {
+	if err := DB.Model(incident).Update("resolved_at", now).Error; err != nil {
+		log.Printf("NEZHA>> Failed to resolve incident %d: %v", incident.ID, err)
+		return
+	}
+	delete(c.open, incident.MuteLabel)
+}
+
+// Acknowledge 确认故障，确认后的故障不再重复发送通知
+func (c *IncidentClass) Acknowledge(incident *model.Incident, userID uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if err := DB.Model(incident).Updates(map[string]any{
+		"acknowledged_at": now,
+		"acknowledged_by": userID,
+	}).Error; err != nil {
+		return err
+	}
+
+	if i, ok := c.open[incident.MuteLabel]; ok && i.ID == incident.ID {
+		i.AcknowledgedAt = &now
+		i.AcknowledgedBy = userID
+	}
+	return nil
+}
+
+// Acknowledged 判断静音标志对应的未恢复故障是否已被确认
+func (c *IncidentClass) Acknowledged(muteLabel string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	incident, ok := c.open[muteLabel]
+	return ok && incident.Acknowledged()
+}
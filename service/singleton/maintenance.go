@@ -0,0 +1,97 @@
+package singleton
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+// MaintenanceClass 维护窗口，窗口内仍记录事件，但不发送通知、不执行触发任务
+type MaintenanceClass struct {
+	class[uint64, *model.Maintenance]
+}
+
+func NewMaintenanceClass() *MaintenanceClass {
+	var sortedList []*model.Maintenance
+
+	DB.Find(&sortedList)
+	list := make(map[uint64]*model.Maintenance, len(sortedList))
+	for _, m := range sortedList {
+		list[m.ID] = m
+	}
+
+	return &MaintenanceClass{
+		class: class[uint64, *model.Maintenance]{
+			list:       list,
+			sortedList: sortedList,
+		},
+	}
+}
+
+func (c *MaintenanceClass) Update(m *model.Maintenance) {
+	c.listMu.Lock()
+	c.list[m.ID] = m
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+func (c *MaintenanceClass) Delete(idList []uint64) {
+	c.listMu.Lock()
+	for _, id := range idList {
+		delete(c.list, id)
+	}
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+// ServerInMaintenance 判断服务器当前是否处于维护窗口内
+func (c *MaintenanceClass) ServerInMaintenance(serverID uint64) bool {
+	now := time.Now().In(Loc)
+
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	for _, m := range c.list {
+		if !slices.Contains(m.Servers, serverID) && !ServerGroupShared.InAny(serverID, m.ServerGroups) {
+			continue
+		}
+		if m.Active(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceInMaintenance 判断服务监控当前是否处于维护窗口内
+func (c *MaintenanceClass) ServiceInMaintenance(serviceID uint64) bool {
+	now := time.Now().In(Loc)
+
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	for _, m := range c.list {
+		if slices.Contains(m.Services, serviceID) && m.Active(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *MaintenanceClass) sortList() {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	sortedList := utils.MapValuesToSlice(c.list)
+	slices.SortFunc(sortedList, func(a, b *model.Maintenance) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	c.sortedListMu.Lock()
+	defer c.sortedListMu.Unlock()
+	c.sortedList = sortedList
+}
@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"fmt"
 	"log"
+	"maps"
 	"slices"
 	"sync"
 	"time"
@@ -22,8 +23,15 @@ type NotificationClass struct {
 	groupToIDList map[uint64]map[uint64]*model.Notification
 	idToGroupList map[uint64]map[uint64]struct{}
 
-	groupList map[uint64]string
-	groupMu   sync.RWMutex
+	groupList     map[uint64]string
+	groupSettings map[uint64]*model.NotificationGroup
+	groupMu       sync.RWMutex
+
+	deliverySignal chan struct{}
+
+	digestMu     sync.Mutex
+	digests      map[uint64]*digestBuffer
+	dailyDigests map[uint64]*dailyDigest
 }
 
 func NewNotificationClass() *NotificationClass {
@@ -49,8 +57,10 @@ func NewNotificationClass() *NotificationClass {
 	var groups []model.NotificationGroup
 	DB.Find(&groups)
 	groupList := make(map[uint64]string)
+	groupSettings := make(map[uint64]*model.NotificationGroup)
 	for _, grp := range groups {
 		groupList[grp.ID] = grp.Name
+		groupSettings[grp.ID] = &grp
 	}
 
 	for gid, nids := range groupNotifications {
@@ -76,7 +86,14 @@ func NewNotificationClass() *NotificationClass {
 		groupToIDList: groupToIDList,
 		idToGroupList: idToGroupList,
 		groupList:     groupList,
+		groupSettings: groupSettings,
+
+		deliverySignal: make(chan struct{}, 1),
+
+		digests:      make(map[uint64]*digestBuffer),
+		dailyDigests: make(map[uint64]*dailyDigest),
 	}
+	go nc.deliveryWorker()
 	return nc
 }
 
@@ -104,6 +121,7 @@ func (c *NotificationClass) UpdateGroup(ng *model.NotificationGroup, ngn []uint6
 
 	_, ok := c.groupList[ng.ID]
 	c.groupList[ng.ID] = ng.Name
+	c.groupSettings[ng.ID] = ng
 
 	c.listMu.Lock()
 	defer c.listMu.Unlock()
@@ -168,8 +186,27 @@ func (c *NotificationClass) DeleteGroup(gids []uint64) {
 
 	for _, gid := range gids {
 		delete(c.groupList, gid)
+		delete(c.groupSettings, gid)
 		delete(c.groupToIDList, gid)
 	}
+
+	c.digestMu.Lock()
+	for _, gid := range gids {
+		delete(c.dailyDigests, gid)
+	}
+	c.digestMu.Unlock()
+}
+
+// GetGroupNotifications 返回通知组中的通知方式，按 ID 排序
+func (c *NotificationClass) GetGroupNotifications(gid uint64) []*model.Notification {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	notifications := utils.MapValuesToSlice(c.groupToIDList[gid])
+	slices.SortFunc(notifications, func(a, b *model.Notification) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return notifications
 }
 
 func (c *NotificationClass) GetGroupName(gid uint64) string {
@@ -198,9 +235,43 @@ func (c *NotificationClass) UnMuteNotification(notificationGroupID uint64, muteL
 	Cache.Delete(fullMuteLabel)
 }
 
-// SendNotification 向指定的通知方式组的所有通知方式发送通知
-func (c *NotificationClass) SendNotification(notificationGroupID uint64, desc string, muteLabel string, ext ...*model.Server) {
+// routeFilter 返回按通知组路由规则判断通知方式是否接收该事件的函数
+func (c *NotificationClass) routeFilter(notificationGroupID uint64, event model.NotificationEvent) func(notificationID uint64) bool {
+	c.groupMu.RLock()
+	var routes []model.NotificationRoute
+	if ng, ok := c.groupSettings[notificationGroupID]; ok {
+		routes = ng.Routes
+	}
+	c.groupMu.RUnlock()
+
+	if len(routes) == 0 {
+		return func(uint64) bool { return true }
+	}
+
+	now := time.Now().In(Loc)
+	routed := make(map[uint64]bool)
+	for _, route := range routes {
+		matched := route.Match(event, now, ServerGroupShared.InAny)
+		for _, nid := range route.Notifications {
+			routed[nid] = routed[nid] || matched
+		}
+	}
+	return func(notificationID uint64) bool {
+		allowed, ok := routed[notificationID]
+		return !ok || allowed
+	}
+}
+
+// SendNotification 向指定的通知方式组中按路由规则匹配的通知方式发送通知
+func (c *NotificationClass) SendNotification(notificationGroupID uint64, event model.NotificationEvent, desc string, muteLabel string, ext ...*model.Server) {
 	if muteLabel != "" {
+		// 已确认的故障不再重复通知
+		if IncidentShared.Acknowledged(muteLabel) {
+			if Conf.Debug {
+				log.Println("NEZHA>> Muted acknowledged incident notification", desc, muteLabel)
+			}
+			return
+		}
 		// 将通知方式组名称加入静音标志
 		muteLabel := NotificationMuteLabel.AppendNotificationGroupName(muteLabel, c.GetGroupName(notificationGroupID))
 		// 通知防骚扰策略
@@ -234,27 +305,51 @@ func (c *NotificationClass) SendNotification(notificationGroupID uint64, desc st
 			return
 		}
 	}
-	// 向该通知方式组的所有通知方式发出通知
-	c.listMu.RLock()
-	defer c.listMu.RUnlock()
-	for _, n := range c.groupToIDList[notificationGroupID] {
-		log.Printf("NEZHA>> Try to notify %s", n.Name)
+	var server *model.Server
+	if len(ext) > 0 {
+		server = ext[0]
 	}
-	for _, n := range c.groupToIDList[notificationGroupID] {
-		ns := model.NotificationServerBundle{
-			Notification: n,
-			Server:       nil,
-			Loc:          Loc,
-		}
-		if len(ext) > 0 {
-			ns.Server = ext[0]
+	if server != nil && event.ServerID == 0 {
+		event.ServerID = server.ID
+	}
+	window, daily := c.digestSettings(notificationGroupID)
+	if daily {
+		c.recordDailyDigest(notificationGroupID, event, server)
+	}
+	allow := c.routeFilter(notificationGroupID, event)
+	// 先复制通知方式列表，渲染模板时不持有 listMu
+	c.listMu.RLock()
+	notifications := slices.Collect(maps.Values(c.groupToIDList[notificationGroupID]))
+	c.listMu.RUnlock()
+
+	// 向该通知方式组的通知方式发出通知，加入发送队列后由队列投递与重试
+	for _, n := range notifications {
+		if !allow(n.ID) {
+			continue
 		}
-		if err := ns.Send(desc); err != nil {
-			log.Printf("NEZHA>> Sending notification to %s failed: %v", n.Name, err)
-		} else {
-			log.Printf("NEZHA>> Sending notification to %s succeeded", n.Name)
+		msg := renderNotification(n, event, desc, server)
+		if window > 0 {
+			c.addDigest(notificationGroupID, window, n.ID, digestItem{event: event, desc: msg, server: server})
+			continue
 		}
+		log.Printf("NEZHA>> Try to notify %s", n.Name)
+		c.enqueueNotification(n, msg, server)
+	}
+	c.wakeDeliveryWorker()
+}
+
+// renderNotification 使用通知方式为事件来源设置的模板渲染消息，未设置模板或渲染失败时使用默认格式
+func renderNotification(n *model.Notification, event model.NotificationEvent, desc string, server *model.Server) string {
+	tmpl := n.Template(event.Source)
+	if tmpl == "" {
+		return desc
+	}
+	msg, err := model.RenderNotificationTemplate(tmpl, model.NewNotificationTemplateData(event, desc, server, time.Now()), Loc)
+	if err != nil {
+		log.Printf("NEZHA>> Failed to render notification template of %s: %v", n.Name, err)
+		return desc
 	}
+	return msg
 }
 
 type _NotificationMuteLabel struct{}
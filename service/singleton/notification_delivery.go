@@ -0,0 +1,199 @@
+package singleton
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/notifier"
+)
+
+const (
+	_NotificationMaxAttempts   = 6
+	_NotificationRetryBase     = time.Second * 30
+	_NotificationRetryMax      = time.Hour
+	_NotificationSendTimeout   = time.Minute
+	_NotificationDeliveryBatch = 50
+	_NotificationWorkers       = 8
+)
+
+// enqueueNotification 为通知方式创建待发送记录，由发送队列异步投递
+func (c *NotificationClass) enqueueNotification(n *model.Notification, desc string, server *model.Server) {
+	d := model.NotificationDelivery{
+		NotificationID: n.ID,
+		Message:        desc,
+		Status:         model.NotificationDeliveryPending,
+		NextAttemptAt:  time.Now(),
+	}
+	d.UserID = n.UserID
+	if err := d.SetServer(server); err != nil {
+		log.Printf("NEZHA>> Failed to encode server of notification %s: %v", n.Name, err)
+	}
+	if err := DB.Create(&d).Error; err != nil {
+		log.Printf("NEZHA>> Failed to queue notification to %s: %v", n.Name, err)
+	}
+}
+
+func (c *NotificationClass) wakeDeliveryWorker() {
+	select {
+	case c.deliverySignal <- struct{}{}:
+	default:
+	}
+}
+
+// deliveryWorker 投递到期的通知，失败时按指数退避重试
+func (c *NotificationClass) deliveryWorker() {
+	ticker := time.NewTicker(time.Second * 5)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.deliverySignal:
+		}
+		c.processDeliveries()
+	}
+}
+
+func (c *NotificationClass) processDeliveries() {
+	for {
+		var deliveries []*model.NotificationDelivery
+		if err := DB.Where("status = ? AND next_attempt_at <= ?", model.NotificationDeliveryPending, time.Now()).
+			Order("next_attempt_at ASC").Limit(_NotificationDeliveryBatch).Find(&deliveries).Error; err != nil {
+			log.Printf("NEZHA>> Failed to load notification queue: %v", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, _NotificationWorkers)
+		for _, d := range deliveries {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				c.deliver(d)
+			}()
+		}
+		wg.Wait()
+
+		if len(deliveries) < _NotificationDeliveryBatch {
+			return
+		}
+	}
+}
+
+func (c *NotificationClass) deliver(d *model.NotificationDelivery) {
+	n, ok := c.Get(d.NotificationID)
+	if !ok {
+		d.Attempts++
+		d.LastError = "notification does not exist"
+		c.deadLetter(d)
+		return
+	}
+
+	server, err := d.GetServer()
+	if err != nil {
+		log.Printf("NEZHA>> Failed to decode server of notification %s: %v", n.Name, err)
+	}
+
+	ns := model.NotificationServerBundle{
+		Notification: n,
+		Server:       server,
+		Loc:          Loc,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), _NotificationSendTimeout)
+	err = notifier.Send(ctx, &ns, d.Message)
+	cancel()
+
+	d.Attempts++
+	if err == nil {
+		now := time.Now()
+		d.Status = model.NotificationDeliverySent
+		d.SentAt = &now
+		d.LastError = ""
+		log.Printf("NEZHA>> Sending notification to %s succeeded", n.Name)
+		if err := DB.Save(d).Error; err != nil {
+			log.Printf("NEZHA>> Failed to update notification delivery %d: %v", d.ID, err)
+		}
+		return
+	}
+
+	d.LastError = err.Error()
+	log.Printf("NEZHA>> Sending notification to %s failed (attempt %d/%d): %v", n.Name, d.Attempts, _NotificationMaxAttempts, err)
+	if d.Attempts >= _NotificationMaxAttempts {
+		c.deadLetter(d)
+		return
+	}
+
+	d.NextAttemptAt = time.Now().Add(notificationRetryDelay(d.Attempts))
+	if err := DB.Save(d).Error; err != nil {
+		log.Printf("NEZHA>> Failed to update notification delivery %d: %v", d.ID, err)
+	}
+}
+
+// deadLetter 将重试次数用尽的通知转入死信表
+func (c *NotificationClass) deadLetter(d *model.NotificationDelivery) {
+	d.Status = model.NotificationDeliveryFailed
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(d).Error; err != nil {
+			return err
+		}
+		dl := model.NotificationDeadLetter{
+			DeliveryID:     d.ID,
+			NotificationID: d.NotificationID,
+			Message:        d.Message,
+			Attempts:       d.Attempts,
+			LastError:      d.LastError,
+		}
+		dl.UserID = d.UserID
+		return tx.Create(&dl).Error
+	})
+	if err != nil {
+		log.Printf("NEZHA>> Failed to move notification delivery %d to dead letters: %v", d.ID, err)
+	}
+}
+
+// Resend 重新投递已转入死信表的通知
+func (c *NotificationClass) Resend(d *model.NotificationDelivery) error {
+	d.Status = model.NotificationDeliveryPending
+	d.Attempts = 0
+	d.LastError = ""
+	d.NextAttemptAt = time.Now()
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(d).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.NotificationDeadLetter{}, "delivery_id = ?", d.ID).Error
+	})
+	if err != nil {
+		return err
+	}
+	c.wakeDeliveryWorker()
+	return nil
+}
+
+// notificationRetryDelay 第 n 次失败后的重试间隔，每次翻倍，最长 1 小时
+func notificationRetryDelay(attempts uint32) time.Duration {
+	delay := _NotificationRetryBase
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= _NotificationRetryMax {
+			return _NotificationRetryMax
+		}
+	}
+	return delay
+}
+
+// CleanNotificationDeliveries 清理过期的通知发送记录与死信
+func CleanNotificationDeliveries() {
+	DB.Unscoped().Delete(&model.NotificationDelivery{}, "status = ? AND created_at < ?", model.NotificationDeliverySent, time.Now().AddDate(0, 0, -7))
+	DB.Unscoped().Delete(&model.NotificationDelivery{}, "status = ? AND created_at < ?", model.NotificationDeliveryFailed, time.Now().AddDate(0, 0, -30))
+	DB.Unscoped().Delete(&model.NotificationDeadLetter{}, "created_at < ?", time.Now().AddDate(0, 0, -30))
+}
@@ -0,0 +1,248 @@
+package singleton
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+const (
+	_DigestMaxLines = 50
+	_DigestMaxNames = 20
+)
+
+type digestItem struct {
+	event  model.NotificationEvent
+	desc   string
+	server *model.Server
+}
+
+// digestBuffer 合并窗口内待发送的通知，按通知方式分别合并
+type digestBuffer struct {
+	window time.Duration
+	items  map[uint64][]digestItem
+	timer  *time.Timer
+}
+
+// dailyDigest 通知组当天的通知统计
+type dailyDigest struct {
+	since   time.Time
+	total   int
+	sources map[string]int
+	servers map[string]int
+	rules   map[string]int
+}
+
+func newDailyDigest() *dailyDigest {
+	return &dailyDigest{
+		since:   time.Now(),
+		sources: make(map[string]int),
+		servers: make(map[string]int),
+		rules:   make(map[string]int),
+	}
+}
+
+// digestSettings 返回通知组的合并窗口以及是否开启每日摘要
+func (c *NotificationClass) digestSettings(notificationGroupID uint64) (time.Duration, bool) {
+	c.groupMu.RLock()
+	defer c.groupMu.RUnlock()
+
+	ng, ok := c.groupSettings[notificationGroupID]
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(ng.DigestWindow) * time.Second, ng.DailyDigestTime != ""
+}
+
+// addDigest 将通知加入合并窗口，窗口结束时统一发送
+func (c *NotificationClass) addDigest(notificationGroupID uint64, window time.Duration, notificationID uint64, item digestItem) {
+	c.digestMu.Lock()
+	defer c.digestMu.Unlock()
+
+	buf, ok := c.digests[notificationGroupID]
+	if !ok {
+		buf = &digestBuffer{
+			window: window,
+			items:  make(map[uint64][]digestItem),
+		}
+		buf.timer = time.AfterFunc(window, func() {
+			c.flushDigest(notificationGroupID)
+		})
+		c.digests[notificationGroupID] = buf
+	}
+	buf.items[notificationID] = append(buf.items[notificationID], item)
+}
+
+// flushDigest 发送通知组合并窗口内的通知，只有一条时按原样发送
+func (c *NotificationClass) flushDigest(notificationGroupID uint64) {
+	c.digestMu.Lock()
+	buf, ok := c.digests[notificationGroupID]
+	delete(c.digests, notificationGroupID)
+	c.digestMu.Unlock()
+
+	if !ok {
+		return
+	}
+	buf.timer.Stop()
+
+	for nid, items := range buf.items {
+		n, ok := c.Get(nid)
+		if !ok {
+			continue
+		}
+		log.Printf("NEZHA>> Try to notify %s", n.Name)
+		if len(items) == 1 {
+			c.enqueueNotification(n, items[0].desc, items[0].server)
+			continue
+		}
+		c.enqueueNotification(n, buildDigestMessage(buf.window, items), nil)
+	}
+	c.wakeDeliveryWorker()
+}
+
+// FlushDigests 立即发送所有合并窗口内的通知
+func (c *NotificationClass) FlushDigests() {
+	c.digestMu.Lock()
+	gids := slices.Collect(maps.Keys(c.digests))
+	c.digestMu.Unlock()
+
+	for _, gid := range gids {
+		c.flushDigest(gid)
+	}
+}
+
+func buildDigestMessage(window time.Duration, items []digestItem) string {
+	var servers, rules []string
+	for _, item := range items {
+		if item.server != nil && !slices.Contains(servers, item.server.Name) {
+			servers = append(servers, item.server.Name)
+		}
+		if item.event.Rule != "" && !slices.Contains(rules, item.event.Rule) {
+			rules = append(rules, item.event.Rule)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(Localizer.Tf("[Digest] %d notifications in the last %s", len(items), window))
+	if len(servers) > 0 {
+		fmt.Fprintf(&sb, "\n%s: %s", Localizer.T("Servers"), joinNames(servers))
+	}
+	if len(rules) > 0 {
+		fmt.Fprintf(&sb, "\n%s: %s", Localizer.T("Rules"), joinNames(rules))
+	}
+	sb.WriteString("\n")
+	for i, item := range items {
+		if i == _DigestMaxLines {
+			sb.WriteString("\n")
+			sb.WriteString(Localizer.Tf("... and %d more", len(items)-i))
+			break
+		}
+		desc, _, _ := strings.Cut(item.desc, "\n")
+		sb.WriteString("\n- ")
+		sb.WriteString(desc)
+	}
+	return sb.String()
+}
+
+func joinNames(names []string) string {
+	if len(names) > _DigestMaxNames {
+		return strings.Join(names[:_DigestMaxNames], ", ") + " " + Localizer.Tf("and %d more", len(names)-_DigestMaxNames)
+	}
+	return strings.Join(names, ", ")
+}
+
+// recordDailyDigest 记录通知组的通知，用于生成每日摘要
+func (c *NotificationClass) recordDailyDigest(notificationGroupID uint64, event model.NotificationEvent, server *model.Server) {
+	c.digestMu.Lock()
+	defer c.digestMu.Unlock()
+
+	d, ok := c.dailyDigests[notificationGroupID]
+	if !ok {
+		d = newDailyDigest()
+		c.dailyDigests[notificationGroupID] = d
+	}
+	d.total++
+	d.sources[event.Source]++
+	if server != nil {
+		d.servers[server.Name]++
+	}
+	if event.Rule != "" {
+		d.rules[event.Rule]++
+	}
+}
+
+// SendDailyDigests 向到达发送时间的通知组发送每日摘要
+func (c *NotificationClass) SendDailyDigests() {
+	clock := time.Now().In(Loc).Format("15:04")
+
+	c.groupMu.RLock()
+	var gids []uint64
+	for gid, ng := range c.groupSettings {
+		if ng.DailyDigestTime == clock {
+			gids = append(gids, gid)
+		}
+	}
+	c.groupMu.RUnlock()
+
+	for _, gid := range gids {
+		c.digestMu.Lock()
+		d, ok := c.dailyDigests[gid]
+		c.dailyDigests[gid] = newDailyDigest()
+		c.digestMu.Unlock()
+
+		if !ok {
+			d = newDailyDigest()
+		}
+		msg := buildDailyDigestMessage(c.GetGroupName(gid), d)
+
+		c.listMu.RLock()
+		for _, n := range c.groupToIDList[gid] {
+			log.Printf("NEZHA>> Try to send daily digest to %s", n.Name)
+			c.enqueueNotification(n, msg, nil)
+		}
+		c.listMu.RUnlock()
+	}
+	if len(gids) > 0 {
+		c.wakeDeliveryWorker()
+	}
+}
+
+func buildDailyDigestMessage(groupName string, d *dailyDigest) string {
+	var sb strings.Builder
+	sb.WriteString(Localizer.Tf("[Daily Digest] %s: %d notifications since %s", groupName, d.total, d.since.In(Loc).Format(time.DateTime)))
+	if d.total == 0 {
+		return sb.String()
+	}
+
+	writeCounts := func(title string, counts map[string]int) {
+		if len(counts) == 0 {
+			return
+		}
+		keys := slices.Collect(maps.Keys(counts))
+		slices.SortFunc(keys, func(a, b string) int {
+			if r := cmp.Compare(counts[b], counts[a]); r != 0 {
+				return r
+			}
+			return cmp.Compare(a, b)
+		})
+		fmt.Fprintf(&sb, "\n\n%s:", title)
+		for i, k := range keys {
+			if i == _DigestMaxNames {
+				sb.WriteString("\n")
+				sb.WriteString(Localizer.Tf("... and %d more", len(keys)-i))
+				break
+			}
+			fmt.Fprintf(&sb, "\n- %s: %d", k, counts[k])
+		}
+	}
+	writeCounts(Localizer.T("Sources"), d.sources)
+	writeCounts(Localizer.T("Servers"), d.servers)
+	writeCounts(Localizer.T("Rules"), d.rules)
+	return sb.String()
+}
@@ -0,0 +1,142 @@
+package singleton
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+// 等待审核的 Agent 会不断重连，最近连接信息按该间隔落库
+const pendingServerTouchInterval = time.Minute
+
+// PendingServerClass 等待管理员审核的 Agent 注册请求
+type PendingServerClass struct {
+	class[uint64, *model.PendingServer]
+}
+
+func NewPendingServerClass() *PendingServerClass {
+	var sortedList []*model.PendingServer
+
+	DB.Find(&sortedList)
+	list := make(map[uint64]*model.PendingServer, len(sortedList))
+	for _, p := range sortedList {
+		list[p.ID] = p
+	}
+
+	c := &PendingServerClass{
+		class: class[uint64, *model.PendingServer]{
+			list: list,
+		},
+	}
+	c.sortList()
+	return c
+}
+
+// GetByUUID 按 UUID 查找注册请求
+func (c *PendingServerClass) GetByUUID(uuid string) (*model.PendingServer, bool) {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	for _, p := range c.list {
+		if p.UUID == uuid {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Register 记录未知 Agent 的连接，返回对应的注册请求。
+// 同一 UUID 的并发连接按 uuid 唯一索引合并为一条记录
+func (c *PendingServerClass) Register(uuid string, userID uint64, ip string) (*model.PendingServer, error) {
+	now := time.Now()
+	if p, ok := c.GetByUUID(uuid); ok && p.IP == ip && now.Sub(p.LastSeenAt) < pendingServerTouchInterval {
+		return p, nil
+	}
+
+	p := &model.PendingServer{
+		Common:     model.Common{UserID: userID},
+		UUID:       uuid,
+		IP:         ip,
+		LastSeenAt: now,
+	}
+	if err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uuid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"ip", "last_seen_at", "updated_at"}),
+	}).Create(p).Error; err != nil {
+		return nil, err
+	}
+	if err := DB.Where("uuid = ?", uuid).First(p).Error; err != nil {
+		return nil, err
+	}
+	c.Update(p)
+	return p, nil
+}
+
+// UpdateHost 记录等待审核的 Agent 上报的主机信息
+func (c *PendingServerClass) UpdateHost(uuid string, host *model.Host) error {
+	p, ok := c.GetByUUID(uuid)
+	if !ok || p.Rejected {
+		return nil
+	}
+
+	cp := *p
+	cp.Host = host
+	if err := DB.Save(&cp).Error; err != nil {
+		return err
+	}
+	c.Update(&cp)
+	return nil
+}
+
+// Reject 拒绝注册请求，Agent 之后的连接会被直接拒绝
+func (c *PendingServerClass) Reject(id uint64) error {
+	p, ok := c.Get(id)
+	if !ok {
+		return Localizer.ErrorT("pending server id %d does not exist", id)
+	}
+
+	cp := *p
+	cp.Rejected = true
+	if err := DB.Model(&cp).Update("rejected", true).Error; err != nil {
+		return err
+	}
+	c.Update(&cp)
+	return nil
+}
+
+func (c *PendingServerClass) Update(p *model.PendingServer) {
+	c.listMu.Lock()
+	c.list[p.ID] = p
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+func (c *PendingServerClass) Delete(idList []uint64) {
+	c.listMu.Lock()
+	for _, id := range idList {
+		delete(c.list, id)
+	}
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+func (c *PendingServerClass) sortList() {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	sortedList := utils.MapValuesToSlice(c.list)
+	slices.SortFunc(sortedList, func(a, b *model.PendingServer) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	c.sortedListMu.Lock()
+	defer c.sortedListMu.Unlock()
+	c.sortedList = sortedList
+}
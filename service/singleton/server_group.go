@@ -0,0 +1,110 @@
+package singleton
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+// ServerGroupClass 服务器分组及其成员关系的缓存
+type ServerGroupClass struct {
+	class[uint64, *model.ServerGroup]
+
+	members map[uint64]map[uint64]struct{} // [server_group_id] -> server_id
+}
+
+func NewServerGroupClass() (*ServerGroupClass, error) {
+	c := &ServerGroupClass{}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload 从数据库重新加载分组与成员关系，分组或服务器变更后调用
+func (c *ServerGroupClass) Reload() error {
+	var groups []*model.ServerGroup
+	if err := DB.Find(&groups).Error; err != nil {
+		return err
+	}
+	var sgs []model.ServerGroupServer
+	if err := DB.Find(&sgs).Error; err != nil {
+		return err
+	}
+
+	list := make(map[uint64]*model.ServerGroup, len(groups))
+	for _, g := range groups {
+		list[g.ID] = g
+	}
+	members := make(map[uint64]map[uint64]struct{})
+	for _, s := range sgs {
+		if members[s.ServerGroupId] == nil {
+			members[s.ServerGroupId] = make(map[uint64]struct{})
+		}
+		members[s.ServerGroupId][s.ServerId] = struct{}{}
+	}
+
+	c.listMu.Lock()
+	c.list = list
+	c.members = members
+	c.listMu.Unlock()
+
+	c.sortList()
+	return nil
+}
+
+// InAny 判断服务器是否属于任一给定分组
+func (c *ServerGroupClass) InAny(serverID uint64, groupIDs []uint64) bool {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	for _, gid := range groupIDs {
+		if _, ok := c.members[gid][serverID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// InScope 按分组判断服务器是否在覆盖范围内：属于排除分组时不覆盖，
+// 指定了分组时以分组成员关系为准，否则使用 fallback 的结果
+func (c *ServerGroupClass) InScope(serverID uint64, groupIDs, excludeGroupIDs []uint64, fallback func() bool) bool {
+	if c.InAny(serverID, excludeGroupIDs) {
+		return false
+	}
+	if len(groupIDs) > 0 {
+		return c.InAny(serverID, groupIDs)
+	}
+	return fallback()
+}
+
+// GetGroupNames 返回服务器所属分组的名称
+func (c *ServerGroupClass) GetGroupNames(serverID uint64) []string {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	var names []string
+	for gid, servers := range c.members {
+		if _, ok := servers[serverID]; ok && c.list[gid] != nil {
+			names = append(names, c.list[gid].Name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (c *ServerGroupClass) sortList() {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	sortedList := utils.MapValuesToSlice(c.list)
+	slices.SortFunc(sortedList, func(a, b *model.ServerGroup) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	c.sortedListMu.Lock()
+	defer c.sortedListMu.Unlock()
+	c.sortedList = sortedList
+}
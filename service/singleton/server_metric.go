@@ -0,0 +1,217 @@
+package singleton
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+const (
+	_MetricsMaxPoints = 720 // 未指定 step 时单次查询返回的最大数据点数
+)
+
+var metricTiers = []model.MetricTier{
+	model.MetricTierRaw,
+	model.MetricTierMinute,
+	model.MetricTierHour,
+	model.MetricTierDay,
+}
+
+// ServerMetricClass 服务器状态指标的持久化与降采样
+type ServerMetricClass struct {
+	pendingMu sync.Mutex
+	pending   []model.ServerMetric
+	lastRaw   map[uint64]time.Time
+}
+
+func NewServerMetricClass() (*ServerMetricClass, error) {
+	c := &ServerMetricClass{
+		lastRaw: make(map[uint64]time.Time),
+	}
+	if Conf.Metrics.Disable {
+		return c, nil
+	}
+
+	// 每分钟写入原始数据，并依次生成分钟、小时、天级汇总
+	if _, err := CronShared.AddFunc("0 * * * * *", c.tick); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *ServerMetricClass) rawInterval() time.Duration {
+	return time.Duration(Conf.Metrics.RawInterval) * time.Second
+}
+
+func (c *ServerMetricClass) retention(tier model.MetricTier) time.Duration {
+	switch tier {
+	case model.MetricTierMinute:
+		return time.Duration(Conf.Metrics.MinuteRetention) * time.Hour * 24
+	case model.MetricTierHour:
+		return time.Duration(Conf.Metrics.HourRetention) * time.Hour * 24
+	case model.MetricTierDay:
+		return time.Duration(Conf.Metrics.DayRetention) * time.Hour * 24
+	default:
+		return time.Duration(Conf.Metrics.RawRetention) * time.Hour
+	}
+}
+
+// Record 记录一次上报的服务器状态，按 RawInterval 限制原始数据的写入频率
+func (c *ServerMetricClass) Record(serverID uint64, state *model.HostState) {
+	if Conf.Metrics.Disable || state == nil {
+		return
+	}
+
+	now := time.Now()
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	if now.Sub(c.lastRaw[serverID]) < c.rawInterval() {
+		return
+	}
+	c.lastRaw[serverID] = now
+	c.pending = append(c.pending, model.NewServerMetric(serverID, state, now))
+}
+
+// Flush 将缓存的原始数据写入数据库
+func (c *ServerMetricClass) Flush() {
+	c.pendingMu.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.pendingMu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+	if err := DB.Create(&pending).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save server metrics: %v", err)
+	}
+}
+
+// Forget 清理已删除服务器的采样状态
+func (c *ServerMetricClass) Forget(idList []uint64) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	for _, id := range idList {
+		delete(c.lastRaw, id)
+	}
+}
+
+func (c *ServerMetricClass) tick() {
+	c.Flush()
+	c.rollupAt(time.Now())
+}
+
+// rollupAt 汇总 now 所在分钟之前的数据，整点汇总小时级数据，配置时区的零点汇总天级数据
+func (c *ServerMetricClass) rollupAt(now time.Time) {
+	minute := now.Truncate(time.Minute)
+	c.rollup(model.MetricTierRaw, model.MetricTierMinute, minute.Add(-time.Minute), minute)
+
+	if minute.Minute() == 0 {
+		c.rollup(model.MetricTierMinute, model.MetricTierHour, minute.Add(-time.Hour), minute)
+	}
+
+	// 天级汇总以配置的时区为准，时区偏移不是整小时时零点不在进程时区的整点
+	if day, ok := localMidnight(minute, Loc); ok {
+		c.rollup(model.MetricTierHour, model.MetricTierDay, day.AddDate(0, 0, -1), day)
+	}
+}
+
+// localMidnight 判断 t 是否为 loc 时区的零点
+func localMidnight(t time.Time, loc *time.Location) (time.Time, bool) {
+	local := t.In(loc)
+	if local.Hour() != 0 || local.Minute() != 0 {
+		return time.Time{}, false
+	}
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc), true
+}
+
+// rollup 将 [from, to) 区间内 src 层级的数据按服务器取平均值，写入 dst 层级
+func (c *ServerMetricClass) rollup(src, dst model.MetricTier, from, to time.Time) {
+	selects := make([]string, 0, len(model.ServerMetricFields)+1)
+	selects = append(selects, "server_id")
+	for _, field := range model.ServerMetricFields {
+		selects = append(selects, fmt.Sprintf("AVG(`%s`) AS `%s`", field, field))
+	}
+
+	var metrics []model.ServerMetric
+	if err := DB.Model(&model.ServerMetric{}).Select(strings.Join(selects, ", ")).
+		Where("tier = ? AND created_at >= ? AND created_at < ?", src, from, to).
+		Group("server_id").Scan(&metrics).Error; err != nil {
+		log.Printf("NEZHA>> Failed to roll up server metrics: %v", err)
+		return
+	}
+	if len(metrics) == 0 {
+		return
+	}
+
+	for i := range metrics {
+		metrics[i].Tier = dst
+		metrics[i].CreatedAt = from
+	}
+	if err := DB.Create(&metrics).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save server metrics: %v", err)
+	}
+}
+
+// pickTier 选择满足步长要求且保留时长能覆盖查询起点的最细粒度层级
+func (c *ServerMetricClass) pickTier(from time.Time, step time.Duration) model.MetricTier {
+	now := time.Now()
+	fallback := model.MetricTierRaw
+	for _, tier := range metricTiers {
+		if tier != model.MetricTierRaw && tier.Resolution(c.rawInterval()) > step {
+			break
+		}
+		fallback = tier
+		if !from.Before(now.Add(-c.retention(tier))) {
+			return tier
+		}
+	}
+	return fallback
+}
+
+// Query 查询单个指标在 [from, to] 区间内的数据，返回实际使用的层级与步长
+func (c *ServerMetricClass) Query(serverID uint64, metric string, from, to time.Time, step time.Duration) (model.MetricTier, time.Duration, []model.ServerMetricPoint, error) {
+	if !model.IsValidServerMetric(metric) {
+		return 0, 0, nil, Localizer.ErrorT("unknown metric: %s", metric)
+	}
+	if !from.Before(to) {
+		return 0, 0, nil, Localizer.ErrorT("invalid time range")
+	}
+
+	if step <= 0 {
+		step = max(to.Sub(from)/_MetricsMaxPoints, c.rawInterval())
+	}
+	tier := c.pickTier(from, step)
+	step = max(step, tier.Resolution(c.rawInterval()))
+
+	var rows []model.ServerMetric
+	if err := DB.Model(&model.ServerMetric{}).Select(fmt.Sprintf("created_at, `%s`", metric)).
+		Where("server_id = ? AND tier = ? AND created_at >= ? AND created_at <= ?", serverID, tier, from, to).
+		Order("created_at").Find(&rows).Error; err != nil {
+		return 0, 0, nil, err
+	}
+
+	points := make([]model.ServerMetricPoint, 0, len(rows))
+	for _, row := range rows {
+		points = append(points, model.ServerMetricPoint{
+			Timestamp: row.CreatedAt.UnixMilli(),
+			Value:     row.Value(metric),
+		})
+	}
+
+	return tier, step, model.DownsampleMetricPoints(points, step), nil
+}
+
+// CleanServerMetrics 按各层级保留时长清理过期指标，并清理已删除服务器的指标
+func CleanServerMetrics() {
+	DB.Unscoped().Delete(&model.ServerMetric{}, "server_id NOT IN (SELECT `id` FROM servers)")
+	for _, tier := range metricTiers {
+		DB.Unscoped().Delete(&model.ServerMetric{}, "tier = ? AND created_at < ?", tier, time.Now().Add(-ServerMetricShared.retention(tier)))
+	}
+}
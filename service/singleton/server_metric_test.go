@@ -0,0 +1,66 @@
+package singleton
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+func TestServerMetricDayRollupAtLocalMidnight(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.ServerMetric{}); err != nil {
+		t.Fatal(err)
+	}
+	prevDB, prevLoc := DB, Loc
+	DB, Loc = db, time.FixedZone("IST", 5*3600+30*60)
+	defer func() { DB, Loc = prevDB, prevLoc }()
+
+	// 2026-10-18 00:00 IST 即 2026-10-17 18:30 UTC，不是 UTC 的整点
+	midnight := time.Date(2026, 10, 17, 18, 30, 0, 0, time.UTC)
+	for _, h := range []time.Duration{-23 * time.Hour, -12 * time.Hour, -time.Hour} {
+		if err := DB.Create(&model.ServerMetric{ServerID: 1, Tier: model.MetricTierHour, CreatedAt: midnight.Add(h), CPU: 30}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &ServerMetricClass{lastRaw: make(map[uint64]time.Time)}
+	countDays := func() int64 {
+		var count int64
+		DB.Model(&model.ServerMetric{}).Where("tier = ?", model.MetricTierDay).Count(&count)
+		return count
+	}
+
+	// UTC 整点不是配置时区的零点，不做天级汇总
+	c.rollupAt(midnight.Add(-30 * time.Minute))
+	if n := countDays(); n != 0 {
+		t.Fatalf("expected no day rollup at 23:30 IST, got %d", n)
+	}
+
+	c.rollupAt(midnight.Add(10 * time.Second))
+	var days []model.ServerMetric
+	DB.Where("tier = ?", model.MetricTierDay).Find(&days)
+	if len(days) != 1 {
+		t.Fatalf("expected one day rollup at 00:00 IST, got %d", len(days))
+	}
+	if !days[0].CreatedAt.Equal(midnight.AddDate(0, 0, -1)) || days[0].CPU != 30 {
+		t.Fatalf("unexpected day rollup %+v", days[0])
+	}
+}
+
+func TestLocalMidnight(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+30*60)
+	if _, ok := localMidnight(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), ist); ok {
+		t.Fatal("00:00 UTC is 05:30 IST, not midnight")
+	}
+	day, ok := localMidnight(time.Date(2026, 10, 17, 18, 30, 0, 0, time.UTC), ist)
+	if !ok || !day.Equal(time.Date(2026, 10, 18, 0, 0, 0, 0, ist)) {
+		t.Fatalf("expected 18:30 UTC to be midnight in IST, got %v %v", day, ok)
+	}
+}
@@ -0,0 +1,121 @@
+package singleton
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+// serviceHistoryRetentionDays server_id = 0 的监控记录的保留天数，超出部分只保留每日汇总
+const serviceHistoryRetentionDays = 30
+
+// RollupServiceHistory 汇总今天与昨天的监控记录，今天的数据在每次执行时更新
+func RollupServiceHistory() {
+	today := localDay(time.Now())
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		if err := rollupServiceDay(day, true); err != nil {
+			log.Printf("NEZHA>> Failed to roll up service history of %s: %v", day.Format(time.DateOnly), err)
+		}
+	}
+}
+
+// BackfillServiceDailyStats 为监控记录保留期内尚未汇总的日期生成每日数据，用于升级后首次启动
+func BackfillServiceDailyStats() {
+	today := localDay(time.Now())
+	// 最早一天的记录可能已被部分清理，不参与汇总
+	for i := serviceHistoryRetentionDays - 1; i > 1; i-- {
+		day := today.AddDate(0, 0, -i)
+		if err := rollupServiceDay(day, false); err != nil {
+			log.Printf("NEZHA>> Failed to roll up service history of %s: %v", day.Format(time.DateOnly), err)
+		}
+	}
+	RollupServiceHistory()
+}
+
+// rollupServiceDay 汇总 day 当天的监控记录，overwrite 为 false 时不覆盖已有的汇总
+func rollupServiceDay(day time.Time, overwrite bool) error {
+	stats, err := serviceDailyStatsFromHistory(nil, day)
+	if err != nil || len(stats) == 0 {
+		return err
+	}
+
+	onConflict := clause.OnConflict{
+		Columns: []clause.Column{{Name: "service_id"}, {Name: "date"}},
+	}
+	if overwrite {
+		onConflict.DoUpdates = clause.AssignmentColumns([]string{"up", "down", "avg_delay", "p95_delay", "updated_at"})
+	} else {
+		onConflict.DoNothing = true
+	}
+	return DB.Clauses(onConflict).Create(&stats).Error
+}
+
+// serviceDailyStatsFromHistory 直接从监控记录计算 day 当天的每日数据，serviceIDs 为空时计算所有服务
+func serviceDailyStatsFromHistory(serviceIDs []uint64, day time.Time) ([]model.ServiceDailyStat, error) {
+	query := DB.Model(&model.ServiceHistory{}).Select("service_id, avg_delay, up, down").
+		Where("server_id = 0 AND created_at >= ? AND created_at < ?", day, day.AddDate(0, 0, 1))
+	if len(serviceIDs) > 0 {
+		query = query.Where("service_id IN (?)", serviceIDs)
+	}
+	var histories []model.ServiceHistory
+	if err := query.Order("service_id").Scan(&histories).Error; err != nil {
+		return nil, err
+	}
+
+	date := day.Format(time.DateOnly)
+	var stats []model.ServiceDailyStat
+	for start := 0; start < len(histories); {
+		end := start
+		for end < len(histories) && histories[end].ServiceID == histories[start].ServiceID {
+			end++
+		}
+		stats = append(stats, model.NewServiceDailyStat(histories[start].ServiceID, date, histories[start:end]))
+		start = end
+	}
+	return stats, nil
+}
+
+// ServiceDailyStats 返回服务在 [from, to] 日期范围内的每日数据，按服务分组并按日期升序。
+// 今天的数据直接从监控记录计算，以免汇总任务的延迟
+func ServiceDailyStats(serviceIDs []uint64, from, to time.Time) (map[uint64][]model.ServiceDailyStat, error) {
+	from, to = localDay(from), localDay(to)
+	res := make(map[uint64][]model.ServiceDailyStat, len(serviceIDs))
+	if len(serviceIDs) == 0 || to.Before(from) {
+		return res, nil
+	}
+
+	today := localDay(time.Now())
+	var stats []model.ServiceDailyStat
+	if err := DB.Where("service_id IN (?) AND date >= ? AND date <= ? AND date < ?", serviceIDs,
+		from.Format(time.DateOnly), to.Format(time.DateOnly), today.Format(time.DateOnly)).
+		Order("date").Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	if !today.Before(from) && !to.Before(today) {
+		live, err := serviceDailyStatsFromHistory(serviceIDs, today)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, live...)
+	}
+
+	for _, s := range stats {
+		res[s.ServiceID] = append(res[s.ServiceID], s)
+	}
+	return res, nil
+}
+
+// CleanServiceDailyStats 清理超出保留时长以及已删除服务的每日数据
+func CleanServiceDailyStats() {
+	before := localDay(time.Now()).AddDate(0, 0, -int(Conf.ServiceStatRetention))
+	DB.Unscoped().Delete(&model.ServiceDailyStat{}, "date < ? OR service_id NOT IN (SELECT `id` FROM services)", before.Format(time.DateOnly))
+}
+
+// localDay 返回 t 在面板时区当天的零点
+func localDay(t time.Time) time.Time {
+	year, month, day := t.In(Loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, Loc)
+}
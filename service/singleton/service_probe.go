@@ -0,0 +1,70 @@
+package singleton
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/probe"
+)
+
+// DashboardReporter 面板自身作为监测点时的 ID，不会分配给服务器，也不与汇总记录使用的 0 冲突
+const DashboardReporter uint64 = math.MaxInt64
+
+const (
+	dashboardProbeConcurrency = 16
+	dashboardProbeTimeout     = time.Second * 10
+)
+
+// ProbeFromDashboard 由面板执行一次服务监控，结果与 Agent 上报的结果一样进入服务状态汇报管道
+func (ss *ServiceSentinel) ProbeFromDashboard(service *model.Service) {
+	if !probe.Supported(service.Type) {
+		return
+	}
+
+	select {
+	case ss.probeSem <- struct{}{}:
+	default:
+		log.Printf("NEZHA>> Dashboard probe for service %d skipped: too many probes in progress", service.ID)
+		return
+	}
+
+	task := service.CheckPB()
+	go func() {
+		defer func() { <-ss.probeSem }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), dashboardProbeTimeout)
+		defer cancel()
+
+		ss.Dispatch(ReportData{
+			Data:     probe.Run(ctx, task),
+			Reporter: DashboardReporter,
+		})
+	}()
+}
+
+// reporterName 监测点的名称
+func reporterName(servers map[uint64]*model.Server, reporter uint64) string {
+	if reporter == DashboardReporter {
+		return Localizer.T("Dashboard")
+	}
+	if server, ok := servers[reporter]; ok {
+		return server.Name
+	}
+	return ""
+}
+
+// reporterServerID 监测点对应的服务器 ID，面板自身作为监测点时为 0
+func reporterServerID(reporter uint64) uint64 {
+	if reporter == DashboardReporter {
+		return 0
+	}
+	return reporter
+}
+
+// hasCertInfo 判断监控结果中是否包含证书信息
+func hasCertInfo(taskType uint64) bool {
+	return taskType == model.TaskTypeHTTPGet || taskType == model.TaskTypeTLS
+}
@@ -0,0 +1,172 @@
+package singleton
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+// reporterResult 单个监测点的一次监控结果
+type reporterResult struct {
+	successful bool
+	t          time.Time
+}
+
+// reporterStatus 单个监测点在统计窗口内的监控结果
+type reporterStatus struct {
+	results  []reporterResult
+	failures uint64 // 连续失败的次数
+}
+
+// add 记录监控结果并移除窗口外的结果
+func (rs *reporterStatus) add(successful bool, now time.Time, window time.Duration) {
+	rs.results = append(rs.results, reporterResult{successful: successful, t: now})
+	if successful {
+		rs.failures = 0
+	} else {
+		rs.failures++
+	}
+	rs.prune(now, window)
+}
+
+func (rs *reporterStatus) prune(now time.Time, window time.Duration) {
+	i := 0
+	for i < len(rs.results) && (now.Sub(rs.results[i].t) > window || len(rs.results)-i > model.ServiceMaxWindowSamples) {
+		i++
+	}
+	rs.results = rs.results[i:]
+}
+
+func (rs *reporterStatus) counts() (up, down uint64) {
+	for _, r := range rs.results {
+		if r.successful {
+			up++
+		} else {
+			down++
+		}
+	}
+	return
+}
+
+// status 监测点在窗口内的状态
+func (rs *reporterStatus) status(service *model.Service) uint8 {
+	up, down := rs.counts()
+	if up+down == 0 {
+		return StatusNoData
+	}
+	if up == 0 {
+		return StatusDown
+	}
+	return ServiceStatusCode(service, float32(up)*100/float32(up+down))
+}
+
+// reporterWindow 监测点状态的统计窗口，与汇总状态的窗口一致，且至少包含三次检查
+func reporterWindow(service *model.Service) time.Duration {
+	return max(service.StatusWindow(), 3*time.Duration(service.Duration)*time.Second)
+}
+
+// recordReporterResult 记录监测点的监控结果，调用时需持有 serviceResponseDataStoreLock
+func (ss *ServiceSentinel) recordReporterResult(service *model.Service, reporter uint64, mh *pb.TaskResult, now time.Time) {
+	status := ss.serviceCurrentStatusData[mh.GetId()]
+	if status.reporters == nil {
+		status.reporters = make(map[uint64]*reporterStatus)
+	}
+	rs, ok := status.reporters[reporter]
+	if !ok {
+		rs = &reporterStatus{}
+		status.reporters[reporter] = rs
+	}
+	rs.add(mh.Successful, now, reporterWindow(service))
+}
+
+// quorumStatus 按监测点数量判定服务状态，未设置 Quorum 时返回汇总状态。
+// 达到 Quorum 时为故障；未达到时汇总状态最多为低可用，避免单个监测点的网络问题导致服务被判定为故障
+func (ss *ServiceSentinel) quorumStatus(service *model.Service, stateCode uint8, now time.Time) uint8 {
+	if service.Quorum == 0 {
+		return stateCode
+	}
+
+	window := reporterWindow(service)
+	var failing, active int
+	for _, rs := range ss.serviceCurrentStatusData[service.ID].reporters {
+		rs.prune(now, window)
+		switch rs.status(service) {
+		case StatusNoData:
+			continue
+		case StatusDown:
+			failing++
+		}
+		active++
+	}
+
+	if service.QuorumReached(failing, active) {
+		return StatusDown
+	}
+	if stateCode == StatusDown {
+		return StatusLowAvailability
+	}
+	return stateCode
+}
+
+// downConfirmed 判断故障是否已确认：连续失败达到阈值的监测点数量达到 Quorum，
+// 未设置 Quorum 时只需一个监测点达到阈值
+func (ss *ServiceSentinel) downConfirmed(service *model.Service, now time.Time) bool {
+	window := reporterWindow(service)
+	threshold := service.DownFailureCount()
+	var confirmed, active int
+	for _, rs := range ss.serviceCurrentStatusData[service.ID].reporters {
+		rs.prune(now, window)
+		if len(rs.results) == 0 {
+			continue
+		}
+		active++
+		if rs.failures >= threshold {
+			confirmed++
+		}
+	}
+
+	if service.Quorum == 0 {
+		return confirmed > 0
+	}
+	return service.QuorumReached(confirmed, active)
+}
+
+// failedReporters 窗口内检查失败过的监测点及其失败次数，按失败次数排序
+func (ss *ServiceSentinel) failedReporters(serviceID uint64, servers map[uint64]*model.Server) []string {
+	status := ss.serviceCurrentStatusData[serviceID]
+	if status == nil {
+		return nil
+	}
+
+	type failure struct {
+		name     string
+		up, down uint64
+		reporter uint64
+	}
+	var failures []failure
+	for _, id := range slices.Sorted(maps.Keys(status.reporters)) {
+		up, down := status.reporters[id].counts()
+		if down == 0 {
+			continue
+		}
+		failures = append(failures, failure{name: reporterName(servers, id), up: up, down: down, reporter: id})
+	}
+	slices.SortStableFunc(failures, func(a, b failure) int {
+		return cmp.Compare(b.down, a.down)
+	})
+
+	reporters := make([]string, 0, len(failures))
+	for _, f := range failures {
+		name := f.name
+		if name == "" {
+			name = fmt.Sprintf("#%d", f.reporter)
+		}
+		reporters = append(reporters, fmt.Sprintf("%s (%d/%d)", name, f.down, f.up+f.down))
+	}
+	return reporters
+}
@@ -13,7 +13,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
-	"golang.org/x/exp/constraints"
 
 	"github.com/nezhahq/nezha/model"
 	"github.com/nezhahq/nezha/pkg/utils"
@@ -21,7 +20,7 @@ import (
 )
 
 const (
-	_CurrentStatusSize = 30 // 统计 15 分钟内的数据为当前状态
+	_CurrentStatusSize = model.ServiceDefaultWindowSamples // 默认统计 15 分钟内的数据为当前状态
 )
 
 type serviceResponseItem struct {
@@ -44,10 +43,18 @@ type _TodayStatsOfService struct {
 
 type serviceResponseData = _TodayStatsOfService
 
+// ServiceReporterStats 单个监测点自启动以来的监控结果统计
+type ServiceReporterStats struct {
+	Up    uint64  // 成功次数
+	Down  uint64  // 失败次数
+	Delay float32 // 最近一次延迟
+}
+
 type serviceTaskStatus struct {
 	lastStatus uint8
 	t          time.Time
 	result     []*pb.TaskResult
+	reporters  map[uint64]*reporterStatus // [reporter] -> 各监测点窗口内的结果
 }
 
 type pingStore struct {
@@ -72,8 +79,9 @@ type ServiceSentinel struct {
 	serviceCurrentStatusData     map[uint64]*serviceTaskStatus    // 当前任务结果缓存
 	serviceResponseDataStore     map[uint64]serviceResponseData   // 当前数据
 
-	serviceResponsePing map[uint64]map[uint64]*pingStore // [service_id] -> ClientID -> delay
-	tlsCertCache        map[uint64]string
+	serviceResponsePing  map[uint64]map[uint64]*pingStore            // [service_id] -> ClientID -> delay
+	serviceReporterStats map[uint64]map[uint64]*ServiceReporterStats // [service_id] -> ClientID -> stats
+	tlsCertCache         map[uint64]string
 
 	servicesLock    sync.RWMutex
 	serviceListLock sync.RWMutex
@@ -83,6 +91,9 @@ type ServiceSentinel struct {
 	// 30天数据缓存
 	monthlyStatusLock sync.Mutex
 	monthlyStatus     map[uint64]*serviceResponseItem
+
+	// 面板监测点并发执行的监控数量
+	probeSem chan struct{}
 }
 
 // NewServiceSentinel 创建服务监控器
@@ -93,11 +104,13 @@ func NewServiceSentinel(serviceSentinelDispatchBus chan<- *model.Service) (*Serv
 		serviceCurrentStatusData: make(map[uint64]*serviceTaskStatus),
 		serviceResponseDataStore: make(map[uint64]serviceResponseData),
 		serviceResponsePing:      make(map[uint64]map[uint64]*pingStore),
+		serviceReporterStats:     make(map[uint64]map[uint64]*ServiceReporterStats),
 		services:                 make(map[uint64]*model.Service),
 		tlsCertCache:             make(map[uint64]string),
 		// 30天数据缓存
 		monthlyStatus: make(map[uint64]*serviceResponseItem),
 		dispatchBus:   serviceSentinelDispatchBus,
+		probeSem:      make(chan struct{}, dashboardProbeConcurrency),
 	}
 
 	// 加载历史记录
@@ -171,6 +184,26 @@ func (ss *ServiceSentinel) Dispatch(r ReportData) {
 	ss.serviceReportChannel <- r
 }
 
+// ReportQueueStats 返回服务状态汇报管道的积压数量与容量
+func (ss *ServiceSentinel) ReportQueueStats() (length, capacity int) {
+	return len(ss.serviceReportChannel), cap(ss.serviceReportChannel)
+}
+
+// CopyReporterStats 返回各监测点的监控结果统计副本
+func (ss *ServiceSentinel) CopyReporterStats() map[uint64]map[uint64]ServiceReporterStats {
+	ss.serviceResponseDataStoreLock.RLock()
+	defer ss.serviceResponseDataStoreLock.RUnlock()
+
+	ret := make(map[uint64]map[uint64]ServiceReporterStats, len(ss.serviceReporterStats))
+	for serviceID, reporters := range ss.serviceReporterStats {
+		ret[serviceID] = make(map[uint64]ServiceReporterStats, len(reporters))
+		for reporter, stats := range reporters {
+			ret[serviceID][reporter] = *stats
+		}
+	}
+	return ret
+}
+
 func (ss *ServiceSentinel) UpdateServiceList() {
 	ss.servicesLock.RLock()
 	defer ss.servicesLock.RUnlock()
@@ -295,6 +328,7 @@ func (ss *ServiceSentinel) Delete(ids []uint64) {
 		delete(ss.serviceResponseDataStore, id)
 		delete(ss.tlsCertCache, id)
 		delete(ss.serviceStatusToday, id)
+		delete(ss.serviceReporterStats, id)
 
 		// 停掉定时任务
 		CronShared.Remove(ss.services[id].CronJobID)
@@ -302,6 +336,7 @@ func (ss *ServiceSentinel) Delete(ids []uint64) {
 
 		delete(ss.monthlyStatus, id)
 	}
+	IncidentShared.ResolveByRule(model.IncidentSourceService, ids)
 }
 
 func (ss *ServiceSentinel) LoadStats() map[uint64]*serviceResponseItem {
@@ -357,6 +392,17 @@ func (ss *ServiceSentinel) CopyStats() map[uint64]model.ServiceResponseItem {
 	return sri
 }
 
+// CurrentStatus 返回服务监控最近一次判定的状态
+func (ss *ServiceSentinel) CurrentStatus(id uint64) uint8 {
+	ss.serviceResponseDataStoreLock.RLock()
+	defer ss.serviceResponseDataStoreLock.RUnlock()
+
+	if status, ok := ss.serviceCurrentStatusData[id]; ok && status.lastStatus != 0 {
+		return status.lastStatus
+	}
+	return StatusNoData
+}
+
 func (ss *ServiceSentinel) Get(id uint64) (s *model.Service, ok bool) {
 	ss.servicesLock.RLock()
 	defer ss.servicesLock.RUnlock()
@@ -405,7 +451,7 @@ func (ss *ServiceSentinel) worker() {
 		css = nil
 
 		mh := r.Data
-		if mh.Type == model.TaskTypeTCPPing || mh.Type == model.TaskTypeICMPPing {
+		if model.IsLatencyTask(mh.Type) {
 			serviceTcpMap, ok := ss.serviceResponsePing[mh.GetId()]
 			if !ok {
 				serviceTcpMap = make(map[uint64]*pingStore)
@@ -443,14 +489,34 @@ func (ss *ServiceSentinel) worker() {
 			ss.serviceStatusToday[mh.GetId()].Down++
 		}
 
+		// 写入监测点统计
+		reporterStats, ok := ss.serviceReporterStats[mh.GetId()]
+		if !ok {
+			reporterStats = make(map[uint64]*ServiceReporterStats)
+			ss.serviceReporterStats[mh.GetId()] = reporterStats
+		}
+		rs, ok := reporterStats[r.Reporter]
+		if !ok {
+			rs = &ServiceReporterStats{}
+			reporterStats[r.Reporter] = rs
+		}
+		if mh.Successful {
+			rs.Up++
+		} else {
+			rs.Down++
+		}
+		rs.Delay = mh.Delay
+
+		cs, _ := ss.Get(mh.GetId())
 		currentTime := time.Now()
+		ss.recordReporterResult(cs, r.Reporter, mh, currentTime)
 		if ss.serviceCurrentStatusData[mh.GetId()].t.IsZero() {
 			ss.serviceCurrentStatusData[mh.GetId()].t = currentTime
 		}
 
 		// 写入当前数据
 		if ss.serviceCurrentStatusData[mh.GetId()].t.Before(currentTime) {
-			ss.serviceCurrentStatusData[mh.GetId()].t = currentTime.Add(30 * time.Second)
+			ss.serviceCurrentStatusData[mh.GetId()].t = currentTime.Add(cs.SampleGate())
 			ss.serviceCurrentStatusData[mh.GetId()].result = append(ss.serviceCurrentStatusData[mh.GetId()].result, mh)
 		}
 
@@ -474,22 +540,30 @@ func (ss *ServiceSentinel) worker() {
 		// 计算在线率，
 		var stateCode uint8
 		{
-			upPercent := uint64(0)
+			upPercent := float32(0)
 			rd := ss.serviceResponseDataStore[mh.GetId()]
 			if rd.Down+rd.Up > 0 {
-				upPercent = rd.Up * 100 / (rd.Down + rd.Up)
+				upPercent = float32(rd.Up) * 100 / float32(rd.Down+rd.Up)
 			}
-			stateCode = GetStatusCode(upPercent)
+			stateCode = ss.quorumStatus(cs, ServiceStatusCode(cs, upPercent), currentTime)
 		}
 
 		// 数据持久化
-		if len(ss.serviceCurrentStatusData[mh.GetId()].result) == _CurrentStatusSize {
+		if len(ss.serviceCurrentStatusData[mh.GetId()].result) >= cs.StatusWindowSize() {
 			ss.serviceCurrentStatusData[mh.GetId()].t = currentTime
 			rd := ss.serviceResponseDataStore[mh.GetId()]
+			// 区间内有失败时记录最近一次的失败原因
+			data := mh.Data
+			for _, cs := range slices.Backward(ss.serviceCurrentStatusData[mh.GetId()].result) {
+				if !cs.Successful {
+					data = cs.Data
+					break
+				}
+			}
 			if err := DB.Create(&model.ServiceHistory{
 				ServiceID: mh.GetId(),
 				AvgDelay:  rd.Delay,
-				Data:      mh.Data,
+				Data:      data,
 				Up:        rd.Up,
 				Down:      rd.Down,
 			}).Error; err != nil {
@@ -499,20 +573,37 @@ func (ss *ServiceSentinel) worker() {
 			ss.serviceCurrentStatusData[mh.GetId()].result = ss.serviceCurrentStatusData[mh.GetId()].result[:0]
 		}
 
-		cs, _ := ss.Get(mh.GetId())
 		m := ServerShared.GetList()
+		// 服务或监测点处于维护期间时，只记录状态，不发送通知与触发任务
+		inMaintenance := MaintenanceShared.ServiceInMaintenance(mh.GetId()) ||
+			(r.Reporter != DashboardReporter && MaintenanceShared.ServerInMaintenance(r.Reporter))
 		// 延迟报警
-		if mh.Delay > 0 {
+		if mh.Delay > 0 && !inMaintenance {
 			delayCheck(&r, m, cs, mh)
 		}
 
+		// 监测点连续失败次数未达到阈值时，不认为服务已故障
+		confirmed := stateCode != StatusDown || ss.downConfirmed(cs, currentTime)
+
 		// 状态变更报警+触发任务执行
-		if stateCode == StatusDown || stateCode != ss.serviceCurrentStatusData[mh.GetId()].lastStatus {
+		if confirmed && (stateCode == StatusDown || stateCode != ss.serviceCurrentStatusData[mh.GetId()].lastStatus) {
 			lastStatus := ss.serviceCurrentStatusData[mh.GetId()].lastStatus
 			// 存储新的状态值
 			ss.serviceCurrentStatusData[mh.GetId()].lastStatus = stateCode
 
-			notifyCheck(&r, m, cs, mh, lastStatus, stateCode)
+			var duration time.Duration
+			switch stateCode {
+			case StatusDown:
+				IncidentShared.Open(model.IncidentSourceService, mh.GetId(), 0, cs.UserID,
+					cs.Name, NotificationMuteLabel.ServiceStateChanged(mh.GetId()))
+				duration = IncidentShared.Since(NotificationMuteLabel.ServiceStateChanged(mh.GetId()))
+			case StatusGood:
+				duration = IncidentShared.Resolve(NotificationMuteLabel.ServiceStateChanged(mh.GetId()))
+			}
+
+			if !inMaintenance {
+				notifyCheck(&r, m, cs, mh, lastStatus, stateCode, duration, ss.failedReporters(mh.GetId(), m))
+			}
 		}
 		ss.serviceResponseDataStoreLock.Unlock()
 
@@ -524,9 +615,13 @@ func (ss *ServiceSentinel) worker() {
 				!strings.HasSuffix(mh.Data, "EOF") &&
 				!strings.HasSuffix(mh.Data, "timed out") {
 				errMsg = mh.Data
-				if cs.Notify {
+				if cs.Notify && !inMaintenance {
 					muteLabel := NotificationMuteLabel.ServiceTLS(mh.GetId(), "network")
-					go NotificationShared.SendNotification(cs.NotificationGroupID, Localizer.Tf("[TLS] Fetch cert info failed, Reporter: %s, Error: %s", cs.Name, errMsg), muteLabel)
+					event := tlsEvent(cs.Name, model.NotificationSeverityWarning)
+					event.ReporterID = reporterServerID(r.Reporter)
+					event.Error = errMsg
+					event.Reporter = reporterName(m, r.Reporter)
+					go NotificationShared.SendNotification(cs.NotificationGroupID, event, Localizer.Tf("[TLS] Fetch cert info failed, Reporter: %s, Error: %s", cs.Name, errMsg), muteLabel)
 				}
 			}
 		} else {
@@ -534,8 +629,8 @@ func (ss *ServiceSentinel) worker() {
 			NotificationShared.UnMuteNotification(cs.NotificationGroupID, NotificationMuteLabel.ServiceTLS(mh.GetId(), "network"))
 
 			var newCert = strings.Split(mh.Data, "|")
-			if len(newCert) > 1 {
-				enableNotify := cs.Notify
+			if hasCertInfo(mh.Type) && len(newCert) > 1 {
+				enableNotify := cs.Notify && !inMaintenance
 
 				// 首次获取证书信息时，缓存证书信息
 				if ss.tlsCertCache[mh.GetId()] == "" {
@@ -569,7 +664,11 @@ func (ss *ServiceSentinel) worker() {
 						// 静音规则： 服务id+证书过期时间
 						// 用于避免多个监测点对相同证书同时报警
 						muteLabel := NotificationMuteLabel.ServiceTLS(mh.GetId(), fmt.Sprintf("expire_%s", expiresTimeStr))
-						go NotificationShared.SendNotification(notificationGroupID, fmt.Sprintf("[TLS] %s %s", serviceName, errMsg), muteLabel)
+						event := tlsEvent(serviceName, model.NotificationSeverityWarning)
+						event.ReporterID = reporterServerID(r.Reporter)
+						event.CertIssuer = newCert[0]
+						event.CertExpiry = &expiresNew
+						go NotificationShared.SendNotification(notificationGroupID, event, fmt.Sprintf("[TLS] %s %s", serviceName, errMsg), muteLabel)
 					}
 
 					// 证书变更提醒
@@ -579,7 +678,11 @@ func (ss *ServiceSentinel) worker() {
 							oldCert[0], expiresOld.Format("2006-01-02 15:04:05"), newCert[0], expiresNew.Format("2006-01-02 15:04:05"))
 
 						// 证书变更后会自动更新缓存，所以不需要静音
-						go NotificationShared.SendNotification(notificationGroupID, fmt.Sprintf("[TLS] %s %s", serviceName, errMsg), "")
+						event := tlsEvent(serviceName, model.NotificationSeverityInfo)
+						event.ReporterID = reporterServerID(r.Reporter)
+						event.CertIssuer = newCert[0]
+						event.CertExpiry = &expiresNew
+						go NotificationShared.SendNotification(notificationGroupID, event, fmt.Sprintf("[TLS] %s %s", serviceName, errMsg), "")
 					}
 				}
 			}
@@ -597,14 +700,14 @@ func delayCheck(r *ReportData, m map[uint64]*model.Server, ss *model.Service, mh
 	maxMuteLabel := NotificationMuteLabel.ServiceLatencyMax(mh.GetId())
 	if mh.Delay > ss.MaxLatency {
 		// 延迟超过最大值
-		reporterServer := m[r.Reporter]
-		msg := Localizer.Tf("[Latency] %s %2f > %2f, Reporter: %s", ss.Name, mh.Delay, ss.MaxLatency, reporterServer.Name)
-		go NotificationShared.SendNotification(notificationGroupID, msg, minMuteLabel)
+		reporter := reporterName(m, r.Reporter)
+		msg := Localizer.Tf("[Latency] %s %2f > %2f, Reporter: %s", ss.Name, mh.Delay, ss.MaxLatency, reporter)
+		go NotificationShared.SendNotification(notificationGroupID, latencyEvent(ss, mh, r.Reporter, reporter), msg, minMuteLabel)
 	} else if mh.Delay < ss.MinLatency {
 		// 延迟低于最小值
-		reporterServer := m[r.Reporter]
-		msg := Localizer.Tf("[Latency] %s %2f < %2f, Reporter: %s", ss.Name, mh.Delay, ss.MinLatency, reporterServer.Name)
-		go NotificationShared.SendNotification(notificationGroupID, msg, maxMuteLabel)
+		reporter := reporterName(m, r.Reporter)
+		msg := Localizer.Tf("[Latency] %s %2f < %2f, Reporter: %s", ss.Name, mh.Delay, ss.MinLatency, reporter)
+		go NotificationShared.SendNotification(notificationGroupID, latencyEvent(ss, mh, r.Reporter, reporter), msg, maxMuteLabel)
 	} else {
 		// 正常延迟， 清除静音缓存
 		NotificationShared.UnMuteNotification(notificationGroupID, minMuteLabel)
@@ -613,13 +716,16 @@ func delayCheck(r *ReportData, m map[uint64]*model.Server, ss *model.Service, mh
 }
 
 func notifyCheck(r *ReportData, m map[uint64]*model.Server,
-	ss *model.Service, mh *pb.TaskResult, lastStatus, stateCode uint8) {
+	ss *model.Service, mh *pb.TaskResult, lastStatus, stateCode uint8, duration time.Duration, failedReporters []string) {
 	// 判断是否需要发送通知
 	isNeedSendNotification := ss.Notify && (lastStatus != 0 || stateCode == StatusDown)
 	if isNeedSendNotification {
-		reporterServer := m[r.Reporter]
+		reporter := reporterName(m, r.Reporter)
 		notificationGroupID := ss.NotificationGroupID
-		notificationMsg := Localizer.Tf("[%s] %s Reporter: %s, Error: %s", StatusCodeToString(stateCode), ss.Name, reporterServer.Name, mh.Data)
+		notificationMsg := Localizer.Tf("[%s] %s Reporter: %s, Error: %s", StatusCodeToString(stateCode), ss.Name, reporter, mh.Data)
+		if len(failedReporters) > 0 {
+			notificationMsg += Localizer.Tf(", Failed reporters: %s", strings.Join(failedReporters, ", "))
+		}
 		muteLabel := NotificationMuteLabel.ServiceStateChanged(mh.GetId())
 
 		// 状态变更时，清除静音缓存
@@ -627,19 +733,27 @@ func notifyCheck(r *ReportData, m map[uint64]*model.Server,
 			NotificationShared.UnMuteNotification(notificationGroupID, muteLabel)
 		}
 
-		go NotificationShared.SendNotification(notificationGroupID, notificationMsg, muteLabel)
+		event := serviceEvent(ss.Name, serviceStateSeverity(stateCode, lastStatus))
+		event.ReporterID = reporterServerID(r.Reporter)
+		event.State = StatusCodeToString(stateCode)
+		event.PrevState = StatusCodeToString(lastStatus)
+		event.Duration = duration
+		event.Reporter = reporter
+		event.Reporters = failedReporters
+		event.Error = mh.Data
+		event.Latency = mh.Delay
+		go NotificationShared.SendNotification(notificationGroupID, event, notificationMsg, muteLabel)
 	}
 
 	// 判断是否需要触发任务
 	isNeedTriggerTask := ss.EnableTriggerTask && lastStatus != 0
 	if isNeedTriggerTask {
-		reporterServer := m[r.Reporter]
 		if stateCode == StatusGood && lastStatus != stateCode {
 			// 当前状态正常 前序状态非正常时 触发恢复任务
-			go CronShared.SendTriggerTasks(ss.RecoverTriggerTasks, reporterServer.ID)
+			go CronShared.SendTriggerTasks(ss.RecoverTriggerTasks, r.Reporter)
 		} else if lastStatus == StatusGood && lastStatus != stateCode {
 			// 前序状态正常 当前状态非正常时 触发失败任务
-			go CronShared.SendTriggerTasks(ss.FailTriggerTasks, reporterServer.ID)
+			go CronShared.SendTriggerTasks(ss.FailTriggerTasks, r.Reporter)
 		}
 	}
 }
@@ -652,14 +766,66 @@ const (
 	StatusDown
 )
 
-func GetStatusCode[T constraints.Float | constraints.Integer](percent T) uint8 {
+// serviceEvent 服务监控的通知事件
+func serviceEvent(name string, severity uint8) model.NotificationEvent {
+	return model.NotificationEvent{
+		Source:   model.NotificationSourceService,
+		Rule:     name,
+		Severity: severity,
+	}
+}
+
+// latencyEvent 服务监控延迟超出范围的通知事件
+func latencyEvent(ss *model.Service, mh *pb.TaskResult, reporterID uint64, reporter string) model.NotificationEvent {
+	event := serviceEvent(ss.Name, model.NotificationSeverityWarning)
+	event.Reporter = reporter
+	event.ReporterID = reporterServerID(reporterID)
+	event.Latency = mh.Delay
+	event.Metrics = []model.NotificationMetric{{
+		Type:  "latency",
+		Value: float64(mh.Delay),
+		Min:   float64(ss.MinLatency),
+		Max:   float64(ss.MaxLatency),
+	}}
+	return event
+}
+
+// tlsEvent TLS 证书的通知事件
+func tlsEvent(name string, severity uint8) model.NotificationEvent {
+	return model.NotificationEvent{
+		Source:   model.NotificationSourceTLS,
+		Rule:     name,
+		Severity: severity,
+	}
+}
+
+// serviceStateSeverity 服务状态变更的通知级别，恢复通知使用所恢复状态的级别，
+// 只接收严重级别的路由同样能收到对应的恢复通知
+func serviceStateSeverity(stateCode, lastStatus uint8) uint8 {
+	return max(stateSeverity(stateCode), stateSeverity(lastStatus))
+}
+
+func stateSeverity(stateCode uint8) uint8 {
+	switch stateCode {
+	case StatusDown:
+		return model.NotificationSeverityCritical
+	case StatusLowAvailability:
+		return model.NotificationSeverityWarning
+	default:
+		return model.NotificationSeverityInfo
+	}
+}
+
+// ServiceStatusCode 按服务设置的可用率阈值返回状态
+func ServiceStatusCode(service *model.Service, percent float32) uint8 {
+	good, degraded := service.StatusThresholds()
 	if percent == 0 {
 		return StatusNoData
 	}
-	if percent > 95 {
+	if percent > good {
 		return StatusGood
 	}
-	if percent > 80 {
+	if percent > degraded {
 		return StatusLowAvailability
 	}
 	return StatusDown
@@ -34,6 +34,15 @@ var (
 	NotificationShared    *NotificationClass
 	NATShared             *NATClass
 	CronShared            *CronClass
+	ServerMetricShared    *ServerMetricClass
+	ServerGroupShared     *ServerGroupClass
+	MaintenanceShared     *MaintenanceClass
+	IncidentShared        *IncidentClass
+	StatusPageShared      *StatusPageClass
+	AgentCredentialShared *AgentCredentialClass
+	PendingServerShared   *PendingServerClass
+	AgentCAShared         *AgentCAClass
+	TaskQueueShared       *TaskQueueClass
 )
 
 //go:embed frontend-templates.yaml
@@ -58,7 +67,24 @@ func LoadSingleton(bus chan<- *model.Service) (err error) {
 	DDNSShared = NewDDNSClass()
 	NotificationShared = NewNotificationClass()
 	ServerShared = NewServerClass()
+	if ServerGroupShared, err = NewServerGroupClass(); err != nil {
+		return
+	}
+	if TaskQueueShared, err = NewTaskQueueClass(); err != nil {
+		return
+	}
+	MaintenanceShared = NewMaintenanceClass()
+	IncidentShared = NewIncidentClass()
 	CronShared = NewCronClass()
+	if ServerMetricShared, err = NewServerMetricClass(); err != nil {
+		return
+	}
+	StatusPageShared = NewStatusPageClass()
+	AgentCredentialShared = NewAgentCredentialClass()
+	PendingServerShared = NewPendingServerClass()
+	if AgentCAShared, err = NewAgentCAClass(); err != nil {
+		return
+	}
 	// 最后初始化 ServiceSentinel
 	ServiceSentinelShared, err = NewServiceSentinel(bus)
 	return
@@ -89,7 +115,13 @@ func InitDBFromPath(path string) error {
 		model.Notification{}, model.AlertRule{}, model.Service{}, model.NotificationGroupNotification{},
 		model.ServiceHistory{}, model.Cron{}, model.Transfer{}, model.ServerGroupServer{},
 		model.NAT{}, model.DDNSProfile{}, model.NotificationGroupNotification{},
-		model.WAF{}, model.Oauth2Bind{})
+		model.WAF{}, model.Oauth2Bind{}, model.ServerMetric{},
+		model.Maintenance{}, model.Incident{}, model.IncidentComment{},
+		model.AlertState{}, model.NotificationDelivery{}, model.NotificationDeadLetter{},
+		model.StatusPage{}, model.StatusPageIncident{}, model.StatusPageIncidentUpdate{},
+		model.ServiceDailyStat{}, model.AgentEnrollmentToken{}, model.ServerCredential{},
+		model.PendingServer{}, model.AgentCA{}, model.AgentCertificate{},
+		model.AgentTask{})
 	if err != nil {
 		return err
 	}
@@ -133,11 +165,13 @@ func RecordTransferHourlyUsage(servers ...*model.Server) {
 // CleanServiceHistory 清理无效或过时的 监控记录 和 流量记录
 func CleanServiceHistory() {
 	// 清理已被删除的服务器的监控记录与流量记录
-	DB.Unscoped().Delete(&model.ServiceHistory{}, "created_at < ? OR service_id NOT IN (SELECT `id` FROM services)", time.Now().AddDate(0, 0, -30))
+	// 超出保留期的可用性数据由每日汇总保存
+	DB.Unscoped().Delete(&model.ServiceHistory{}, "created_at < ? OR service_id NOT IN (SELECT `id` FROM services)", time.Now().AddDate(0, 0, -serviceHistoryRetentionDays))
 	// 由于网络监控记录的数据较多，并且前端仅使用了 1 天的数据
 	// 考虑到 sqlite 数据量问题，仅保留一天数据，
 	// server_id = 0 的数据会用于/service页面的可用性展示
 	DB.Unscoped().Delete(&model.ServiceHistory{}, "(created_at < ? AND server_id != 0) OR service_id NOT IN (SELECT `id` FROM services)", time.Now().AddDate(0, 0, -1))
+	CleanServiceDailyStats()
 	DB.Unscoped().Delete(&model.Transfer{}, "server_id NOT IN (SELECT `id` FROM servers)")
 	// 计算可清理流量记录的时长
 	var allServerKeep time.Time
@@ -0,0 +1,273 @@
+package singleton
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+const (
+	statusPageHistoryDays     = 90
+	statusPageRecentIncidents = 7 * 24 * time.Hour // 已结束的公告在状态页中保留展示的时长
+	statusPageCacheTTL        = 30 * time.Second
+)
+
+// StatusPageClass 对外公开的状态页
+type StatusPageClass struct {
+	class[uint64, *model.StatusPage]
+}
+
+func NewStatusPageClass() *StatusPageClass {
+	var sortedList []*model.StatusPage
+
+	DB.Find(&sortedList)
+	list := make(map[uint64]*model.StatusPage, len(sortedList))
+	for _, p := range sortedList {
+		list[p.ID] = p
+	}
+
+	return &StatusPageClass{
+		class: class[uint64, *model.StatusPage]{
+			list:       list,
+			sortedList: sortedList,
+		},
+	}
+}
+
+func (c *StatusPageClass) Update(p *model.StatusPage) {
+	c.listMu.Lock()
+	if old, ok := c.list[p.ID]; ok {
+		c.Invalidate(old.Slug)
+	}
+	c.list[p.ID] = p
+	c.listMu.Unlock()
+
+	c.Invalidate(p.Slug)
+	c.sortList()
+}
+
+func (c *StatusPageClass) Delete(idList []uint64) {
+	c.listMu.Lock()
+	for _, id := range idList {
+		if p, ok := c.list[id]; ok {
+			c.Invalidate(p.Slug)
+		}
+		delete(c.list, id)
+	}
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+// GetBySlug 按访问路径查找状态页
+func (c *StatusPageClass) GetBySlug(slug string) (*model.StatusPage, bool) {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	for _, p := range c.list {
+		if p.Slug == slug {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Invalidate 清除状态页的公开数据缓存
+func (c *StatusPageClass) Invalidate(slug string) {
+	Cache.Delete(statusPageCacheKey(slug))
+}
+
+func statusPageCacheKey(slug string) string {
+	return "status-page:" + slug
+}
+
+// Summary 返回状态页的公开数据，结果缓存 30 秒
+func (c *StatusPageClass) Summary(p *model.StatusPage) (*model.StatusPageResponse, error) {
+	if cached, ok := Cache.Get(statusPageCacheKey(p.Slug)); ok {
+		return cached.(*model.StatusPageResponse), nil
+	}
+
+	res, err := c.summary(p)
+	if err != nil {
+		return nil, err
+	}
+	Cache.Set(statusPageCacheKey(p.Slug), res, statusPageCacheTTL)
+	return res, nil
+}
+
+type serviceDailyCount struct {
+	up, down [statusPageHistoryDays]uint64
+}
+
+func (c *StatusPageClass) summary(p *model.StatusPage) (*model.StatusPageResponse, error) {
+	now := time.Now().In(Loc)
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, Loc)
+	since := today.AddDate(0, 0, 1-statusPageHistoryDays)
+
+	// 可用性按每日汇总计算，超出汇总保留期的部分不计入
+	daily, err := ServiceDailyStats(p.ServiceIDs(), since, today)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint64]*serviceDailyCount)
+	for id, stats := range daily {
+		sc := &serviceDailyCount{}
+		for _, s := range stats {
+			date, err := time.ParseInLocation(time.DateOnly, s.Date, Loc)
+			if err != nil {
+				continue
+			}
+			idx := dayIndex(since, date)
+			if idx < 0 || idx >= statusPageHistoryDays {
+				continue
+			}
+			sc.up[idx] += s.Up
+			sc.down[idx] += s.Down
+		}
+		counts[id] = sc
+	}
+
+	res := &model.StatusPageResponse{
+		Name:        p.Name,
+		Description: p.Description,
+		Status:      StatusNoData,
+		Components:  make([]model.StatusPageComponentStatus, 0, len(p.Components)),
+		GeneratedAt: now,
+	}
+	for _, component := range p.Components {
+		cs := model.StatusPageComponentStatus{
+			Name:        component.Name,
+			Description: component.Description,
+			Status:      StatusNoData,
+			Daily:       make([]model.StatusPageDailyUptime, statusPageHistoryDays),
+		}
+		var total serviceDailyCount
+		for _, id := range component.Services {
+			service, ok := ServiceSentinelShared.Get(id)
+			if !ok {
+				continue
+			}
+			sc := counts[id]
+			if sc == nil {
+				sc = &serviceDailyCount{}
+			}
+			for i := range statusPageHistoryDays {
+				total.up[i] += sc.up[i]
+				total.down[i] += sc.down[i]
+			}
+
+			ss := model.StatusPageServiceStatus{
+				Name:          service.Name,
+				Status:        ServiceSentinelShared.CurrentStatus(id),
+				InMaintenance: MaintenanceShared.ServiceInMaintenance(id),
+				Uptime:        sc.uptime(),
+			}
+			cs.Status = max(cs.Status, ss.Status)
+			cs.InMaintenance = cs.InMaintenance || ss.InMaintenance
+			cs.Services = append(cs.Services, ss)
+		}
+		cs.Uptime = total.uptime()
+		for i := range statusPageHistoryDays {
+			cs.Daily[i] = model.StatusPageDailyUptime{
+				Date: since.AddDate(0, 0, i).Format(time.DateOnly),
+				Up:   total.up[i],
+				Down: total.down[i],
+			}
+		}
+		res.Status = max(res.Status, cs.Status)
+		res.Components = append(res.Components, cs)
+	}
+
+	incidents, err := StatusPageIncidents(p.ID, now.Add(-statusPageRecentIncidents), 0)
+	if err != nil {
+		return nil, err
+	}
+	res.Incidents = incidents
+	return res, nil
+}
+
+// dayIndex 返回 t 相对 since 的天数，按日期计算以兼容夏令时
+func dayIndex(since, t time.Time) int {
+	y, m, d := t.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, since.Location())
+	return int(day.Sub(since).Round(24*time.Hour) / (24 * time.Hour))
+}
+
+func (sc *serviceDailyCount) uptime() model.StatusPageUptime {
+	sum := func(days int) *float64 {
+		var up, down uint64
+		for i := statusPageHistoryDays - days; i < statusPageHistoryDays; i++ {
+			up += sc.up[i]
+			down += sc.down[i]
+		}
+		if up+down == 0 {
+			return nil
+		}
+		percent := float64(up) * 100 / float64(up+down)
+		return &percent
+	}
+	return model.StatusPageUptime{Day7: sum(7), Day30: sum(30), Day90: sum(90)}
+}
+
+// StatusPageIncidents 返回状态页的公告及其更新记录，按开始时间倒序。
+// resolvedAfter 不为零时只返回未结束或在该时间之后结束的公告，limit 为 0 时不限制数量
+func StatusPageIncidents(statusPageID uint64, resolvedAfter time.Time, limit int) ([]*model.StatusPageIncident, error) {
+	query := DB.Where("status_page_id = ?", statusPageID)
+	if !resolvedAfter.IsZero() {
+		query = query.Where("resolved_at IS NULL OR resolved_at >= ?", resolvedAfter)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	incidents := make([]*model.StatusPageIncident, 0)
+	if err := query.Order("started_at DESC, id DESC").Find(&incidents).Error; err != nil {
+		return nil, err
+	}
+	if err := LoadStatusPageIncidentUpdates(incidents); err != nil {
+		return nil, err
+	}
+	return incidents, nil
+}
+
+// LoadStatusPageIncidentUpdates 加载公告的更新记录，按时间倒序
+func LoadStatusPageIncidentUpdates(incidents []*model.StatusPageIncident) error {
+	if len(incidents) == 0 {
+		return nil
+	}
+	ids := make([]uint64, 0, len(incidents))
+	byID := make(map[uint64]*model.StatusPageIncident, len(incidents))
+	for _, i := range incidents {
+		ids = append(ids, i.ID)
+		byID[i.ID] = i
+	}
+
+	var updates []model.StatusPageIncidentUpdate
+	if err := DB.Where("incident_id IN (?)", ids).Order("id DESC").Find(&updates).Error; err != nil {
+		return err
+	}
+	for _, u := range updates {
+		if i, ok := byID[u.IncidentID]; ok {
+			i.Updates = append(i.Updates, u)
+		}
+	}
+	return nil
+}
+
+func (c *StatusPageClass) sortList() {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	sortedList := utils.MapValuesToSlice(c.list)
+	slices.SortFunc(sortedList, func(a, b *model.StatusPage) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	c.sortedListMu.Lock()
+	defer c.sortedListMu.Unlock()
+	c.sortedList = sortedList
+}
@@ -0,0 +1,438 @@
+package singleton
+
+import (
+	"cmp"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+const (
+	agentTaskQueueSize   = 256 // 每台服务器排队任务的上限
+	agentTaskHistorySize = 100 // 每台服务器保留的已跟踪任务数量
+)
+
+// agentTaskSendTimeout 需要立即执行的任务等待发送的时长
+var agentTaskSendTimeout = 10 * time.Second
+
+// TaskQueueClass 为每台服务器维护任务队列，任务流上只有一个写协程发送任务
+type TaskQueueClass struct {
+	mu     sync.RWMutex
+	queues map[uint64]*agentTaskQueue
+	nextID atomic.Uint64
+}
+
+type agentTaskQueue struct {
+	serverID uint64
+
+	mu      sync.Mutex
+	pending []*agentTaskEntry
+	history []*agentTaskEntry // 已跟踪的任务，按投递 ID 递增
+	stream  pb.NezhaService_RequestTaskServer
+	stop    chan struct{}
+
+	wake chan struct{}
+}
+
+type agentTaskEntry struct {
+	queue   *agentTaskQueue
+	task    model.AgentTask // 由 queue.mu 保护
+	tracked bool
+	done    chan struct{} // 任务发送或过期后关闭
+}
+
+type enqueueOptions struct {
+	ttl        time.Duration
+	online     bool // Agent 离线时直接返回错误
+	tracked    bool // 记录投递状态，可通过接口查询
+	persistent bool
+}
+
+func NewTaskQueueClass() (*TaskQueueClass, error) {
+	q := &TaskQueueClass{
+		queues: make(map[uint64]*agentTaskQueue),
+	}
+
+	var maxID uint64
+	if err := DB.Model(&model.AgentTask{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error; err != nil {
+		return nil, err
+	}
+	q.nextID.Store(maxID)
+
+	var tasks []*model.AgentTask
+	if err := DB.Order("id").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var stale []uint64
+	for _, t := range tasks {
+		if _, ok := ServerShared.Get(t.ServerID); !ok || t.Expired(now) {
+			stale = append(stale, t.ID)
+			continue
+		}
+		t.Persistent = true
+		aq := q.queue(t.ServerID)
+		e := &agentTaskEntry{queue: aq, task: *t, tracked: true, done: make(chan struct{})}
+		aq.pending = append(aq.pending, e)
+		aq.history = append(aq.history, e)
+	}
+	if len(stale) > 0 {
+		if err := DB.Delete(&model.AgentTask{}, "id IN (?)", stale).Error; err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+func (q *TaskQueueClass) queue(serverID uint64) *agentTaskQueue {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	aq, ok := q.queues[serverID]
+	if !ok {
+		aq = &agentTaskQueue{serverID: serverID, wake: make(chan struct{}, 1)}
+		q.queues[serverID] = aq
+	}
+	return aq
+}
+
+// Attach 绑定服务器的任务流并启动写协程，任务流结束时调用返回的函数解除绑定
+func (q *TaskQueueClass) Attach(serverID uint64, stream pb.NezhaService_RequestTaskServer) func() {
+	aq := q.queue(serverID)
+	stop := make(chan struct{})
+
+	aq.mu.Lock()
+	if aq.stop != nil {
+		close(aq.stop)
+	}
+	aq.stream, aq.stop = stream, stop
+	aq.mu.Unlock()
+
+	go q.write(aq, stream, stop)
+	aq.notify()
+
+	return func() {
+		aq.mu.Lock()
+		defer aq.mu.Unlock()
+		if aq.stop == stop {
+			close(stop)
+			aq.stream, aq.stop = nil, nil
+		}
+	}
+}
+
+// Post 向在线的 Agent 发送任务，不跟踪投递状态，用于保活、服务监控等周期性任务
+func (q *TaskQueueClass) Post(server *model.Server, task *pb.Task) error {
+	_, err := q.enqueue(server, task, enqueueOptions{ttl: agentTaskSendTimeout, online: true})
+	return err
+}
+
+// Send 向在线的 Agent 发送任务并等待写协程写入任务流，用于终端、文件管理等需要立即执行的任务。
+// 这些任务不会回报结果，因此不跟踪投递状态
+func (q *TaskQueueClass) Send(server *model.Server, task *pb.Task) error {
+	e, err := q.enqueue(server, task, enqueueOptions{ttl: agentTaskSendTimeout, online: true})
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(agentTaskSendTimeout)
+	defer timer.Stop()
+	select {
+	case <-e.done:
+	case <-timer.C:
+	}
+
+	e.queue.mu.Lock()
+	defer e.queue.mu.Unlock()
+	if e.task.Status == model.AgentTaskQueued || e.task.Status == model.AgentTaskExpired {
+		return Localizer.ErrorT("operation timeout")
+	}
+	return nil
+}
+
+// Enqueue 将命令放入队列，Agent 离线时等待其重新连接，超过 AgentCommandTTL 仍未发送则放弃。
+// 只有命令会回报结果，其他类型的任务不跟踪投递状态
+func (q *TaskQueueClass) Enqueue(server *model.Server, task *pb.Task) (uint64, error) {
+	e, err := q.enqueue(server, task, enqueueOptions{
+		ttl:        time.Duration(Conf.AgentCommandTTL) * time.Minute,
+		tracked:    task.GetType() == model.TaskTypeCommand,
+		persistent: Conf.PersistAgentCommands,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return e.task.ID, nil
+}
+
+func (q *TaskQueueClass) enqueue(server *model.Server, task *pb.Task, opts enqueueOptions) (*agentTaskEntry, error) {
+	aq := q.queue(server.ID)
+	now := time.Now()
+
+	aq.mu.Lock()
+	if opts.online && aq.stream == nil {
+		aq.mu.Unlock()
+		return nil, Localizer.ErrorT("server not found or not connected")
+	}
+	if len(aq.pending) >= agentTaskQueueSize {
+		aq.mu.Unlock()
+		return nil, Localizer.ErrorT("task queue is full")
+	}
+
+	e := &agentTaskEntry{
+		queue: aq,
+		task: model.AgentTask{
+			Common: model.Common{
+				ID:        q.nextID.Add(1),
+				CreatedAt: now,
+				UpdatedAt: now,
+				UserID:    server.UserID,
+			},
+			ServerID:   server.ID,
+			Type:       task.GetType(),
+			TaskID:     task.GetId(),
+			Data:       task.GetData(),
+			Persistent: opts.persistent,
+			ExpiresAt:  now.Add(opts.ttl),
+		},
+		tracked: opts.tracked,
+		done:    make(chan struct{}),
+	}
+	// 先入库再入队，避免写协程发送后删除记录早于写入
+	if opts.persistent {
+		if err := DB.Create(&e.task).Error; err != nil {
+			aq.mu.Unlock()
+			return nil, err
+		}
+	}
+	aq.pending = append(aq.pending, e)
+	if opts.tracked {
+		aq.track(e)
+	}
+	aq.mu.Unlock()
+
+	aq.notify()
+	return e, nil
+}
+
+func (q *TaskQueueClass) write(aq *agentTaskQueue, stream pb.NezhaService_RequestTaskServer, stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-aq.wake:
+		}
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			e, task, expired := aq.next(time.Now())
+			q.finish(expired)
+			if e == nil {
+				break
+			}
+
+			if err := stream.Send(task); err != nil {
+				// 任务流已断开，放回队首等待 Agent 重新连接
+				aq.requeue(e)
+				log.Printf("NEZHA>> Failed to send task to server %d: %v", aq.serverID, err)
+				return
+			}
+			if persistent := aq.sent(e, time.Now()); persistent {
+				if err := DB.Delete(&model.AgentTask{}, e.task.ID).Error; err != nil {
+					log.Printf("NEZHA>> Failed to delete sent task %d: %v", e.task.ID, err)
+				}
+			}
+		}
+	}
+}
+
+// Ack 将 Agent 回报的结果关联到最早发送且尚未确认的同类任务。
+// 同一计划任务多次发送时按发送顺序确认，Agent 重启等原因未回报的发送会占用之后的结果
+func (q *TaskQueueClass) Ack(serverID uint64, r *pb.TaskResult) {
+	q.mu.RLock()
+	aq, ok := q.queues[serverID]
+	q.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	aq.mu.Lock()
+	defer aq.mu.Unlock()
+
+	for _, e := range aq.history {
+		if e.task.Acknowledges(r) {
+			now := time.Now()
+			e.task.Status = model.AgentTaskAcknowledged
+			e.task.AckedAt = &now
+			e.task.UpdatedAt = now
+			e.task.Successful = r.GetSuccessful()
+			return
+		}
+	}
+}
+
+// ExpireTasks 清理离线服务器队列中已过期的任务
+func (q *TaskQueueClass) ExpireTasks() {
+	q.mu.RLock()
+	queues := make([]*agentTaskQueue, 0, len(q.queues))
+	for _, aq := range q.queues {
+		queues = append(queues, aq)
+	}
+	q.mu.RUnlock()
+
+	now := time.Now()
+	for _, aq := range queues {
+		aq.mu.Lock()
+		expired := aq.expire(now)
+		aq.mu.Unlock()
+		q.finish(expired)
+	}
+}
+
+func (q *TaskQueueClass) finish(expired []model.AgentTask) {
+	var ids []uint64
+	for _, t := range expired {
+		if t.Persistent {
+			ids = append(ids, t.ID)
+		}
+		if t.Type == model.TaskTypeCommand {
+			notifyCommandExpired(&t)
+		}
+	}
+	if len(ids) > 0 {
+		if err := DB.Delete(&model.AgentTask{}, "id IN (?)", ids).Error; err != nil {
+			log.Printf("NEZHA>> Failed to delete expired tasks: %v", err)
+		}
+	}
+}
+
+// GetSortedList 按投递 ID 排序的已跟踪任务，serverID 为 0 时返回所有服务器的任务
+func (q *TaskQueueClass) GetSortedList(serverID uint64) []*model.AgentTask {
+	q.mu.RLock()
+	queues := make([]*agentTaskQueue, 0, len(q.queues))
+	for id, aq := range q.queues {
+		if serverID == 0 || id == serverID {
+			queues = append(queues, aq)
+		}
+	}
+	q.mu.RUnlock()
+
+	var list []*model.AgentTask
+	for _, aq := range queues {
+		aq.mu.Lock()
+		for _, e := range aq.history {
+			t := e.task
+			list = append(list, &t)
+		}
+		aq.mu.Unlock()
+	}
+	slices.SortFunc(list, func(a, b *model.AgentTask) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return list
+}
+
+// Delete 删除服务器时丢弃其队列
+func (q *TaskQueueClass) Delete(idList []uint64) {
+	q.mu.Lock()
+	var queues []*agentTaskQueue
+	for _, id := range idList {
+		if aq, ok := q.queues[id]; ok {
+			queues = append(queues, aq)
+			delete(q.queues, id)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, aq := range queues {
+		aq.mu.Lock()
+		if aq.stop != nil {
+			close(aq.stop)
+			aq.stream, aq.stop = nil, nil
+		}
+		for _, e := range aq.pending {
+			e.task.Status = model.AgentTaskExpired
+			close(e.done)
+		}
+		aq.pending = nil
+		aq.mu.Unlock()
+	}
+
+	if err := DB.Delete(&model.AgentTask{}, "server_id IN (?)", idList).Error; err != nil {
+		log.Printf("NEZHA>> Failed to delete tasks of deleted servers: %v", err)
+	}
+}
+
+func (aq *agentTaskQueue) notify() {
+	select {
+	case aq.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next 取出下一个待发送的任务，同时返回已过期的任务
+func (aq *agentTaskQueue) next(now time.Time) (*agentTaskEntry, *pb.Task, []model.AgentTask) {
+	aq.mu.Lock()
+	defer aq.mu.Unlock()
+
+	expired := aq.expire(now)
+	if len(aq.pending) == 0 {
+		return nil, nil, expired
+	}
+	e := aq.pending[0]
+	aq.pending = aq.pending[1:]
+	return e, e.task.PB(), expired
+}
+
+func (aq *agentTaskQueue) expire(now time.Time) []model.AgentTask {
+	var expired []model.AgentTask
+	aq.pending = slices.DeleteFunc(aq.pending, func(e *agentTaskEntry) bool {
+		if !e.task.Expired(now) {
+			return false
+		}
+		e.task.Status = model.AgentTaskExpired
+		e.task.UpdatedAt = now
+		close(e.done)
+		expired = append(expired, e.task)
+		return true
+	})
+	return expired
+}
+
+func (aq *agentTaskQueue) requeue(e *agentTaskEntry) {
+	aq.mu.Lock()
+	defer aq.mu.Unlock()
+	aq.pending = slices.Insert(aq.pending, 0, e)
+}
+
+func (aq *agentTaskQueue) sent(e *agentTaskEntry, now time.Time) (persistent bool) {
+	aq.mu.Lock()
+	defer aq.mu.Unlock()
+
+	e.task.Status = model.AgentTaskSent
+	e.task.SentAt = &now
+	e.task.UpdatedAt = now
+	close(e.done)
+	return e.task.Persistent
+}
+
+// track 记录需要跟踪的任务，超出上限时丢弃最早的已结束任务
+func (aq *agentTaskQueue) track(e *agentTaskEntry) {
+	aq.history = append(aq.history, e)
+	for i := 0; len(aq.history) > agentTaskHistorySize && i < len(aq.history); {
+		if aq.history[i].task.Status == model.AgentTaskQueued {
+			i++
+			continue
+		}
+		aq.history = slices.Delete(aq.history, i, i+1)
+	}
+}
@@ -0,0 +1,236 @@
+package singleton
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/i18n"
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+// fakeTaskStream 记录写入的任务，send 不为 nil 时由其决定发送结果
+type fakeTaskStream struct {
+	pb.NezhaService_RequestTaskServer
+	tasks chan *pb.Task
+	send  func(*pb.Task) error
+}
+
+func newFakeTaskStream(send func(*pb.Task) error) *fakeTaskStream {
+	return &fakeTaskStream{tasks: make(chan *pb.Task, 16), send: send}
+}
+
+func (s *fakeTaskStream) Send(task *pb.Task) error {
+	if s.send != nil {
+		if err := s.send(task); err != nil {
+			return err
+		}
+	}
+	s.tasks <- task
+	return nil
+}
+
+func (s *fakeTaskStream) expect(t *testing.T, taskID uint64) {
+	t.Helper()
+	select {
+	case task := <-s.tasks:
+		if task.GetId() != taskID {
+			t.Fatalf("expected task %d, got %d", taskID, task.GetId())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("task %d was not sent", taskID)
+	}
+}
+
+func (s *fakeTaskStream) expectNone(t *testing.T) {
+	t.Helper()
+	select {
+	case task := <-s.tasks:
+		t.Fatalf("unexpected task %d", task.GetId())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func newTestTaskQueue(t *testing.T) *TaskQueueClass {
+	t.Helper()
+	if Localizer == nil {
+		Localizer = i18n.NewLocalizer("en_US", domain, "translations", i18n.Translations)
+	}
+	return &TaskQueueClass{queues: make(map[uint64]*agentTaskQueue)}
+}
+
+func entryStatus(e *agentTaskEntry) uint8 {
+	e.queue.mu.Lock()
+	defer e.queue.mu.Unlock()
+	return e.task.Status
+}
+
+func waitClosed(t *testing.T, done chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("task was not finished")
+	}
+}
+
+var testTaskServer = &model.Server{Common: model.Common{ID: 1}}
+
+func TestTaskQueueAttachHandoff(t *testing.T) {
+	q := newTestTaskQueue(t)
+
+	if err := q.Post(testTaskServer, &pb.Task{Id: 1}); err == nil {
+		t.Fatal("expected post to an offline server to fail")
+	}
+
+	first := newFakeTaskStream(nil)
+	detachFirst := q.Attach(testTaskServer.ID, first)
+	if err := q.Post(testTaskServer, &pb.Task{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+	first.expect(t, 1)
+
+	// 新的任务流接管后，旧任务流不再收到任务，其解除绑定也不影响新任务流
+	second := newFakeTaskStream(nil)
+	detachSecond := q.Attach(testTaskServer.ID, second)
+	detachFirst()
+	if err := q.Post(testTaskServer, &pb.Task{Id: 2}); err != nil {
+		t.Fatal(err)
+	}
+	second.expect(t, 2)
+	first.expectNone(t)
+
+	detachSecond()
+	if err := q.Post(testTaskServer, &pb.Task{Id: 3}); err == nil {
+		t.Fatal("expected post after detach to fail")
+	}
+}
+
+func TestTaskQueueRequeueOnSendFailure(t *testing.T) {
+	q := newTestTaskQueue(t)
+
+	attempted := make(chan struct{}, 1)
+	broken := newFakeTaskStream(func(*pb.Task) error {
+		attempted <- struct{}{}
+		return errors.New("stream closed")
+	})
+	detachBroken := q.Attach(testTaskServer.ID, broken)
+
+	e, err := q.enqueue(testTaskServer, &pb.Task{Id: 1, Type: model.TaskTypeCommand}, enqueueOptions{ttl: time.Minute, tracked: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-attempted:
+	case <-time.After(time.Second):
+		t.Fatal("task was not sent")
+	}
+	detachBroken()
+	if status := entryStatus(e); status != model.AgentTaskQueued {
+		t.Fatalf("expected task to be requeued, got status %d", status)
+	}
+
+	stream := newFakeTaskStream(nil)
+	defer q.Attach(testTaskServer.ID, stream)()
+	stream.expect(t, 1)
+	waitClosed(t, e.done)
+	if status := entryStatus(e); status != model.AgentTaskSent {
+		t.Fatalf("expected task to be sent, got status %d", status)
+	}
+
+	q.Ack(testTaskServer.ID, &pb.TaskResult{Id: 1, Type: model.TaskTypeCommand, Successful: true})
+	list := q.GetSortedList(testTaskServer.ID)
+	if len(list) != 1 || list[0].Status != model.AgentTaskAcknowledged || !list[0].Successful {
+		t.Fatalf("expected task to be acknowledged, got %+v", list)
+	}
+}
+
+func TestTaskQueueExpire(t *testing.T) {
+	q := newTestTaskQueue(t)
+
+	e, err := q.enqueue(testTaskServer, &pb.Task{Id: 1, Type: model.TaskTypeReportConfig}, enqueueOptions{ttl: time.Millisecond, tracked: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	q.ExpireTasks()
+	waitClosed(t, e.done)
+	if status := entryStatus(e); status != model.AgentTaskExpired {
+		t.Fatalf("expected task to expire, got status %d", status)
+	}
+
+	// 过期的任务不会在 Agent 重新连接后发送
+	stream := newFakeTaskStream(nil)
+	defer q.Attach(testTaskServer.ID, stream)()
+	stream.expectNone(t)
+}
+
+func TestTaskQueueSendTimeout(t *testing.T) {
+	q := newTestTaskQueue(t)
+
+	timeout := agentTaskSendTimeout
+	agentTaskSendTimeout = 50 * time.Millisecond
+	defer func() { agentTaskSendTimeout = timeout }()
+
+	release := make(chan struct{})
+	stream := newFakeTaskStream(func(*pb.Task) error {
+		<-release
+		return nil
+	})
+	defer q.Attach(testTaskServer.ID, stream)()
+
+	start := time.Now()
+	if err := q.Send(testTaskServer, &pb.Task{Id: 1, Type: model.TaskTypeTerminalGRPC}); err == nil {
+		t.Fatal("expected send to time out while the stream is blocked")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("send took %v", elapsed)
+	}
+	close(release)
+	stream.expect(t, 1)
+
+	if err := q.Send(testTaskServer, &pb.Task{Id: 2, Type: model.TaskTypeTerminalGRPC}); err != nil {
+		t.Fatal(err)
+	}
+	stream.expect(t, 2)
+	if list := q.GetSortedList(testTaskServer.ID); len(list) != 0 {
+		t.Fatalf("tasks sent with Send should not be tracked, got %+v", list)
+	}
+}
+
+func TestTaskQueueDelete(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.AgentTask{}); err != nil {
+		t.Fatal(err)
+	}
+	prevDB := DB
+	DB = db
+	defer func() { DB = prevDB }()
+
+	q := newTestTaskQueue(t)
+	e, err := q.enqueue(testTaskServer, &pb.Task{Id: 1, Type: model.TaskTypeCommand}, enqueueOptions{ttl: time.Minute, tracked: true, persistent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q.Delete([]uint64{testTaskServer.ID})
+	waitClosed(t, e.done)
+	if status := entryStatus(e); status != model.AgentTaskExpired {
+		t.Fatalf("expected deleted task to be expired, got status %d", status)
+	}
+	var count int64
+	DB.Model(&model.AgentTask{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("expected persisted tasks to be deleted, got %d", count)
+	}
+	if list := q.GetSortedList(testTaskServer.ID); len(list) != 0 {
+		t.Fatalf("expected queue to be removed, got %+v", list)
+	}
+}
@@ -101,6 +101,18 @@ func OnUserDelete(id []uint64, errorFunc func(string, ...any) error) error {
 				return err
 			}
 
+			if err := tx.Unscoped().Delete(&model.ServerMetric{}, "server_id in (?)", servers).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Unscoped().Delete(&model.AgentEnrollmentToken{}, "user_id = ?", uid).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Unscoped().Delete(&model.PendingServer{}, "user_id = ?", uid).Error; err != nil {
+				return err
+			}
+
 			if err := tx.Where("id IN (?)", id).Delete(&model.User{}).Error; err != nil {
 				return err
 			}
@@ -115,6 +127,8 @@ func OnUserDelete(id []uint64, errorFunc func(string, ...any) error) error {
 			CronShared.Delete(crons)
 		}
 
+		PendingServerShared.Delete(model.FindByUserID(PendingServerShared.GetSortedList(), uid))
+
 		if server {
 			AlertsLock.Lock()
 			for _, sid := range servers {
@@ -128,6 +142,15 @@ func OnUserDelete(id []uint64, errorFunc func(string, ...any) error) error {
 			}
 			AlertsLock.Unlock()
 			ServerShared.Delete(servers)
+			ServerMetricShared.Forget(servers)
+			AgentCredentialShared.Delete(servers)
+			if err := AgentCAShared.RevokeByServer(servers); err != nil {
+				return errorFunc("%v", err)
+			}
+			IncidentShared.ResolveByServer(servers)
+			if err := ServerGroupShared.Reload(); err != nil {
+				return errorFunc("%v", err)
+			}
 		}
 
 		secret := UserInfoMap[uid].AgentSecret